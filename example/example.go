@@ -5,7 +5,7 @@ import (
 	"fmt"
 
 	"github.com/fnproject/fn_go"
-	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/pager"
 	"github.com/fnproject/fn_go/provider"
 )
 
@@ -21,31 +21,15 @@ func main() {
 		panic(err.Error())
 	}
 
-	appClient := currentProvider.APIClientv2().Apps
-
 	ctx := context.Background()
 
-	var cursor string
-	for {
-		params := &apps.ListAppsParams{
-			Context: ctx,
-		}
-		if cursor != "" {
-			params.Cursor = &cursor
-		}
-
-		gotApps, err := appClient.ListApps(params)
-		if err != nil {
-			panic(err.Error())
-		}
-
-		for _, app := range gotApps.Payload.Items {
-			fmt.Printf("App %s\n", app.Name)
-		}
-
-		if gotApps.Payload.NextCursor == "" {
-			break
-		}
-		cursor = gotApps.Payload.NextCursor
+	// The iterator follows NextCursor for us, fetching pages as they are needed
+	appIterator := pager.Apps(ctx, currentProvider.APIClientv2(), nil, pager.Options{PageSize: 50})
+	for appIterator.Next() {
+		fmt.Printf("App %s\n", appIterator.App().Name)
+	}
+
+	if err := appIterator.Err(); err != nil {
+		panic(err.Error())
 	}
 }
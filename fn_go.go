@@ -3,24 +3,33 @@ package fn_go
 import (
 	"github.com/fnproject/fn_go/provider"
 	"github.com/fnproject/fn_go/provider/defaultprovider"
+	"github.com/fnproject/fn_go/provider/execprovider"
 	"github.com/fnproject/fn_go/provider/oracle"
 )
 
 const (
 	// provider names
-	DefaultProvider  = "default"
-	OracleProvider   = "oracle"
-	OracleIPProvider = "oracle-ip"
-	OracleCSProvider = "oracle-cs"
+	DefaultProvider    = "default"
+	OracleProvider     = "oracle"
+	OracleIPProvider   = "oracle-ip"
+	OracleCSProvider   = "oracle-cs"
+	OracleAutoProvider = "oracle-auto"
+	OracleRPProvider   = "oracle-rp"
+	OracleOKEProvider  = "oracle-oke"
+	ExecProvider       = "exec"
 )
 
 // DefaultProviders includes the bundled providers available in the client
 var DefaultProviders = provider.Providers{
 	Providers: map[string]provider.ProviderFunc{
-		"":               defaultprovider.NewFromConfig,
-		DefaultProvider:  defaultprovider.NewFromConfig,
-		OracleProvider:   oracle.NewFromConfig,
-		OracleIPProvider: oracle.NewIPProvider,
-		OracleCSProvider: oracle.NewCSProvider,
+		"":                 defaultprovider.NewFromConfig,
+		DefaultProvider:    defaultprovider.NewFromConfig,
+		OracleProvider:     oracle.NewFromConfig,
+		OracleIPProvider:   oracle.NewIPProvider,
+		OracleCSProvider:   oracle.NewCSProvider,
+		OracleAutoProvider: oracle.NewAutoProvider,
+		OracleRPProvider:   oracle.NewRPProvider,
+		OracleOKEProvider:  oracle.NewOKEProvider,
+		ExecProvider:       execprovider.NewFromConfig,
 	},
 }
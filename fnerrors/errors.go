@@ -0,0 +1,179 @@
+// Package fnerrors provides a single error type for failed Fn API calls, independent of the provider that made them.
+//
+// Provider API clients return the original errors (e.g. a generated *apps.GetAppNotFound or an OCI
+// common.ServiceError), which the helpers in this package (IsNotFound, StatusCode etc.) classify whatever their type,
+// and AsError converts into an *Error. Errors that have been wrapped into an *Error, by Wrap or provider.WrapErrors,
+// keep the original error available through errors.As.
+package fnerrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/go-openapi/runtime"
+	"github.com/oracle/oci-go-sdk/v65/common"
+)
+
+// ErrUnsupported is matched by errors.Is for operations that the current provider does not support
+var ErrUnsupported = errors.New("operation not supported")
+
+// CodeUnsupported is the Code of errors created by NewUnsupported
+const CodeUnsupported = "Unsupported"
+
+// Error describes a failed API call
+type Error struct {
+	// StatusCode is the HTTP status of the failed call, or 0 if no response was received
+	StatusCode int
+	// Code is a service-specific error code (e.g. the OCI error code) if one was returned
+	Code string
+	// Message is the error message returned by the service
+	Message string
+	// RequestID identifies the failed request, if known
+	RequestID string
+	// Err is the underlying error
+	Err error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("%s (status %d)", e.Message, e.StatusCode)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is allows errors created by NewUnsupported to match ErrUnsupported
+func (e *Error) Is(target error) bool {
+	return target == ErrUnsupported && e.Code == CodeUnsupported
+}
+
+// NewUnsupported creates an error for an operation that is not supported, it matches ErrUnsupported
+func NewUnsupported(message string) *Error {
+	return &Error{
+		Code:    CodeUnsupported,
+		Message: message,
+	}
+}
+
+// statusCoder is implemented by the go-swagger generated default responses (e.g. *fns.GetFnDefault)
+type statusCoder interface {
+	Code() int
+}
+
+// errorPayloader is implemented by the go-swagger generated error responses
+type errorPayloader interface {
+	GetPayload() *modelsv2.Error
+}
+
+// generatedStatus matches the status in the messages of the go-swagger generated errors, e.g. "[GET /apps/{appID}][404] ..."
+var generatedStatus = regexp.MustCompile(`^\[[A-Z]+ [^\]]*\]\[(\d{3})\]`)
+
+// Wrap converts err into an *Error, recording requestID if err does not carry a request ID of its own. Errors that
+// are not API errors (e.g. connection failures) are wrapped with a zero StatusCode. A nil err returns nil.
+func Wrap(err error, requestID string) error {
+	if err == nil {
+		return nil
+	}
+	var fnErr *Error
+	if errors.As(err, &fnErr) {
+		return err
+	}
+
+	result := fromError(err)
+	if result.RequestID == "" {
+		result.RequestID = requestID
+	}
+	return result
+}
+
+// fromError extracts whatever status information is available from err
+func fromError(err error) *Error {
+	result := &Error{Message: err.Error(), Err: err}
+
+	var serviceErr common.ServiceError
+	var apiErr *runtime.APIError
+	var coder statusCoder
+
+	switch {
+	case errors.As(err, &serviceErr):
+		result.StatusCode = serviceErr.GetHTTPStatusCode()
+		result.Code = serviceErr.GetCode()
+		result.Message = serviceErr.GetMessage()
+		result.RequestID = serviceErr.GetOpcRequestID()
+	case errors.As(err, &apiErr):
+		result.StatusCode = apiErr.Code
+	case errors.As(err, &coder):
+		result.StatusCode = coder.Code()
+	default:
+		if m := generatedStatus.FindStringSubmatch(err.Error()); m != nil {
+			result.StatusCode, _ = strconv.Atoi(m[1])
+		}
+	}
+
+	var payloader errorPayloader
+	if errors.As(err, &payloader) {
+		if payload := payloader.GetPayload(); payload != nil && payload.Message != "" {
+			result.Message = payload.Message
+		}
+	}
+
+	return result
+}
+
+// AsError returns err as an *Error, converting it if it was not wrapped by a provider. It returns nil for a nil err.
+func AsError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var fnErr *Error
+	if errors.As(err, &fnErr) {
+		return fnErr
+	}
+	return fromError(err)
+}
+
+// StatusCode returns the HTTP status code of a failed API call, or 0 if it is not known
+func StatusCode(err error) int {
+	if fnErr := AsError(err); fnErr != nil {
+		return fnErr.StatusCode
+	}
+	return 0
+}
+
+// RequestID returns the request ID of a failed API call, if known
+func RequestID(err error) string {
+	if fnErr := AsError(err); fnErr != nil {
+		return fnErr.RequestID
+	}
+	return ""
+}
+
+// IsNotFound is true if err was caused by a missing resource
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsConflict is true if err was caused by a conflicting resource, e.g. one that already exists
+func IsConflict(err error) bool {
+	return StatusCode(err) == http.StatusConflict
+}
+
+// IsUnauthorized is true if err was caused by missing or invalid credentials
+func IsUnauthorized(err error) bool {
+	return StatusCode(err) == http.StatusUnauthorized
+}
+
+// IsUnsupported is true if err was caused by an operation that the provider or server does not implement
+func IsUnsupported(err error) bool {
+	return errors.Is(err, ErrUnsupported) || StatusCode(err) == http.StatusNotImplemented
+}
@@ -0,0 +1,78 @@
+package fnerrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/go-openapi/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeServiceError struct {
+	status    int
+	code      string
+	requestID string
+}
+
+func (e fakeServiceError) Error() string           { return e.code }
+func (e fakeServiceError) GetHTTPStatusCode() int  { return e.status }
+func (e fakeServiceError) GetMessage() string      { return "message for " + e.code }
+func (e fakeServiceError) GetCode() string         { return e.code }
+func (e fakeServiceError) GetOpcRequestID() string { return e.requestID }
+
+func TestClassifiesKnownErrors(t *testing.T) {
+	defaultErr := fns.NewGetFnDefault(http.StatusUnauthorized)
+	defaultErr.Payload = &modelsv2.Error{Message: "bad token"}
+
+	var tests = []struct {
+		err     error
+		status  int
+		message string
+	}{
+		{&apps.GetAppNotFound{Payload: &modelsv2.Error{Message: "App not found"}}, http.StatusNotFound, "App not found"},
+		{&apps.CreateAppConflict{Payload: &modelsv2.Error{Message: "App already exists"}}, http.StatusConflict, "App already exists"},
+		{defaultErr, http.StatusUnauthorized, "bad token"},
+		{&runtime.APIError{OperationName: "GetApp", Code: http.StatusBadGateway}, http.StatusBadGateway, ""},
+		{fakeServiceError{status: http.StatusNotFound, code: "NotAuthorizedOrNotFound", requestID: "opc-id"}, http.StatusNotFound, "message for NotAuthorizedOrNotFound"},
+		{errors.New("connection refused"), 0, "connection refused"},
+	}
+
+	for _, test := range tests {
+		err := Wrap(test.err, "")
+
+		var fnErr *Error
+		if !assert.True(t, errors.As(err, &fnErr), "%T not wrapped", test.err) {
+			continue
+		}
+		assert.Equal(t, test.status, fnErr.StatusCode, "%T", test.err)
+		assert.Equal(t, test.status, StatusCode(test.err), "unwrapped %T", test.err)
+		if test.message != "" {
+			assert.Equal(t, test.message, fnErr.Message, "%T", test.err)
+		}
+		// the original error is still reachable
+		assert.True(t, errors.Is(err, test.err))
+		assert.Equal(t, test.err.Error(), err.Error())
+	}
+
+	assert.True(t, IsNotFound(Wrap(&apps.GetAppNotFound{}, "")))
+	assert.True(t, IsConflict(&apps.CreateAppConflict{}))
+	assert.True(t, IsUnauthorized(defaultErr))
+	assert.Equal(t, "opc-id", RequestID(fakeServiceError{status: http.StatusConflict, requestID: "opc-id"}))
+}
+
+func TestRequestIDAndUnsupported(t *testing.T) {
+	err := Wrap(&apps.GetAppNotFound{}, "my-request")
+	assert.Equal(t, "my-request", RequestID(fmt.Errorf("wrapped: %w", err)))
+
+	unsupported := NewUnsupported("triggers are not supported")
+	assert.True(t, IsUnsupported(unsupported))
+	assert.True(t, errors.Is(unsupported, ErrUnsupported))
+	assert.Equal(t, "triggers are not supported", unsupported.Error())
+	assert.False(t, IsUnsupported(err))
+	assert.False(t, IsNotFound(nil))
+}
@@ -0,0 +1,371 @@
+package fntest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/fnproject/fn_go/modelsv2"
+)
+
+var (
+	errAppNotFound     = errorf(http.StatusNotFound, "App not found")
+	errFnNotFound      = errorf(http.StatusNotFound, "Fn not found")
+	errTriggerNotFound = errorf(http.StatusNotFound, "Trigger not found")
+)
+
+func (s *Server) sortedApps() []*modelsv2.App {
+	var result []*modelsv2.App
+	for _, app := range s.apps {
+		result = append(result, app)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (s *Server) sortedFns(appID string) []*modelsv2.Fn {
+	var result []*modelsv2.Fn
+	for _, fn := range s.fns {
+		if fn.AppID == appID {
+			result = append(result, fn)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (s *Server) sortedTriggers(appID string) []*modelsv2.Trigger {
+	var result []*modelsv2.Trigger
+	for _, trigger := range s.triggers {
+		if trigger.AppID == appID {
+			result = append(result, trigger)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (s *Server) listApps(query url.Values) (int, interface{}, *apiError) {
+	after, perPage, err := page(query)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	list := &modelsv2.AppList{}
+	for _, app := range s.sortedApps() {
+		if app.Name <= after || (query.Get("name") != "" && app.Name != query.Get("name")) {
+			continue
+		}
+		if len(list.Items) == perPage {
+			list.NextCursor = nextCursor(list.Items[perPage-1].Name)
+			break
+		}
+		list.Items = append(list.Items, app)
+	}
+	return http.StatusOK, list, nil
+}
+
+func (s *Server) createApp(app *modelsv2.App) (int, interface{}, *apiError) {
+	if app.Name == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "Missing app name")
+	}
+	if app.ID != "" {
+		return 0, nil, errorf(http.StatusBadRequest, "App ID cannot be supplied on create")
+	}
+	for _, existing := range s.apps {
+		if existing.Name == app.Name {
+			return 0, nil, errorf(http.StatusConflict, "App already exists")
+		}
+	}
+
+	app.ID = s.newID()
+	app.Config = mergeConfig(nil, app.Config)
+	app.Annotations = mergeAnnotations(nil, app.Annotations)
+	app.CreatedAt = stamp()
+	app.UpdatedAt = app.CreatedAt
+	s.apps[app.ID] = app
+	return http.StatusOK, app, nil
+}
+
+func (s *Server) updateApp(id string, update *modelsv2.App) (int, interface{}, *apiError) {
+	app, ok := s.apps[id]
+	if !ok {
+		return 0, nil, errAppNotFound
+	}
+	if update.ID != "" && update.ID != id {
+		return 0, nil, errorf(http.StatusConflict, "App ID in path does not match ID in body")
+	}
+	if update.Name != "" && update.Name != app.Name {
+		return 0, nil, errorf(http.StatusConflict, "App name cannot be changed")
+	}
+
+	app.Config = mergeConfig(app.Config, update.Config)
+	app.Annotations = mergeAnnotations(app.Annotations, update.Annotations)
+	if update.SyslogURL != nil {
+		app.SyslogURL = update.SyslogURL
+	}
+	app.UpdatedAt = stamp()
+	return http.StatusOK, app, nil
+}
+
+// deleteApp removes an app along with its functions and triggers
+func (s *Server) deleteApp(id string) (int, interface{}, *apiError) {
+	if _, ok := s.apps[id]; !ok {
+		return 0, nil, errAppNotFound
+	}
+	for fnID, fn := range s.fns {
+		if fn.AppID == id {
+			delete(s.fns, fnID)
+		}
+	}
+	for triggerID, trigger := range s.triggers {
+		if trigger.AppID == id {
+			delete(s.triggers, triggerID)
+		}
+	}
+	delete(s.apps, id)
+	return http.StatusNoContent, nil, nil
+}
+
+func (s *Server) listFns(query url.Values) (int, interface{}, *apiError) {
+	appID := query.Get("app_id")
+	if appID == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "Missing app ID")
+	}
+	after, perPage, err := page(query)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	list := &modelsv2.FnList{}
+	for _, fn := range s.sortedFns(appID) {
+		if fn.Name <= after || (query.Get("name") != "" && fn.Name != query.Get("name")) {
+			continue
+		}
+		if len(list.Items) == perPage {
+			list.NextCursor = nextCursor(list.Items[perPage-1].Name)
+			break
+		}
+		list.Items = append(list.Items, fn)
+	}
+	return http.StatusOK, list, nil
+}
+
+func (s *Server) createFn(fn *modelsv2.Fn) (int, interface{}, *apiError) {
+	if fn.Name == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "Missing function name")
+	}
+	if fn.Image == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "Missing image")
+	}
+	if fn.ID != "" {
+		return 0, nil, errorf(http.StatusBadRequest, "Fn ID cannot be supplied on create")
+	}
+	if _, ok := s.apps[fn.AppID]; !ok {
+		return 0, nil, errAppNotFound
+	}
+	for _, existing := range s.fns {
+		if existing.AppID == fn.AppID && existing.Name == fn.Name {
+			return 0, nil, errorf(http.StatusConflict, "Fn already exists")
+		}
+	}
+
+	fn.ID = s.newID()
+	if fn.Memory == 0 {
+		fn.Memory = defaultMemory
+	}
+	if fn.Timeout == nil {
+		timeout := defaultTimeout
+		fn.Timeout = &timeout
+	}
+	if fn.IdleTimeout == nil {
+		idleTimeout := defaultIdleTimeout
+		fn.IdleTimeout = &idleTimeout
+	}
+	fn.Config = mergeConfig(nil, fn.Config)
+	fn.Annotations = mergeAnnotations(nil, fn.Annotations)
+	fn.CreatedAt = stamp()
+	fn.UpdatedAt = fn.CreatedAt
+	s.fns[fn.ID] = fn
+	return http.StatusOK, fn, nil
+}
+
+func (s *Server) updateFn(id string, update *modelsv2.Fn) (int, interface{}, *apiError) {
+	fn, ok := s.fns[id]
+	if !ok {
+		return 0, nil, errFnNotFound
+	}
+	if update.ID != "" && update.ID != id {
+		return 0, nil, errorf(http.StatusConflict, "Fn ID in path does not match ID in body")
+	}
+	if update.AppID != "" && update.AppID != fn.AppID {
+		return 0, nil, errorf(http.StatusConflict, "Fn app cannot be changed")
+	}
+	if update.Name != "" && update.Name != fn.Name {
+		return 0, nil, errorf(http.StatusConflict, "Fn name cannot be changed")
+	}
+
+	if update.Image != "" {
+		fn.Image = update.Image
+	}
+	if update.Memory != 0 {
+		fn.Memory = update.Memory
+	}
+	if update.Timeout != nil {
+		fn.Timeout = update.Timeout
+	}
+	if update.IdleTimeout != nil {
+		fn.IdleTimeout = update.IdleTimeout
+	}
+	fn.Config = mergeConfig(fn.Config, update.Config)
+	fn.Annotations = mergeAnnotations(fn.Annotations, update.Annotations)
+	fn.UpdatedAt = stamp()
+	return http.StatusOK, fn, nil
+}
+
+// deleteFn removes a function along with its triggers
+func (s *Server) deleteFn(id string) (int, interface{}, *apiError) {
+	if _, ok := s.fns[id]; !ok {
+		return 0, nil, errFnNotFound
+	}
+	for triggerID, trigger := range s.triggers {
+		if trigger.FnID == id {
+			delete(s.triggers, triggerID)
+		}
+	}
+	delete(s.fns, id)
+	return http.StatusNoContent, nil, nil
+}
+
+func (s *Server) listTriggers(query url.Values) (int, interface{}, *apiError) {
+	appID := query.Get("app_id")
+	if appID == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "Missing app ID")
+	}
+	after, perPage, err := page(query)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	list := &modelsv2.TriggerList{}
+	for _, trigger := range s.sortedTriggers(appID) {
+		if trigger.Name <= after ||
+			(query.Get("fn_id") != "" && trigger.FnID != query.Get("fn_id")) ||
+			(query.Get("name") != "" && trigger.Name != query.Get("name")) {
+			continue
+		}
+		if len(list.Items) == perPage {
+			list.NextCursor = nextCursor(list.Items[perPage-1].Name)
+			break
+		}
+		list.Items = append(list.Items, trigger)
+	}
+	return http.StatusOK, list, nil
+}
+
+func (s *Server) createTrigger(trigger *modelsv2.Trigger) (int, interface{}, *apiError) {
+	if trigger.Name == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "Missing trigger name")
+	}
+	if trigger.Type == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "Missing trigger type")
+	}
+	if trigger.ID != "" {
+		return 0, nil, errorf(http.StatusBadRequest, "Trigger ID cannot be supplied on create")
+	}
+	if _, ok := s.apps[trigger.AppID]; !ok {
+		return 0, nil, errAppNotFound
+	}
+	fn, ok := s.fns[trigger.FnID]
+	if !ok || fn.AppID != trigger.AppID {
+		return 0, nil, errFnNotFound
+	}
+	for _, existing := range s.triggers {
+		if existing.AppID == trigger.AppID && existing.Name == trigger.Name {
+			return 0, nil, errorf(http.StatusConflict, "Trigger already exists")
+		}
+	}
+
+	trigger.ID = s.newID()
+	trigger.Annotations = mergeAnnotations(nil, trigger.Annotations)
+	trigger.CreatedAt = stamp()
+	trigger.UpdatedAt = trigger.CreatedAt
+	s.triggers[trigger.ID] = trigger
+	return http.StatusOK, trigger, nil
+}
+
+func (s *Server) updateTrigger(id string, update *modelsv2.Trigger) (int, interface{}, *apiError) {
+	trigger, ok := s.triggers[id]
+	if !ok {
+		return 0, nil, errTriggerNotFound
+	}
+	if update.ID != "" && update.ID != id {
+		return 0, nil, errorf(http.StatusConflict, "Trigger ID in path does not match ID in body")
+	}
+	if (update.AppID != "" && update.AppID != trigger.AppID) || (update.FnID != "" && update.FnID != trigger.FnID) {
+		return 0, nil, errorf(http.StatusConflict, "Trigger app and function cannot be changed")
+	}
+	if update.Name != "" && update.Name != trigger.Name {
+		return 0, nil, errorf(http.StatusConflict, "Trigger name cannot be changed")
+	}
+	if update.Type != "" && update.Type != trigger.Type {
+		return 0, nil, errorf(http.StatusConflict, "Trigger type cannot be changed")
+	}
+
+	if update.Source != "" {
+		trigger.Source = update.Source
+	}
+	trigger.Annotations = mergeAnnotations(trigger.Annotations, update.Annotations)
+	trigger.UpdatedAt = stamp()
+	return http.StatusOK, trigger, nil
+}
+
+func (s *Server) deleteTrigger(id string) (int, interface{}, *apiError) {
+	if _, ok := s.triggers[id]; !ok {
+		return 0, nil, errTriggerNotFound
+	}
+	delete(s.triggers, id)
+	return http.StatusNoContent, nil, nil
+}
+
+func copyApp(app *modelsv2.App) *modelsv2.App {
+	c := *app
+	c.Config = copyConfig(app.Config)
+	c.Annotations = copyAnnotations(app.Annotations)
+	return &c
+}
+
+func copyFn(fn *modelsv2.Fn) *modelsv2.Fn {
+	c := *fn
+	c.Config = copyConfig(fn.Config)
+	c.Annotations = copyAnnotations(fn.Annotations)
+	return &c
+}
+
+func copyTrigger(trigger *modelsv2.Trigger) *modelsv2.Trigger {
+	c := *trigger
+	c.Annotations = copyAnnotations(trigger.Annotations)
+	return &c
+}
+
+func copyConfig(config map[string]string) map[string]string {
+	if config == nil {
+		return nil
+	}
+	c := make(map[string]string, len(config))
+	for k, v := range config {
+		c[k] = v
+	}
+	return c
+}
+
+func copyAnnotations(annotations map[string]interface{}) map[string]interface{} {
+	if annotations == nil {
+		return nil
+	}
+	c := make(map[string]interface{}, len(annotations))
+	for k, v := range annotations {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,488 @@
+// Package fntest provides an in-process fake of the Fn /v2 API for testing code that uses fn_go.
+//
+// The fake keeps apps, functions and triggers in memory and follows the Fn server's semantics for config merges,
+// cursor pagination, cascading deletes and 404/409 errors. Faults and latency can be injected to exercise retry and
+// error handling without a real server:
+//
+//	srv := fntest.NewServer()
+//	defer srv.Close()
+//	srv.AddFault(fntest.Fault{Method: http.MethodGet, Path: "/v2/apps", StatusCode: 503, Times: 2})
+//	client := srv.Client()
+package fntest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/models"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/go-openapi/strfmt"
+)
+
+const (
+	// DefaultVersion is the version reported by /version unless Server.Version is changed
+	DefaultVersion = "0.3.750"
+
+	defaultPerPage = 30
+	maxPerPage     = 100
+
+	defaultMemory      uint64 = 128
+	defaultTimeout     int32  = 30
+	defaultIdleTimeout int32  = 30
+)
+
+// Fault describes a failure that the server returns instead of handling matching requests
+type Fault struct {
+	// Method restricts the fault to one HTTP method, empty matches any method
+	Method string
+	// Path restricts the fault to request paths with this prefix, empty matches any path
+	Path string
+	// StatusCode is the status returned for matching requests
+	StatusCode int
+	// Message is returned as the error message, it defaults to the status text
+	Message string
+	// Header contains additional response headers, e.g. Retry-After
+	Header http.Header
+	// Times is the number of requests the fault applies to, zero applies it until the faults are cleared
+	Times int
+}
+
+func (f *Fault) matches(r *http.Request) bool {
+	return (f.Method == "" || strings.EqualFold(f.Method, r.Method)) && strings.HasPrefix(r.URL.Path, f.Path)
+}
+
+// Server is a fake Fn server backed by in-memory state
+type Server struct {
+	// Version is the server version reported by /version
+	Version string
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	nextID   int
+	apps     map[string]*modelsv2.App
+	fns      map[string]*modelsv2.Fn
+	triggers map[string]*modelsv2.Trigger
+	faults   []*Fault
+	latency  time.Duration
+	requests []string
+}
+
+// NewServer starts a fake Fn server, callers must call Close when done
+func NewServer() *Server {
+	s := &Server{
+		Version:  DefaultVersion,
+		apps:     map[string]*modelsv2.App{},
+		fns:      map[string]*modelsv2.Fn{},
+		triggers: map[string]*modelsv2.Trigger{},
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// URL is the base URL of the server, e.g. http://127.0.0.1:41234
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// TransportConfig returns a clientv2 transport configuration that points at the server
+func (s *Server) TransportConfig() *clientv2.TransportConfig {
+	u, _ := url.Parse(s.srv.URL)
+	return clientv2.DefaultTransportConfig().WithHost(u.Host).WithSchemes([]string{u.Scheme})
+}
+
+// Client returns a clientv2 client that talks to the server
+func (s *Server) Client() *clientv2.Fn {
+	return clientv2.NewHTTPClientWithConfig(strfmt.Default, s.TransportConfig())
+}
+
+// AddFault makes the server fail matching requests, faults are checked in the order they were added
+func (s *Server) AddFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// SetLatency delays every response by d
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Requests returns the requests received so far as "METHOD /path", including those that were failed by a fault
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.requests...)
+}
+
+// AddApp stores an app directly, bypassing validation. An ID is assigned if the app has none.
+func (s *Server) AddApp(app *modelsv2.App) *modelsv2.App {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app = copyApp(app)
+	if app.ID == "" {
+		app.ID = s.newID()
+	}
+	s.apps[app.ID] = app
+	return copyApp(app)
+}
+
+// AddFn stores a function directly, bypassing validation. An ID is assigned if the function has none.
+func (s *Server) AddFn(fn *modelsv2.Fn) *modelsv2.Fn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn = copyFn(fn)
+	if fn.ID == "" {
+		fn.ID = s.newID()
+	}
+	s.fns[fn.ID] = fn
+	return copyFn(fn)
+}
+
+// AddTrigger stores a trigger directly, bypassing validation. An ID is assigned if the trigger has none.
+func (s *Server) AddTrigger(trigger *modelsv2.Trigger) *modelsv2.Trigger {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	trigger = copyTrigger(trigger)
+	if trigger.ID == "" {
+		trigger.ID = s.newID()
+	}
+	s.triggers[trigger.ID] = trigger
+	return copyTrigger(trigger)
+}
+
+// Apps returns a copy of the stored apps ordered by name
+func (s *Server) Apps() []*modelsv2.App {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []*modelsv2.App
+	for _, app := range s.sortedApps() {
+		result = append(result, copyApp(app))
+	}
+	return result
+}
+
+// Fns returns a copy of the stored functions of an app ordered by name
+func (s *Server) Fns(appID string) []*modelsv2.Fn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []*modelsv2.Fn
+	for _, fn := range s.sortedFns(appID) {
+		result = append(result, copyFn(fn))
+	}
+	return result
+}
+
+// Triggers returns a copy of the stored triggers of an app ordered by name
+func (s *Server) Triggers(appID string) []*modelsv2.Trigger {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result []*modelsv2.Trigger
+	for _, trigger := range s.sortedTriggers(appID) {
+		result = append(result, copyTrigger(trigger))
+	}
+	return result
+}
+
+func (s *Server) newID() string {
+	s.nextID++
+	return fmt.Sprintf("01FAKE%020d", s.nextID)
+}
+
+// apiError is returned by handlers and rendered as a modelsv2.Error body
+type apiError struct {
+	status  int
+	message string
+}
+
+func errorf(status int, format string, args ...interface{}) *apiError {
+	return &apiError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	latency := s.latency
+	fault := s.takeFault(r)
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if fault != nil {
+		for k, vs := range fault.Header {
+			w.Header()[k] = vs
+		}
+		message := fault.Message
+		if message == "" {
+			message = http.StatusText(fault.StatusCode)
+		}
+		writeJSON(w, fault.StatusCode, &modelsv2.Error{Message: message})
+		return
+	}
+
+	// handlers return stored resources, so they are encoded before the lock is released
+	s.mu.Lock()
+	status, body, apiErr := s.route(r)
+	var encoded []byte
+	if apiErr == nil && body != nil {
+		encoded, _ = json.Marshal(body)
+	}
+	s.mu.Unlock()
+
+	if apiErr != nil {
+		writeJSON(w, apiErr.status, &modelsv2.Error{Message: apiErr.message})
+		return
+	}
+	if body == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(encoded)
+}
+
+// takeFault returns the first fault matching r, consuming one of its uses
+func (s *Server) takeFault(r *http.Request) *Fault {
+	for i, f := range s.faults {
+		if !f.matches(r) {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func (s *Server) route(r *http.Request) (int, interface{}, *apiError) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if path == "/version" && r.Method == http.MethodGet {
+		return http.StatusOK, &models.Version{Version: s.Version}, nil
+	}
+
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "v2" {
+		return 0, nil, errorf(http.StatusNotFound, "Path not found")
+	}
+
+	var id string
+	if len(parts) == 3 {
+		id = parts[2]
+	}
+
+	switch parts[1] {
+	case "apps":
+		return s.routeApps(r, id)
+	case "fns":
+		return s.routeFns(r, id)
+	case "triggers":
+		return s.routeTriggers(r, id)
+	}
+	return 0, nil, errorf(http.StatusNotFound, "Path not found")
+}
+
+func (s *Server) routeApps(r *http.Request, id string) (int, interface{}, *apiError) {
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		return s.listApps(r.URL.Query())
+	case id == "" && r.Method == http.MethodPost:
+		app := &modelsv2.App{}
+		if err := decode(r, app); err != nil {
+			return 0, nil, err
+		}
+		return s.createApp(app)
+	case id != "" && r.Method == http.MethodGet:
+		app, ok := s.apps[id]
+		if !ok {
+			return 0, nil, errAppNotFound
+		}
+		return http.StatusOK, app, nil
+	case id != "" && r.Method == http.MethodPut:
+		app := &modelsv2.App{}
+		if err := decode(r, app); err != nil {
+			return 0, nil, err
+		}
+		return s.updateApp(id, app)
+	case id != "" && r.Method == http.MethodDelete:
+		return s.deleteApp(id)
+	}
+	return 0, nil, errorf(http.StatusMethodNotAllowed, "Method not allowed")
+}
+
+func (s *Server) routeFns(r *http.Request, id string) (int, interface{}, *apiError) {
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		return s.listFns(r.URL.Query())
+	case id == "" && r.Method == http.MethodPost:
+		fn := &modelsv2.Fn{}
+		if err := decode(r, fn); err != nil {
+			return 0, nil, err
+		}
+		return s.createFn(fn)
+	case id != "" && r.Method == http.MethodGet:
+		fn, ok := s.fns[id]
+		if !ok {
+			return 0, nil, errFnNotFound
+		}
+		return http.StatusOK, fn, nil
+	case id != "" && r.Method == http.MethodPut:
+		fn := &modelsv2.Fn{}
+		if err := decode(r, fn); err != nil {
+			return 0, nil, err
+		}
+		return s.updateFn(id, fn)
+	case id != "" && r.Method == http.MethodDelete:
+		return s.deleteFn(id)
+	}
+	return 0, nil, errorf(http.StatusMethodNotAllowed, "Method not allowed")
+}
+
+func (s *Server) routeTriggers(r *http.Request, id string) (int, interface{}, *apiError) {
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		return s.listTriggers(r.URL.Query())
+	case id == "" && r.Method == http.MethodPost:
+		trigger := &modelsv2.Trigger{}
+		if err := decode(r, trigger); err != nil {
+			return 0, nil, err
+		}
+		return s.createTrigger(trigger)
+	case id != "" && r.Method == http.MethodGet:
+		trigger, ok := s.triggers[id]
+		if !ok {
+			return 0, nil, errTriggerNotFound
+		}
+		return http.StatusOK, trigger, nil
+	case id != "" && r.Method == http.MethodPut:
+		trigger := &modelsv2.Trigger{}
+		if err := decode(r, trigger); err != nil {
+			return 0, nil, err
+		}
+		return s.updateTrigger(id, trigger)
+	case id != "" && r.Method == http.MethodDelete:
+		return s.deleteTrigger(id)
+	}
+	return 0, nil, errorf(http.StatusMethodNotAllowed, "Method not allowed")
+}
+
+func decode(r *http.Request, v interface{}) *apiError {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errorf(http.StatusBadRequest, "Invalid JSON: %s", err)
+	}
+	return nil
+}
+
+// page parses the cursor and per_page query parameters, cursors are the base64 encoded name of the last item returned
+func page(query url.Values) (string, int, *apiError) {
+	var after string
+	if cursor := query.Get("cursor"); cursor != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+		if err != nil {
+			return "", 0, errorf(http.StatusBadRequest, "Invalid cursor")
+		}
+		after = string(decoded)
+	}
+
+	perPage := defaultPerPage
+	if pp := query.Get("per_page"); pp != "" {
+		n, err := strconv.Atoi(pp)
+		if err != nil || n < 1 {
+			return "", 0, errorf(http.StatusBadRequest, "Invalid per_page")
+		}
+		perPage = n
+	}
+	if perPage > maxPerPage {
+		perPage = maxPerPage
+	}
+	return after, perPage, nil
+}
+
+func nextCursor(name string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(name))
+}
+
+func stamp() strfmt.DateTime {
+	return strfmt.DateTime(time.Now().UTC())
+}
+
+// mergeConfig applies a config update, empty values delete keys
+func mergeConfig(config, update map[string]string) map[string]string {
+	if len(update) == 0 {
+		return config
+	}
+	if config == nil {
+		config = map[string]string{}
+	}
+	for k, v := range update {
+		if v == "" {
+			delete(config, k)
+		} else {
+			config[k] = v
+		}
+	}
+	if len(config) == 0 {
+		return nil
+	}
+	return config
+}
+
+// mergeAnnotations applies an annotations update, null or empty values delete keys
+func mergeAnnotations(annotations, update map[string]interface{}) map[string]interface{} {
+	if len(update) == 0 {
+		return annotations
+	}
+	if annotations == nil {
+		annotations = map[string]interface{}{}
+	}
+	for k, v := range update {
+		if v == nil || v == "" {
+			delete(annotations, k)
+		} else {
+			annotations[k] = v
+		}
+	}
+	if len(annotations) == 0 {
+		return nil
+	}
+	return annotations
+}
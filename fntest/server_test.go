@@ -0,0 +1,118 @@
+package fntest
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/fnproject/fn_go/client/version"
+	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/fnerrors"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/pager"
+	"github.com/fnproject/fn_go/provider"
+	openapi "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppAndFnLifecycle(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	c := srv.Client()
+
+	created, err := c.Apps.CreateApp(apps.NewCreateAppParams().WithBody(&modelsv2.App{
+		Name:   "myapp",
+		Config: map[string]string{"A": "1", "B": "2"},
+	}))
+	if !assert.NoError(t, err) {
+		return
+	}
+	appID := created.Payload.ID
+
+	_, err = c.Apps.CreateApp(apps.NewCreateAppParams().WithBody(&modelsv2.App{Name: "myapp"}))
+	assert.IsType(t, &apps.CreateAppConflict{}, err)
+
+	updated, err := c.Apps.UpdateApp(apps.NewUpdateAppParams().WithAppID(appID).WithBody(&modelsv2.App{
+		Config: map[string]string{"A": "", "C": "3"},
+	}))
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{"B": "2", "C": "3"}, updated.Payload.Config)
+	}
+
+	fn, err := c.Fns.CreateFn(fns.NewCreateFnParams().WithBody(&modelsv2.Fn{AppID: appID, Name: "hello", Image: "fnproject/hello"}))
+	if assert.NoError(t, err) {
+		assert.Equal(t, uint64(128), fn.Payload.Memory)
+	}
+
+	_, err = c.Fns.CreateFn(fns.NewCreateFnParams().WithBody(&modelsv2.Fn{AppID: "missing", Name: "hello", Image: "fnproject/hello"}))
+	assert.Equal(t, http.StatusNotFound, fnerrors.StatusCode(fnerrors.Wrap(err, "")))
+
+	_, err = c.Apps.DeleteApp(apps.NewDeleteAppParams().WithAppID(appID))
+	assert.NoError(t, err)
+	assert.Empty(t, srv.Fns(appID), "functions should be deleted with their app")
+
+	_, err = c.Apps.GetApp(apps.NewGetAppParams().WithAppID(appID))
+	assert.IsType(t, &apps.GetAppNotFound{}, err)
+}
+
+func TestPagination(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	for _, name := range []string{"e", "b", "d", "a", "c"} {
+		srv.AddApp(&modelsv2.App{Name: name})
+	}
+
+	result, err := pager.Apps(context.Background(), srv.Client(), nil, pager.Options{PageSize: 2}).All()
+	if assert.NoError(t, err) && assert.Len(t, result, 5) {
+		assert.Equal(t, "a", result[0].Name)
+		assert.Equal(t, "e", result[4].Name)
+	}
+	assert.Len(t, srv.Requests(), 3)
+}
+
+func TestVersion(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL())
+	res, err := version.New(openapi.New(u.Host, "/", []string{u.Scheme}), strfmt.Default).GetVersion(version.NewGetVersionParams())
+	if assert.NoError(t, err) {
+		assert.Equal(t, DefaultVersion, res.Payload.Version)
+	}
+}
+
+func TestFaultsAreRetried(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.AddApp(&modelsv2.App{Name: "myapp"})
+	srv.AddFault(Fault{Method: http.MethodGet, Path: "/v2/apps", StatusCode: http.StatusServiceUnavailable, Times: 2})
+
+	policy := provider.DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+
+	u, _ := url.Parse(srv.URL())
+	transport := openapi.New(u.Host, clientv2.DefaultBasePath, []string{u.Scheme})
+	transport.Transport = provider.RetryRoundTripper(policy, transport.Transport)
+
+	res, err := clientv2.New(transport, strfmt.Default).Apps.ListApps(apps.NewListAppsParams())
+	if assert.NoError(t, err) {
+		assert.Len(t, res.Payload.Items, 1)
+	}
+	assert.Len(t, srv.Requests(), 3)
+}
+
+func TestLatencyRespectsContext(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.SetLatency(time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := srv.Client().Apps.ListApps(apps.NewListAppsParams().WithContext(ctx))
+	assert.Error(t, err)
+}
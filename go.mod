@@ -14,6 +14,7 @@ require (
 	github.com/stretchr/testify v1.6.1
 	golang.org/x/crypto v0.19.0
 	golang.org/x/net v0.17.0
+	gopkg.in/yaml.v3 v3.0.0-20220521103104-8f96da9f5d5e
 )
 
 require (
@@ -37,5 +38,4 @@ require (
 	golang.org/x/term v0.17.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	gopkg.in/yaml.v2 v2.3.0 // indirect
-	gopkg.in/yaml.v3 v3.0.0-20220521103104-8f96da9f5d5e // indirect
 )
@@ -0,0 +1,44 @@
+package pager
+
+import (
+	"context"
+
+	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/modelsv2"
+)
+
+// AppIterator iterates over the apps returned by a ListApps query
+type AppIterator struct {
+	*iterator
+}
+
+// Apps returns an iterator over all apps matching params (which may be nil). The cursor, page size and context of
+// params are managed by the iterator.
+func Apps(ctx context.Context, client *clientv2.Fn, params *apps.ListAppsParams, opts Options) *AppIterator {
+	if params == nil {
+		params = apps.NewListAppsParams()
+	}
+	return &AppIterator{newIterator(ctx, opts, func(ctx context.Context, cursor *string, perPage *int64) (interface{}, string, error) {
+		p := *params
+		p.Context, p.Cursor, p.PerPage = ctx, cursor, perPage
+		res, err := client.Apps.ListApps(&p)
+		if err != nil {
+			return nil, "", err
+		}
+		return res.Payload.Items, res.Payload.NextCursor, nil
+	})}
+}
+
+// App returns the current app
+func (i *AppIterator) App() *modelsv2.App {
+	app, _ := i.current.(*modelsv2.App)
+	return app
+}
+
+// All consumes the remainder of the iterator and returns the apps it produced
+func (i *AppIterator) All() ([]*modelsv2.App, error) {
+	var result []*modelsv2.App
+	err := i.all(&result)
+	return result, err
+}
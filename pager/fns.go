@@ -0,0 +1,44 @@
+package pager
+
+import (
+	"context"
+
+	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/modelsv2"
+)
+
+// FnIterator iterates over the functions returned by a ListFns query
+type FnIterator struct {
+	*iterator
+}
+
+// Fns returns an iterator over all functions matching params (which may be nil). The cursor, page size and context of
+// params are managed by the iterator.
+func Fns(ctx context.Context, client *clientv2.Fn, params *fns.ListFnsParams, opts Options) *FnIterator {
+	if params == nil {
+		params = fns.NewListFnsParams()
+	}
+	return &FnIterator{newIterator(ctx, opts, func(ctx context.Context, cursor *string, perPage *int64) (interface{}, string, error) {
+		p := *params
+		p.Context, p.Cursor, p.PerPage = ctx, cursor, perPage
+		res, err := client.Fns.ListFns(&p)
+		if err != nil {
+			return nil, "", err
+		}
+		return res.Payload.Items, res.Payload.NextCursor, nil
+	})}
+}
+
+// Fn returns the current function
+func (i *FnIterator) Fn() *modelsv2.Fn {
+	fn, _ := i.current.(*modelsv2.Fn)
+	return fn
+}
+
+// All consumes the remainder of the iterator and returns the functions it produced
+func (i *FnIterator) All() ([]*modelsv2.Fn, error) {
+	var result []*modelsv2.Fn
+	err := i.all(&result)
+	return result, err
+}
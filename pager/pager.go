@@ -0,0 +1,123 @@
+// Package pager provides iterators over the paginated list operations of the Fn API.
+//
+// Iterators work against any *clientv2.Fn, including those returned by providers that shim the Fn API onto
+// another service, and fetch pages lazily as items are consumed.
+package pager
+
+import (
+	"context"
+	"reflect"
+)
+
+// Options controls how an iterator fetches pages
+type Options struct {
+	// PageSize is the number of items requested per page, 0 uses the server's default page size
+	PageSize int64
+	// MaxItems caps the total number of items returned by the iterator, 0 means no limit
+	MaxItems int
+}
+
+// pageFunc lists a single page starting at cursor (nil for the first page), returning the slice of items on the page
+// and the cursor of the next page (empty when there are no more pages)
+type pageFunc func(ctx context.Context, cursor *string, perPage *int64) (items interface{}, nextCursor string, err error)
+
+// iterator holds the state common to all typed iterators, which embed it and add typed accessors for the current item
+type iterator struct {
+	ctx     context.Context
+	fetch   pageFunc
+	opts    Options
+	buf     []interface{}
+	cursor  string
+	done    bool
+	count   int
+	current interface{}
+	err     error
+}
+
+func newIterator(ctx context.Context, opts Options, fetch pageFunc) *iterator {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &iterator{ctx: ctx, fetch: fetch, opts: opts}
+}
+
+// Next advances the iterator, returning false when there are no more items or an error occurred
+func (it *iterator) Next() bool {
+	it.current = nil
+	if it.err != nil || (it.opts.MaxItems > 0 && it.count >= it.opts.MaxItems) {
+		return false
+	}
+
+	for len(it.buf) == 0 {
+		if it.done {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			it.err = err
+			return false
+		}
+
+		items, nextCursor, err := it.fetch(it.ctx, stringOrNil(it.cursor), it.perPage())
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.buf = toInterfaces(items)
+		it.cursor = nextCursor
+		it.done = nextCursor == ""
+	}
+
+	it.current = it.buf[0]
+	it.buf = it.buf[1:]
+	it.count++
+	return true
+}
+
+// Err returns the first error encountered while fetching pages, if any
+func (it *iterator) Err() error {
+	return it.err
+}
+
+// all consumes the remainder of the iterator, appending its items to the slice that result points to
+func (it *iterator) all(result interface{}) error {
+	slice := reflect.ValueOf(result).Elem()
+	for it.Next() {
+		slice.Set(reflect.Append(slice, reflect.ValueOf(it.current)))
+	}
+	return it.Err()
+}
+
+// perPage returns the page size to request, never asking for more than the remaining number of items
+func (it *iterator) perPage() *int64 {
+	pageSize := it.opts.PageSize
+	if it.opts.MaxItems > 0 {
+		remaining := int64(it.opts.MaxItems - it.count)
+		if pageSize == 0 || remaining < pageSize {
+			pageSize = remaining
+		}
+	}
+	if pageSize <= 0 {
+		return nil
+	}
+	return &pageSize
+}
+
+func stringOrNil(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// toInterfaces copies the elements of the slice items into a []interface{}
+func toInterfaces(items interface{}) []interface{} {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice {
+		return nil
+	}
+	result := make([]interface{}, v.Len())
+	for i := range result {
+		result[i] = v.Index(i).Interface()
+	}
+	return result
+}
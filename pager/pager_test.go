@@ -0,0 +1,107 @@
+package pager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider/oracle/shim"
+	"github.com/fnproject/fn_go/provider/oracle/shim/client"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// newAppsServer serves count apps from /v2/apps using numeric cursors
+func newAppsServer(t *testing.T, count int, requests *[]url.Values) *clientv2.Fn {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.Query())
+
+		start, _ := strconv.Atoi(r.URL.Query().Get("cursor"))
+		perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+		if perPage == 0 {
+			perPage = 30
+		}
+
+		list := &modelsv2.AppList{}
+		for i := start; i < count && i < start+perPage; i++ {
+			list.Items = append(list.Items, &modelsv2.App{ID: strconv.Itoa(i), Name: "app" + strconv.Itoa(i)})
+		}
+		if start+perPage < count {
+			list.NextCursor = strconv.Itoa(start + perPage)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}))
+	t.Cleanup(srv.Close)
+
+	u, _ := url.Parse(srv.URL)
+	return clientv2.NewHTTPClientWithConfig(nil, clientv2.DefaultTransportConfig().WithHost(u.Host).WithSchemes([]string{"http"}))
+}
+
+func TestAppsIteratesAllPages(t *testing.T) {
+	var requests []url.Values
+	c := newAppsServer(t, 7, &requests)
+
+	result, err := Apps(context.Background(), c, nil, Options{PageSize: 3}).All()
+	assert.NoError(t, err)
+	if assert.Len(t, result, 7) {
+		assert.Equal(t, "app6", result[6].Name)
+	}
+	if assert.Len(t, requests, 3) {
+		assert.Equal(t, "3", requests[0].Get("per_page"))
+	}
+}
+
+func TestAppsMaxItems(t *testing.T) {
+	var requests []url.Values
+	c := newAppsServer(t, 20, &requests)
+
+	result, err := Apps(context.Background(), c, nil, Options{PageSize: 3, MaxItems: 5}).All()
+	assert.NoError(t, err)
+	assert.Len(t, result, 5)
+	// The last page only requests what is needed to reach MaxItems
+	if assert.Len(t, requests, 2) {
+		assert.Equal(t, "2", requests[1].Get("per_page"))
+	}
+}
+
+func TestAppsContextCancelled(t *testing.T) {
+	var requests []url.Values
+	c := newAppsServer(t, 20, &requests)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	it := Apps(ctx, c, nil, Options{PageSize: 2})
+	assert.True(t, it.Next())
+	assert.True(t, it.Next())
+	cancel()
+	assert.False(t, it.Next())
+	assert.Equal(t, context.Canceled, it.Err())
+	assert.Len(t, requests, 1)
+}
+
+func TestAppsAndFnsOverOCIShims(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ociClient := client.NewMockFunctionsManagementClientBasic(ctrl)
+	c := &clientv2.Fn{
+		Apps: shim.NewAppsShim(ociClient, "PagerCompartment"),
+		Fns:  shim.NewFnsShim(ociClient),
+	}
+
+	appList, err := Apps(context.Background(), c, nil, Options{}).All()
+	assert.NoError(t, err)
+	assert.Len(t, appList, 9)
+
+	appID := "PagerAppId"
+	fnList, err := Fns(context.Background(), c, &fns.ListFnsParams{AppID: &appID}, Options{MaxItems: 4}).All()
+	assert.NoError(t, err)
+	assert.Len(t, fnList, 4)
+}
@@ -0,0 +1,44 @@
+package pager
+
+import (
+	"context"
+
+	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/clientv2/triggers"
+	"github.com/fnproject/fn_go/modelsv2"
+)
+
+// TriggerIterator iterates over the triggers returned by a ListTriggers query
+type TriggerIterator struct {
+	*iterator
+}
+
+// Triggers returns an iterator over all triggers matching params (which may be nil). The cursor, page size and context of
+// params are managed by the iterator.
+func Triggers(ctx context.Context, client *clientv2.Fn, params *triggers.ListTriggersParams, opts Options) *TriggerIterator {
+	if params == nil {
+		params = triggers.NewListTriggersParams()
+	}
+	return &TriggerIterator{newIterator(ctx, opts, func(ctx context.Context, cursor *string, perPage *int64) (interface{}, string, error) {
+		p := *params
+		p.Context, p.Cursor, p.PerPage = ctx, cursor, perPage
+		res, err := client.Triggers.ListTriggers(&p)
+		if err != nil {
+			return nil, "", err
+		}
+		return res.Payload.Items, res.Payload.NextCursor, nil
+	})}
+}
+
+// Trigger returns the current trigger
+func (i *TriggerIterator) Trigger() *modelsv2.Trigger {
+	trigger, _ := i.current.(*modelsv2.Trigger)
+	return trigger
+}
+
+// All consumes the remainder of the iterator and returns the triggers it produced
+func (i *TriggerIterator) All() ([]*modelsv2.Trigger, error) {
+	var result []*modelsv2.Trigger
+	err := i.all(&result)
+	return result, err
+}
@@ -11,7 +11,11 @@ const (
 	//CfgFnAPIURL is a config key used as the default URL for resolving the API server - different providers may generate URLs in their own way
 	CfgFnAPIURL      = "api-url"
 	CfgFnToken       = "token"
+	CfgFnProvider    = "provider"
+	CfgFnTokenFile   = "token-file"
 	contextRequestID = ridKey("request-id")
+	// contextCompartmentID scopes calls to a compartment other than the one the provider was configured with
+	contextCompartmentID = ridKey("compartment-id")
 )
 
 // ConfigSource abstracts  loading configuration keys from an underlying configuration system such as Viper
@@ -55,3 +59,22 @@ func GetRequestID(ctx context.Context) string {
 
 	return ""
 }
+
+// WithCompartmentID overrides the compartment used by calls made with the returned context, for providers that
+// organise resources into compartments
+func WithCompartmentID(ctx context.Context, compartmentID string) context.Context {
+	return context.WithValue(ctx, contextCompartmentID, compartmentID)
+}
+
+// GetCompartmentID returns the compartment override from the context, or "" if there is none
+func GetCompartmentID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	compartmentID, ok := ctx.Value(contextCompartmentID).(string)
+	if ok {
+		return compartmentID
+	}
+
+	return ""
+}
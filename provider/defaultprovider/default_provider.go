@@ -3,6 +3,7 @@ package defaultprovider
 import (
 	openapi "github.com/go-openapi/runtime/client"
 
+	"fmt"
 	"net/http"
 	"net/url"
 
@@ -10,6 +11,7 @@ import (
 
 	"github.com/fnproject/fn_go/client/version"
 	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/modelsv2"
 	"github.com/fnproject/fn_go/provider"
 	"github.com/go-openapi/strfmt"
 )
@@ -18,15 +20,21 @@ import (
 type Provider struct {
 	// Optional token to add as  bearer token to auth calls
 	Token string
+	// Optional source of bearer tokens that is asked for a token on every call, this takes the place of Token when the
+	// token is rotated or expires
+	TokenSource provider.TokenSource
+	// Optional hosts other than the API host that the bearer token is sent to, such as the host of annotated invoke
+	// endpoints; requests to any other host are sent without it
+	CredentialHosts []string
 	// API url to use for FN API interactions
 	FnApiUrl *url.URL
+	// Optional policy for retrying failed requests, nil disables retries
+	RetryPolicy *provider.RetryPolicy
 }
 
 func (dp *Provider) APIClientv2() *clientv2.Fn {
 	transport := openapi.New(dp.FnApiUrl.Host, path.Join(dp.FnApiUrl.Path, clientv2.DefaultBasePath), []string{dp.FnApiUrl.Scheme})
-	if dp.Token != "" {
-		transport.DefaultAuthentication = openapi.BearerToken(dp.Token)
-	}
+	transport.Transport = dp.WrapCallTransport(transport.Transport)
 
 	return clientv2.New(transport, strfmt.Default)
 }
@@ -40,14 +48,44 @@ func NewFromConfig(configSource provider.ConfigSource, _ provider.PassPhraseSour
 		return nil, err
 	}
 
+	retryPolicy, err := provider.RetryPolicyFromConfig(configSource)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenSource, err := provider.TokenSourceFromConfig(configSource)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Provider{
-		Token:    configSource.GetString(provider.CfgFnToken),
-		FnApiUrl: apiUrl,
+		Token:           configSource.GetString(provider.CfgFnToken),
+		TokenSource:     tokenSource,
+		CredentialHosts: provider.CredentialHostsFromConfig(configSource),
+		FnApiUrl:        apiUrl,
+		RetryPolicy:     retryPolicy,
 	}, nil
 }
 
+// WrapCallTransport adds the bearer token to requests for the API host and CredentialHosts, refreshing it if it is
+// rejected, and retries failed requests
 func (dp *Provider) WrapCallTransport(t http.RoundTripper) http.RoundTripper {
-	return t
+	if tokenSource := dp.tokenSource(); tokenSource != nil {
+		hosts := append([]string{provider.HostPort(dp.FnApiUrl)}, dp.CredentialHosts...)
+		t = provider.HostRestrictedRoundTripper(hosts, provider.TokenSourceRoundTripper(tokenSource, t), t)
+	}
+	return provider.RetryRoundTripper(dp.RetryPolicy, t)
+}
+
+// tokenSource returns the source of bearer tokens for calls, or nil for un-authenticated calls
+func (dp *Provider) tokenSource() provider.TokenSource {
+	if dp.TokenSource != nil {
+		return dp.TokenSource
+	}
+	if dp.Token != "" {
+		return provider.StaticTokenSource(dp.Token)
+	}
+	return nil
 }
 
 func (dp *Provider) UnavailableResources() []provider.FnResourceType {
@@ -61,9 +99,7 @@ func (dp *Provider) APIURL() *url.URL {
 func (dp *Provider) APIClient() *clientv2.Fn {
 	join := path.Join(dp.FnApiUrl.Path, clientv2.DefaultBasePath)
 	transport := openapi.New(dp.FnApiUrl.Host, join, []string{dp.FnApiUrl.Scheme})
-	if dp.Token != "" {
-		transport.DefaultAuthentication = openapi.BearerToken(dp.Token)
-	}
+	transport.Transport = dp.WrapCallTransport(transport.Transport)
 
 	return clientv2.New(transport, strfmt.Default)
 }
@@ -73,3 +109,18 @@ func (op *Provider) VersionClient() *version.Client {
 	runtime.Transport = op.WrapCallTransport(runtime.Transport)
 	return version.New(runtime, strfmt.Default)
 }
+
+// InvokeClient returns a client that invokes functions directly against the Fn server, falling back to the server's
+// /invoke/{fnID} endpoint for functions without an invoke endpoint annotation
+func (op *Provider) InvokeClient() provider.InvokeClient {
+	transport := op.WrapCallTransport(http.DefaultTransport)
+
+	return provider.NewInvokeClient(transport, func(fn *modelsv2.Fn) (string, error) {
+		if fn.ID == "" {
+			return "", fmt.Errorf("function has no ID")
+		}
+		u := *op.FnApiUrl
+		u.Path = path.Join(u.Path, "invoke", fn.ID)
+		return u.String(), nil
+	})
+}
@@ -0,0 +1,80 @@
+package defaultprovider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/fnerrors"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider"
+)
+
+func TestAPIClientReturnsGeneratedErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"App not found"}`))
+	}))
+	defer srv.Close()
+
+	p, err := NewFromConfig(provider.NewConfigSourceFromMap(map[string]string{provider.CfgFnAPIURL: srv.URL}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.APIClientv2().Apps.GetApp(apps.NewGetAppParams().WithAppID("missing"))
+
+	// callers that type assert on the generated errors keep working, and the fnerrors helpers classify them too
+	if _, ok := err.(*apps.GetAppNotFound); !ok {
+		t.Errorf("expected a *apps.GetAppNotFound, got %T", err)
+	}
+	if !fnerrors.IsNotFound(err) || fnerrors.AsError(err).Message != "App not found" {
+		t.Errorf("expected the error to be classified as not found, got %+v", fnerrors.AsError(err))
+	}
+}
+
+func TestInvokeClientSendsTokenOnlyToCredentialHosts(t *testing.T) {
+	var mu sync.Mutex
+	got := map[string]string{}
+	server := func(name string) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			got[name] = r.Header.Get("Authorization")
+			mu.Unlock()
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+	api, invoke, trusted := server("api"), server("invoke"), server("trusted")
+
+	p, err := NewFromConfig(provider.NewConfigSourceFromMap(map[string]string{
+		provider.CfgFnAPIURL:          api.URL,
+		provider.CfgFnToken:           "token",
+		provider.CfgFnCredentialHosts: trusted.Listener.Addr().String(),
+	}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := p.(*Provider).InvokeClient()
+	for _, fn := range []*modelsv2.Fn{
+		{ID: "fn"},
+		{ID: "fn", Annotations: map[string]interface{}{provider.AnnotationInvokeEndpoint: invoke.URL + "/invoke/fn"}},
+		{ID: "fn", Annotations: map[string]interface{}{provider.AnnotationInvokeEndpoint: trusted.URL + "/invoke/fn"}},
+	} {
+		resp, err := client.Invoke(context.Background(), fn, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	if got["api"] != "Bearer token" || got["trusted"] != "Bearer token" {
+		t.Errorf("expected the token to be sent to the API and credential hosts, got %v", got)
+	}
+	if auth, ok := got["invoke"]; !ok || auth != "" {
+		t.Errorf("expected the token not to be sent to another invoke endpoint, got %v", got)
+	}
+}
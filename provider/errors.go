@@ -0,0 +1,29 @@
+package provider
+
+import (
+	"github.com/fnproject/fn_go/fnerrors"
+	"github.com/go-openapi/runtime"
+)
+
+type errorTransport struct {
+	transport runtime.ClientTransport
+}
+
+// WrapErrors converts errors returned by the generated API clients into *fnerrors.Error, the request ID of the
+// operation's context is recorded on the error. Providers don't wrap the clients they return, so that callers can keep
+// type asserting on the generated errors; this is for programs that opt in with clients of their own.
+func WrapErrors(transport runtime.ClientTransport) runtime.ClientTransport {
+	return &errorTransport{transport: transport}
+}
+
+func (t *errorTransport) Submit(op *runtime.ClientOperation) (interface{}, error) {
+	result, err := t.transport.Submit(op)
+	if err != nil {
+		var requestID string
+		if op.Context != nil {
+			requestID = GetRequestID(op.Context)
+		}
+		return result, fnerrors.Wrap(err, requestID)
+	}
+	return result, nil
+}
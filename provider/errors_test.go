@@ -0,0 +1,44 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/fnerrors"
+	openapi "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+)
+
+func TestWrapErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message":"App not found"}`))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	client := clientv2.New(WrapErrors(openapi.New(u.Host, clientv2.DefaultBasePath, []string{"http"})), strfmt.Default)
+
+	ctx := WithRequestID(context.Background(), "request-id")
+	_, err := client.Apps.GetApp(apps.NewGetAppParams().WithContext(ctx).WithAppID("missing"))
+
+	var fnErr *fnerrors.Error
+	if !errors.As(err, &fnErr) {
+		t.Fatalf("expected an *fnerrors.Error, got %T", err)
+	}
+	if !fnerrors.IsNotFound(err) || fnErr.Message != "App not found" || fnErr.RequestID != "request-id" {
+		t.Errorf("unexpected error %+v", fnErr)
+	}
+
+	var notFound *apps.GetAppNotFound
+	if !errors.As(err, &notFound) {
+		t.Errorf("generated error type not reachable from %T", err)
+	}
+}
@@ -0,0 +1,124 @@
+package execprovider
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/fnproject/fn_go/client/version"
+	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider"
+	openapi "github.com/go-openapi/runtime/client"
+	"github.com/go-openapi/strfmt"
+)
+
+const (
+	// CfgExecCommand is the credential helper to run, arguments that contain spaces can be quoted
+	CfgExecCommand = "exec.command"
+	// CfgExecTimeout limits how long the credential helper may run for, as a Go duration such as 10s
+	CfgExecTimeout = "exec.timeout"
+)
+
+// Provider authenticates calls with the credentials returned by an external credential helper
+type Provider struct {
+	// API url to use for FN API interactions
+	FnApiUrl *url.URL
+	// TokenSource supplies the helper's credentials for calls
+	TokenSource provider.TokenSource
+	// Optional hosts other than the API host that the credentials are sent to, requests to any other host are sent
+	// without them
+	CredentialHosts []string
+	// Optional policy for retrying failed requests, nil disables retries
+	RetryPolicy *provider.RetryPolicy
+}
+
+// NewFromConfig creates an "exec" provider, which runs the command in exec.command to get credentials. The helper
+// prints a Credential as JSON; it is run once up front, and then again whenever the credential is about to expire or
+// is rejected by the server.
+func NewFromConfig(configSource provider.ConfigSource, _ provider.PassPhraseSource) (provider.Provider, error) {
+	if configSource.GetString(CfgExecCommand) == "" {
+		return nil, fmt.Errorf("%s must be set to the credential helper to run", CfgExecCommand)
+	}
+	command, err := provider.ParseCommandLine(configSource.GetString(CfgExecCommand))
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for %s: %s", CfgExecCommand, err)
+	}
+	timeout := provider.DefaultHelperCommandTimeout
+	if configSource.IsSet(CfgExecTimeout) {
+		if timeout, err = time.ParseDuration(configSource.GetString(CfgExecTimeout)); err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s", CfgExecTimeout, err)
+		}
+	}
+
+	retryPolicy, err := provider.RetryPolicyFromConfig(configSource)
+	if err != nil {
+		return nil, err
+	}
+
+	cfgApiUrl := configSource.GetString(provider.CfgFnAPIURL)
+	helper := NewHelper(command, cfgApiUrl, timeout)
+	credential, err := helper.Credential()
+	if err != nil {
+		return nil, err
+	}
+
+	// the helper may choose the endpoint, otherwise the configured one is used
+	if credential.APIURL != "" {
+		cfgApiUrl = credential.APIURL
+	}
+	apiUrl, err := provider.CanonicalFnAPIUrl(cfgApiUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		FnApiUrl:        apiUrl,
+		TokenSource:     helper.TokenSource(credential),
+		CredentialHosts: provider.CredentialHostsFromConfig(configSource),
+		RetryPolicy:     retryPolicy,
+	}, nil
+}
+
+// WrapCallTransport adds the helper's credentials to requests for the API host and CredentialHosts, refreshing them if
+// they are rejected, and retries failed requests
+func (ep *Provider) WrapCallTransport(t http.RoundTripper) http.RoundTripper {
+	hosts := append([]string{provider.HostPort(ep.FnApiUrl)}, ep.CredentialHosts...)
+	t = provider.HostRestrictedRoundTripper(hosts, provider.TokenSourceRoundTripper(ep.TokenSource, t), t)
+	return provider.RetryRoundTripper(ep.RetryPolicy, t)
+}
+
+func (ep *Provider) UnavailableResources() []provider.FnResourceType {
+	return []provider.FnResourceType{}
+}
+
+func (ep *Provider) APIURL() *url.URL {
+	return ep.FnApiUrl
+}
+
+func (ep *Provider) APIClientv2() *clientv2.Fn {
+	transport := openapi.New(ep.FnApiUrl.Host, path.Join(ep.FnApiUrl.Path, clientv2.DefaultBasePath), []string{ep.FnApiUrl.Scheme})
+	transport.Transport = ep.WrapCallTransport(transport.Transport)
+	return clientv2.New(transport, strfmt.Default)
+}
+
+func (ep *Provider) VersionClient() *version.Client {
+	runtime := openapi.New(ep.FnApiUrl.Host, ep.FnApiUrl.Path, []string{ep.FnApiUrl.Scheme})
+	runtime.Transport = ep.WrapCallTransport(runtime.Transport)
+	return version.New(runtime, strfmt.Default)
+}
+
+// InvokeClient returns a client that invokes functions with the helper's credentials, falling back to the server's
+// /invoke/{fnID} endpoint for functions without an invoke endpoint annotation
+func (ep *Provider) InvokeClient() provider.InvokeClient {
+	return provider.NewInvokeClient(ep.WrapCallTransport(http.DefaultTransport), func(fn *modelsv2.Fn) (string, error) {
+		if fn.ID == "" {
+			return "", fmt.Errorf("function has no ID")
+		}
+		u := *ep.FnApiUrl
+		u.Path = path.Join(u.Path, "invoke", fn.ID)
+		return u.String(), nil
+	})
+}
@@ -0,0 +1,182 @@
+package execprovider
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider"
+)
+
+// writeHelper writes a credential helper script that numbers its tokens, counting its runs in a file next to it. The
+// script is in a directory with a space in its name, so the returned command quotes it.
+func writeHelper(t *testing.T, output string) (string, func() int) {
+	dir := filepath.Join(t.TempDir(), "credential helper")
+	if err := os.Mkdir(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	countFile := filepath.Join(dir, "count")
+	script := filepath.Join(dir, "helper.sh")
+	content := fmt.Sprintf(`#!/bin/sh
+test "$%s" = "%s" || exit 2
+n=$(($(cat "%s" 2>/dev/null || echo 0) + 1))
+echo $n > "%s"
+%s
+`, EnvProtocol, ProtocolVersion, countFile, countFile, output)
+	if err := ioutil.WriteFile(script, []byte(content), 0700); err != nil {
+		t.Fatal(err)
+	}
+	return `"` + script + `"`, func() int {
+		data, _ := ioutil.ReadFile(countFile)
+		var n int
+		fmt.Sscan(string(data), &n)
+		return n
+	}
+}
+
+func TestExecProvider(t *testing.T) {
+	var mu sync.Mutex
+	accepted := "Bearer token-1"
+	var seen []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, r.URL.Path+" "+r.Header.Get("Authorization")+" "+r.Header.Get("X-Tenant"))
+		if r.Header.Get("Authorization") != accepted || r.Header.Get("X-Tenant") != "acme" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"items":[]}`))
+	}))
+	defer srv.Close()
+
+	expiry := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	helper, runs := writeHelper(t, fmt.Sprintf(`printf '{"version":"v1","token":"token-%%s","headers":{"X-Tenant":"acme"},"expiry":"%s","api-url":"%s"}' $n`, expiry, srv.URL))
+
+	p, err := NewFromConfig(provider.NewConfigSourceFromMap(map[string]string{CfgExecCommand: helper}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.APIURL().String() != srv.URL {
+		t.Errorf("expected the API URL to come from the helper, got %s", p.APIURL())
+	}
+
+	client := p.APIClientv2()
+	if _, err := client.Apps.ListApps(apps.NewListAppsParams()); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := p.InvokeClient().Invoke(context.Background(), &modelsv2.Fn{ID: "fnid"}, &provider.InvokeRequest{Body: strings.NewReader("hello")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if runs() != 1 {
+		t.Errorf("expected the credential to be cached, the helper ran %d times", runs())
+	}
+
+	// the server rejects the cached credential, so the helper is run again and the call retried
+	mu.Lock()
+	accepted = "Bearer token-2"
+	mu.Unlock()
+	if _, err := client.Apps.ListApps(apps.NewListAppsParams()); err != nil {
+		t.Fatal(err)
+	}
+	if runs() != 2 {
+		t.Errorf("expected the helper to run again after a 401, it ran %d times", runs())
+	}
+
+	// the credentials aren't sent to invoke endpoints on other hosts
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, "other "+r.Header.Get("Authorization")+" "+r.Header.Get("X-Tenant"))
+	}))
+	defer other.Close()
+	resp, err = p.InvokeClient().Invoke(context.Background(), &modelsv2.Fn{ID: "fnid", Annotations: map[string]interface{}{
+		provider.AnnotationInvokeEndpoint: other.URL + "/invoke/fnid",
+	}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []string{"/v2/apps Bearer token-1 acme", "/invoke/fnid Bearer token-1 acme", "/v2/apps Bearer token-1 acme", "/v2/apps Bearer token-2 acme", "other  "}
+	if strings.Join(seen, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected requests %v", seen)
+	}
+}
+
+func TestExecProviderRefreshesBeforeExpiry(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"items":[]}`))
+	}))
+	defer srv.Close()
+
+	// the credential is within the refresh window as soon as it is issued, so every call after the first (which uses the
+	// credential from when the provider was created) runs the helper
+	expiry := time.Now().Add(10 * time.Second).UTC().Format(time.RFC3339)
+	helper, runs := writeHelper(t, fmt.Sprintf(`printf '{"token":"token-%%s","expiry":"%s"}' $n`, expiry))
+	p, err := NewFromConfig(provider.NewConfigSourceFromMap(map[string]string{CfgExecCommand: helper, provider.CfgFnAPIURL: srv.URL}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := p.APIClientv2()
+	for i := 0; i < 3; i++ {
+		if _, err := client.Apps.ListApps(apps.NewListAppsParams()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if runs() != 3 {
+		t.Errorf("expected the helper to run for each call near expiry, it ran %d times", runs())
+	}
+}
+
+func TestExecProviderErrors(t *testing.T) {
+	helper, _ := writeHelper(t, `echo "not logged in, run fn-login" >&2; exit 1`)
+	_, err := NewFromConfig(provider.NewConfigSourceFromMap(map[string]string{CfgExecCommand: helper}), nil)
+	if err == nil || !strings.Contains(err.Error(), "not logged in, run fn-login") {
+		t.Errorf("expected the helper's stderr in the error, got %v", err)
+	}
+
+	helper, _ = writeHelper(t, `echo '{"version":"v2","token":"t"}'`)
+	_, err = NewFromConfig(provider.NewConfigSourceFromMap(map[string]string{CfgExecCommand: helper}), nil)
+	if err == nil || !strings.Contains(err.Error(), "unsupported protocol version v2") {
+		t.Errorf("expected an unsupported version error, got %v", err)
+	}
+
+	helper, _ = writeHelper(t, `echo '{"headers":{"X-Api-Key":"key"}}'`)
+	p, err := NewFromConfig(provider.NewConfigSourceFromMap(map[string]string{
+		CfgExecCommand:       helper,
+		provider.CfgFnAPIURL: "https://fn.example.com",
+	}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.APIURL().Hostname() != "fn.example.com" {
+		t.Errorf("expected the configured API URL when the helper doesn't return one, got %s", p.APIURL())
+	}
+
+	helper, _ = writeHelper(t, `sleep 10`)
+	_, err = NewFromConfig(provider.NewConfigSourceFromMap(map[string]string{CfgExecCommand: helper, CfgExecTimeout: "100ms"}), nil)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("expected a hung helper to time out, got %v", err)
+	}
+
+	if _, err := NewFromConfig(provider.NewConfigSourceFromMap(map[string]string{}), nil); err == nil {
+		t.Errorf("expected an error without a helper command")
+	}
+}
@@ -0,0 +1,103 @@
+package execprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/fnproject/fn_go/provider"
+)
+
+const (
+	// ProtocolVersion is the version of the credential helper protocol, it is passed to helpers in
+	// FN_CREDENTIAL_PROTOCOL and helpers may echo it back in the version field of their output
+	ProtocolVersion = "v1"
+
+	// EnvProtocol and EnvAPIURL are set in the environment of the helper, EnvAPIURL holds the configured api-url (if
+	// any) so that one helper can serve several contexts
+	EnvProtocol = "FN_CREDENTIAL_PROTOCOL"
+	EnvAPIURL   = "FN_CREDENTIAL_API_URL"
+)
+
+// Credential is the JSON document that a credential helper prints on stdout
+type Credential struct {
+	// Version is the protocol version the helper speaks, it may be omitted
+	Version string `json:"version,omitempty"`
+	// Token is sent as a bearer token, it may be omitted if Headers carry the credentials
+	Token string `json:"token,omitempty"`
+	// Headers are added to every request
+	Headers map[string]string `json:"headers,omitempty"`
+	// Expiry is when the credential should no longer be used, a credential without one is used until the server
+	// rejects it
+	Expiry *time.Time `json:"expiry,omitempty"`
+	// APIURL is the API endpoint to use, overriding the api-url config key; it is read from the first credential
+	APIURL string `json:"api-url,omitempty"`
+}
+
+func (c *Credential) header() http.Header {
+	header := http.Header{}
+	for k, v := range c.Headers {
+		header.Set(k, v)
+	}
+	return header
+}
+
+// Helper is a credential helper, it is run with the protocol version and API URL in its environment and its stderr is
+// passed through so that it can report problems
+type Helper struct {
+	Command *provider.HelperCommand
+}
+
+// NewHelper returns a Helper that runs args, passing it apiURL and killing it if it runs for longer than timeout
+func NewHelper(args []string, apiURL string, timeout time.Duration) *Helper {
+	return &Helper{Command: &provider.HelperCommand{
+		Args:    args,
+		Env:     []string{EnvProtocol + "=" + ProtocolVersion, EnvAPIURL + "=" + apiURL},
+		Timeout: timeout,
+		Stderr:  os.Stderr,
+	}}
+}
+
+// Credential runs the helper and returns the credential that it prints
+func (h *Helper) Credential() (*Credential, error) {
+	name := h.Command.Name()
+	out, err := h.Command.Output()
+	if err != nil {
+		return nil, fmt.Errorf("credential helper %s failed: %s", name, err)
+	}
+
+	credential := &Credential{}
+	if err := json.Unmarshal(out, credential); err != nil {
+		return nil, fmt.Errorf("invalid output from credential helper %s: %s", name, err)
+	}
+	if credential.Version != "" && credential.Version != ProtocolVersion {
+		return nil, fmt.Errorf("credential helper %s returned unsupported protocol version %s, expected %s", name, credential.Version, ProtocolVersion)
+	}
+	if credential.Token == "" && len(credential.Headers) == 0 {
+		return nil, fmt.Errorf("credential helper %s returned no token or headers", name)
+	}
+	return credential, nil
+}
+
+// TokenSource returns a token source for the helper's credentials, which runs the helper again shortly before the
+// credential expires or when the server rejects it. The first credential is initial if it is not nil.
+func (h *Helper) TokenSource(initial *Credential) provider.RefreshableTokenSource {
+	return provider.NewCachingTokenSource(func() (string, http.Header, time.Time, error) {
+		// fetches don't overlap, so initial is used exactly once
+		credential := initial
+		initial = nil
+		if credential == nil {
+			var err error
+			if credential, err = h.Credential(); err != nil {
+				return "", nil, time.Time{}, err
+			}
+		}
+		var expiry time.Time
+		if credential.Expiry != nil {
+			expiry = *credential.Expiry
+		}
+		return credential.Token, credential.header(), expiry, nil
+	})
+}
@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// FnContextEnvVar selects the Fn CLI context to load, overriding the current context in config.yaml
+	FnContextEnvVar = "FN_CONTEXT"
+
+	fnConfigDirName      = ".fn"
+	fnConfigFileName     = "config.yaml"
+	fnContextsDirName    = "contexts"
+	fnCurrentContextKey  = "current-context"
+	fnDefaultContextName = "default"
+	fnEnvPrefix          = "FN_"
+)
+
+// NewConfigSourceFromFnContext loads an Fn CLI context from ~/.fn the same way the fn CLI does and returns it as a
+// config source, along with the name of the provider that the context uses, ready for Providers.ProviderFromConfig.
+//
+// If contextName is empty the context named by FN_CONTEXT is used, then the current-context from ~/.fn/config.yaml. Keys
+// in the context can be overridden by FN_ environment variables: FN_API_URL overrides api-url, FN_PROVIDER overrides
+// provider and FN_ORACLE_COMPARTMENT_ID overrides oracle.compartment-id. The returned source reports which of these
+// each value came from.
+func NewConfigSourceFromFnContext(contextName string) (*LayeredConfigSource, string, error) {
+	home, err := homedir.Dir()
+	if err != nil {
+		return nil, "", fmt.Errorf("error getting home directory %s", err)
+	}
+	return NewConfigSourceFromFnContextDir(filepath.Join(home, fnConfigDirName), contextName)
+}
+
+// NewConfigSourceFromFnContextDir is NewConfigSourceFromFnContext with an explicit Fn CLI config directory in place of ~/.fn
+func NewConfigSourceFromFnContextDir(configDir, contextName string) (*LayeredConfigSource, string, error) {
+	if contextName == "" {
+		contextName = os.Getenv(FnContextEnvVar)
+	}
+	if contextName == "" {
+		var err error
+		if contextName, err = currentFnContext(configDir); err != nil {
+			return nil, "", err
+		}
+	}
+	if strings.ContainsAny(contextName, `/\`) || contextName == "." || contextName == ".." {
+		return nil, "", fmt.Errorf("invalid Fn context name %q", contextName)
+	}
+
+	contextFile := filepath.Join(configDir, fnContextsDirName, contextName+".yaml")
+	contextLayer, err := NewFileConfigLayer(contextFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, "", fmt.Errorf("Fn context %s does not exist: %w", contextName, err)
+		}
+		return nil, "", fmt.Errorf("failed to read Fn context %s: %w", contextName, err)
+	}
+
+	source := NewLayeredConfigSource(contextLayer, NewEnvConfigLayer(fnEnvPrefix))
+	return source, source.GetString(CfgFnProvider), nil
+}
+
+// currentFnContext returns the current-context from config.yaml, a missing file or key means the default context
+func currentFnContext(configDir string) (string, error) {
+	values, err := readFnYAML(filepath.Join(configDir, fnConfigFileName))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fnDefaultContextName, nil
+		}
+		return "", fmt.Errorf("failed to read Fn CLI config: %w", err)
+	}
+	if current, ok := values[fnCurrentContextKey].(string); ok && current != "" {
+		return current, nil
+	}
+	return fnDefaultContextName, nil
+}
+
+func readFnYAML(path string) (map[string]interface{}, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("invalid YAML in %s: %w", path, err)
+	}
+	return values, nil
+}
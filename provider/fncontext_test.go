@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFnConfig(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// clearFnEnv unsets any FN_ variables for the duration of a test
+func clearFnEnv(t *testing.T) {
+	for _, kv := range os.Environ() {
+		if name := strings.SplitN(kv, "=", 2)[0]; strings.HasPrefix(name, "FN_") {
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func TestConfigSourceFromFnContext(t *testing.T) {
+	dir := t.TempDir()
+	clearFnEnv(t)
+
+	writeFnConfig(t, dir, "config.yaml", "cli-version: 0.6.0\ncurrent-context: prod\n")
+	writeFnConfig(t, dir, "contexts/default.yaml", "api-url: http://localhost:8080\nprovider: default\n")
+	writeFnConfig(t, dir, "contexts/prod.yaml", `api-url: https://functions.us-ashburn-1.oraclecloud.com
+provider: oracle
+registry: iad.ocir.io/tenancy/repo
+oracle.compartment-id: ocid1.compartment.oc1..prod
+oracle.disable-certs: true
+oracle:
+  profile: PROD
+`)
+
+	source, providerName, err := NewConfigSourceFromFnContextDir(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if providerName != "oracle" {
+		t.Errorf("expected the current context's provider, got %q", providerName)
+	}
+	for key, expected := range map[string]string{
+		CfgFnAPIURL:             "https://functions.us-ashburn-1.oraclecloud.com",
+		"oracle.compartment-id": "ocid1.compartment.oc1..prod",
+		"oracle.profile":        "PROD",
+	} {
+		if got := source.GetString(key); got != expected {
+			t.Errorf("expected %s to be %q, got %q", key, expected, got)
+		}
+	}
+	if !source.GetBool("oracle.disable-certs") {
+		t.Errorf("expected oracle.disable-certs to be true")
+	}
+	if source.IsSet(CfgFnToken) {
+		t.Errorf("token should not be set")
+	}
+
+	t.Setenv("FN_API_URL", "https://override.example.com")
+	t.Setenv("FN_TOKEN", "secret")
+	t.Setenv("FN_ORACLE_COMPARTMENT_ID", "ocid1.compartment.oc1..env")
+	source, _, err = NewConfigSourceFromFnContextDir(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := source.GetString(CfgFnAPIURL); got != "https://override.example.com" {
+		t.Errorf("expected FN_API_URL to override api-url, got %q", got)
+	}
+	if got := source.GetString(CfgFnToken); got != "secret" {
+		t.Errorf("expected FN_TOKEN to set token, got %q", got)
+	}
+	if got := source.GetString("oracle.compartment-id"); got != "ocid1.compartment.oc1..env" {
+		t.Errorf("expected FN_ORACLE_COMPARTMENT_ID to override oracle.compartment-id, got %q", got)
+	}
+
+	t.Setenv(FnContextEnvVar, "default")
+	_, providerName, err = NewConfigSourceFromFnContextDir(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if providerName != "default" {
+		t.Errorf("expected FN_CONTEXT to select the default context, got provider %q", providerName)
+	}
+
+	if _, _, err := NewConfigSourceFromFnContextDir(dir, "missing"); err == nil {
+		t.Errorf("expected an error for a missing context")
+	}
+	if _, _, err := NewConfigSourceFromFnContextDir(dir, "../config"); err == nil {
+		t.Errorf("expected an error for a context name containing a path")
+	}
+}
+
+func TestConfigSourceFromFnContextWithoutConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	clearFnEnv(t)
+	writeFnConfig(t, dir, "contexts/default.yaml", "api-url: http://localhost:8080\n")
+
+	source, providerName, err := NewConfigSourceFromFnContextDir(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if providerName != "" {
+		t.Errorf("expected no provider, got %q", providerName)
+	}
+	if got := source.GetString(CfgFnAPIURL); got != "http://localhost:8080" {
+		t.Errorf("expected the default context to be used, got api-url %q", got)
+	}
+}
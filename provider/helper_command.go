@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	// DefaultHelperCommandTimeout is how long a helper command may run for when no timeout is configured
+	DefaultHelperCommandTimeout = 30 * time.Second
+
+	// only the end of a failing command's stderr is included in errors
+	maxHelperStderrBytes = 4 * 1024
+)
+
+// HelperCommand is an external program that is run to get a credential or passphrase. It is run directly rather than
+// through a shell, with its stdin connected to the null device, and it is killed if it runs for longer than Timeout so
+// that a hung helper can't block callers indefinitely.
+type HelperCommand struct {
+	// Args is the program to run followed by its arguments
+	Args []string
+	// Env is added to the environment of the program
+	Env []string
+	// Timeout limits how long the program may run for, DefaultHelperCommandTimeout is used if it is zero
+	Timeout time.Duration
+	// Stderr receives a copy of the program's stderr if it is not nil
+	Stderr io.Writer
+}
+
+// ParseCommandLine splits a configured command into the program and its arguments. Arguments are separated by
+// whitespace, and an argument (or part of one) that contains whitespace can be quoted with single or double quotes;
+// there are no escape characters, so Windows paths can be used as-is.
+func ParseCommandLine(command string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote in command %s", quote, command)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return args, nil
+}
+
+// Name is the program that the command runs
+func (c *HelperCommand) Name() string {
+	if len(c.Args) == 0 {
+		return ""
+	}
+	return c.Args[0]
+}
+
+// Output runs the command and returns what it prints on stdout. Errors include the end of the command's stderr so that
+// helpers can explain why they failed.
+func (c *HelperCommand) Output() ([]byte, error) {
+	if len(c.Args) == 0 {
+		return nil, errors.New("no command")
+	}
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHelperCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Args[0], c.Args[1:]...)
+	cmd.Env = append(os.Environ(), c.Env...)
+	// helpers must not wait for input on the caller's stdin, they read from the null device
+	cmd.Stdin = nil
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if c.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(c.Stderr, &stderr)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("timed out after %s", timeout)
+		}
+		if err != nil {
+			msg := strings.TrimSpace(stderr.String())
+			if len(msg) > maxHelperStderrBytes {
+				msg = msg[len(msg)-maxHelperStderrBytes:]
+			}
+			if msg != "" {
+				return nil, fmt.Errorf("%s: %s", err, msg)
+			}
+			return nil, err
+		}
+		return stdout.Bytes(), nil
+	case <-ctx.Done():
+		// the process is killed, but anything it started may hold its output open and keep Wait from returning
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+}
@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	for command, expected := range map[string][]string{
+		"fn-login --print-token":                      {"fn-login", "--print-token"},
+		`  "/opt/My Tools/fn-login"  --profile 'a b'`: {"/opt/My Tools/fn-login", "--profile", "a b"},
+		`C:\tools\fn-login.exe --user="it's me" ''`:   {`C:\tools\fn-login.exe`, "--user=it's me", ""},
+	} {
+		args, err := ParseCommandLine(command)
+		if err != nil || !reflect.DeepEqual(args, expected) {
+			t.Errorf("expected %q to be parsed as %q, got %q %v", command, expected, args, err)
+		}
+	}
+
+	for _, command := range []string{"", "   ", `fn-login "--profile`} {
+		if _, err := ParseCommandLine(command); err == nil {
+			t.Errorf("expected an error for %q", command)
+		}
+	}
+}
+
+func TestHelperCommandOutput(t *testing.T) {
+	// stdin is the null device, so a helper that reads it doesn't wait for input
+	out, err := (&HelperCommand{Args: []string{"sh", "-c", "cat; echo $FN_TEST_VALUE"}, Env: []string{"FN_TEST_VALUE=value"}}).Output()
+	if err != nil || string(out) != "value\n" {
+		t.Errorf("expected the command's output, got %q %v", out, err)
+	}
+
+	// the helper is killed when it times out, even if something it started keeps its output open
+	start := time.Now()
+	_, err = (&HelperCommand{Args: []string{"sh", "-c", "sleep 10; echo late"}, Timeout: 100 * time.Millisecond}).Output()
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("expected the command to time out, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the command to be stopped at its timeout, it took %s", elapsed)
+	}
+}
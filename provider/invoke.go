@@ -0,0 +1,257 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/fnproject/fn_go/fnerrors"
+	"github.com/fnproject/fn_go/modelsv2"
+)
+
+const (
+	// AnnotationInvokeEndpoint is the function annotation that holds the URL to use when invoking a function
+	AnnotationInvokeEndpoint = "fnproject.io/fn/invokeEndpoint"
+
+	// HeaderCallID is returned by the Fn server with the ID of the call that handled an invocation
+	HeaderCallID = "Fn-Call-Id"
+	// HeaderFnHTTPStatus carries the HTTP status set by an HTTP-gateway-aware function
+	HeaderFnHTTPStatus = "Fn-Http-Status"
+	// HeaderFnFdkPrefix prefixes headers set by the function development kit (e.g. Fn-Fdk-Version)
+	HeaderFnFdkPrefix = "Fn-Fdk-"
+	// HeaderFnIntent tells the FDK how to interpret the invocation body (e.g. "httprequest", "cloudevent")
+	HeaderFnIntent = "Fn-Intent"
+	// HeaderFnInvokeType selects between synchronous and detached invocations
+	HeaderFnInvokeType = "Fn-Invoke-Type"
+
+	// InvokeTypeSync waits for the function to complete and returns its output
+	InvokeTypeSync = "sync"
+	// InvokeTypeDetached returns as soon as the call has been accepted, leaving result handling to the function
+	InvokeTypeDetached = "detached"
+
+	headerOpcRequestID = "Opc-Request-Id"
+	maxErrorBodyBytes  = 64 * 1024
+)
+
+// InvokeClient invokes functions by way of their invoke endpoint
+type InvokeClient interface {
+	// Invoke calls a function synchronously. The caller must close the response body.
+	Invoke(ctx context.Context, fn *modelsv2.Fn, req *InvokeRequest) (*InvokeResponse, error)
+	// InvokeDetached starts a function call without waiting for its result and returns a handle to the accepted call
+	InvokeDetached(ctx context.Context, fn *modelsv2.Fn, req *InvokeRequest) (*CallHandle, error)
+}
+
+// InvokeRequest describes the input to a function invocation; a nil request invokes the function with an empty body
+type InvokeRequest struct {
+	// Body is streamed to the function as-is, it may be nil
+	Body io.Reader
+	// ContentType is the content type of Body, if empty no Content-Type header is sent
+	ContentType string
+	// Intent is sent as the Fn-Intent header if set
+	Intent string
+	// Header contains any additional headers to add to the request
+	Header http.Header
+}
+
+// InvokeResponse is the result of a successful synchronous invocation
+type InvokeResponse struct {
+	// StatusCode is the HTTP status code returned by the invoke endpoint
+	StatusCode int
+	// Header contains all of the response headers
+	Header http.Header
+	// Body streams the function output, it must be closed by the caller
+	Body io.ReadCloser
+	// CallID is the ID of the call that served the invocation
+	CallID string
+	// RequestID is the request ID returned by the server, if any
+	RequestID string
+	// FnHTTPStatus is the value of the Fn-Http-Status header, or 0 if the function did not set one
+	FnHTTPStatus int
+	// FdkHeaders contains the Fn-Fdk-* headers set by the function's FDK
+	FdkHeaders http.Header
+}
+
+// CallHandle identifies a detached call that has been accepted by the server
+type CallHandle struct {
+	// CallID is the ID of the accepted call
+	CallID string
+	// RequestID is the request ID returned by the server, if any
+	RequestID string
+	// StatusCode is the HTTP status with which the call was accepted (normally 202)
+	StatusCode int
+}
+
+// InvokeError is returned when the invoke endpoint responds with a non-successful status
+type InvokeError struct {
+	StatusCode int
+	CallID     string
+	RequestID  string
+	Message    string
+}
+
+func (e *InvokeError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("invoke failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("invoke failed with status %d: %s", e.StatusCode, e.Message)
+}
+
+// Code returns the HTTP status code of the failed invocation
+func (e *InvokeError) Code() int {
+	return e.StatusCode
+}
+
+// EndpointResolver computes the invoke URL for functions that do not carry an invoke endpoint annotation
+type EndpointResolver func(fn *modelsv2.Fn) (string, error)
+
+type httpInvokeClient struct {
+	client   *http.Client
+	fallback EndpointResolver
+}
+
+// NewInvokeClient creates an invoke client that sends requests through transport (which should already carry any
+// auth required by the provider). Endpoints are taken from the function's invoke endpoint annotation, or from
+// fallback if the annotation is missing and fallback is not nil.
+func NewInvokeClient(transport http.RoundTripper, fallback EndpointResolver) InvokeClient {
+	return &httpInvokeClient{
+		client:   &http.Client{Transport: transport},
+		fallback: fallback,
+	}
+}
+
+// InvokeEndpoint returns the invoke URL of fn using its invoke endpoint annotation
+func InvokeEndpoint(fn *modelsv2.Fn) (string, error) {
+	if fn == nil {
+		return "", fmt.Errorf("no function specified")
+	}
+	endpoint, ok := fn.Annotations[AnnotationInvokeEndpoint]
+	if !ok {
+		return "", fmt.Errorf("function %s has no %s annotation", fn.ID, AnnotationInvokeEndpoint)
+	}
+	endpointStr, ok := endpoint.(string)
+	if !ok || endpointStr == "" {
+		return "", fmt.Errorf("function %s has an invalid %s annotation", fn.ID, AnnotationInvokeEndpoint)
+	}
+	return endpointStr, nil
+}
+
+func (c *httpInvokeClient) endpoint(fn *modelsv2.Fn) (string, error) {
+	endpoint, err := InvokeEndpoint(fn)
+	if err != nil && fn != nil && c.fallback != nil {
+		return c.fallback(fn)
+	}
+	return endpoint, err
+}
+
+func (c *httpInvokeClient) Invoke(ctx context.Context, fn *modelsv2.Fn, req *InvokeRequest) (*InvokeResponse, error) {
+	resp, err := c.do(ctx, fn, req, InvokeTypeSync)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InvokeResponse{
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header,
+		Body:         resp.Body,
+		CallID:       resp.Header.Get(HeaderCallID),
+		RequestID:    resp.Header.Get(headerOpcRequestID),
+		FnHTTPStatus: fnHTTPStatus(resp.Header),
+		FdkHeaders:   fdkHeaders(resp.Header),
+	}, nil
+}
+
+func (c *httpInvokeClient) InvokeDetached(ctx context.Context, fn *modelsv2.Fn, req *InvokeRequest) (*CallHandle, error) {
+	resp, err := c.do(ctx, fn, req, InvokeTypeDetached)
+	if err != nil {
+		return nil, err
+	}
+	// detached calls carry no useful body - drain it so that the connection can be reused
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxErrorBodyBytes))
+	resp.Body.Close()
+
+	return &CallHandle{
+		CallID:     resp.Header.Get(HeaderCallID),
+		RequestID:  resp.Header.Get(headerOpcRequestID),
+		StatusCode: resp.StatusCode,
+	}, nil
+}
+
+// do sends the invocation and returns the raw response, any non-2xx response is converted into an *InvokeError
+// (wrapped in an *fnerrors.Error)
+func (c *httpInvokeClient) do(ctx context.Context, fn *modelsv2.Fn, req *InvokeRequest, invokeType string) (*http.Response, error) {
+	endpoint, err := c.endpoint(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	if req == nil {
+		req = &InvokeRequest{}
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, endpoint, req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid invoke request: %s", err)
+	}
+	if ctx != nil {
+		httpReq = httpReq.WithContext(ctx)
+	}
+
+	for k, vs := range req.Header {
+		for _, v := range vs {
+			httpReq.Header.Add(k, v)
+		}
+	}
+	if req.ContentType != "" {
+		httpReq.Header.Set("Content-Type", req.ContentType)
+	}
+	if req.Intent != "" {
+		httpReq.Header.Set(HeaderFnIntent, req.Intent)
+	}
+	httpReq.Header.Set(HeaderFnInvokeType, invokeType)
+	if ctx != nil {
+		if requestID := GetRequestID(ctx); requestID != "" && httpReq.Header.Get(headerOpcRequestID) == "" {
+			httpReq.Header.Set(headerOpcRequestID, requestID)
+		}
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		invokeErr := &InvokeError{
+			StatusCode: resp.StatusCode,
+			CallID:     resp.Header.Get(HeaderCallID),
+			RequestID:  resp.Header.Get(headerOpcRequestID),
+			Message:    strings.TrimSpace(string(body)),
+		}
+		return nil, fnerrors.Wrap(invokeErr, invokeErr.RequestID)
+	}
+
+	return resp, nil
+}
+
+func fnHTTPStatus(header http.Header) int {
+	status, err := strconv.Atoi(header.Get(HeaderFnHTTPStatus))
+	if err != nil {
+		return 0
+	}
+	return status
+}
+
+func fdkHeaders(header http.Header) http.Header {
+	result := http.Header{}
+	for k, vs := range header {
+		if strings.HasPrefix(http.CanonicalHeaderKey(k), HeaderFnFdkPrefix) {
+			result[k] = vs
+		}
+	}
+	return result
+}
@@ -0,0 +1,115 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/fnproject/fn_go/fnerrors"
+	"github.com/fnproject/fn_go/modelsv2"
+)
+
+func TestInvokeStreamsBodyAndReturnsFnHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/invoke/fnid" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "text/plain" {
+			t.Errorf("unexpected content type %s", ct)
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Header().Set(HeaderCallID, "callid")
+		w.Header().Set(HeaderFnHTTPStatus, "201")
+		w.Header().Set("Fn-Fdk-Version", "fdk-go/0.0.1")
+		w.Write([]byte("hello " + string(body)))
+	}))
+	defer srv.Close()
+
+	fn := &modelsv2.Fn{
+		ID:          "fnid",
+		Annotations: map[string]interface{}{AnnotationInvokeEndpoint: srv.URL + "/invoke/fnid"},
+	}
+
+	resp, err := NewInvokeClient(http.DefaultTransport, nil).Invoke(context.Background(), fn, &InvokeRequest{
+		Body:        strings.NewReader("world"),
+		ContentType: "text/plain",
+	})
+	if err != nil {
+		t.Fatalf("invoke failed: %s", err)
+	}
+	defer resp.Body.Close()
+
+	out, _ := ioutil.ReadAll(resp.Body)
+	if string(out) != "hello world" {
+		t.Errorf("unexpected body %q", out)
+	}
+	if resp.CallID != "callid" {
+		t.Errorf("unexpected call ID %q", resp.CallID)
+	}
+	if resp.FnHTTPStatus != 201 {
+		t.Errorf("unexpected Fn-Http-Status %d", resp.FnHTTPStatus)
+	}
+	if resp.FdkHeaders.Get("Fn-Fdk-Version") != "fdk-go/0.0.1" {
+		t.Errorf("FDK headers not returned: %v", resp.FdkHeaders)
+	}
+}
+
+func TestInvokeEndpointFallbackAndErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderCallID, "failedcall")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("function failed"))
+	}))
+	defer srv.Close()
+
+	fn := &modelsv2.Fn{ID: "fnid"}
+
+	if _, err := NewInvokeClient(http.DefaultTransport, nil).Invoke(context.Background(), fn, nil); err == nil {
+		t.Fatal("expected an error for a function without an invoke endpoint")
+	}
+
+	client := NewInvokeClient(http.DefaultTransport, func(fn *modelsv2.Fn) (string, error) {
+		return srv.URL + "/invoke/" + fn.ID, nil
+	})
+	_, err := client.Invoke(context.Background(), fn, nil)
+	var invokeErr *InvokeError
+	if !errors.As(err, &invokeErr) {
+		t.Fatalf("expected an *InvokeError, got %v", err)
+	}
+	if fnerrors.StatusCode(err) != http.StatusBadGateway {
+		t.Errorf("unexpected status code %d", fnerrors.StatusCode(err))
+	}
+	if invokeErr.StatusCode != http.StatusBadGateway || invokeErr.CallID != "failedcall" || invokeErr.Message != "function failed" {
+		t.Errorf("unexpected error %+v", invokeErr)
+	}
+}
+
+func TestInvokeDetached(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if it := r.Header.Get(HeaderFnInvokeType); it != InvokeTypeDetached {
+			t.Errorf("unexpected invoke type %q", it)
+		}
+		w.Header().Set(HeaderCallID, "detachedcall")
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	fn := &modelsv2.Fn{
+		ID:          "fnid",
+		Annotations: map[string]interface{}{AnnotationInvokeEndpoint: srv.URL + "/invoke/fnid"},
+	}
+
+	handle, err := NewInvokeClient(http.DefaultTransport, nil).InvokeDetached(context.Background(), fn, &InvokeRequest{
+		Body: strings.NewReader("event"),
+	})
+	if err != nil {
+		t.Fatalf("detached invoke failed: %s", err)
+	}
+	if handle.CallID != "detachedcall" || handle.StatusCode != http.StatusAccepted {
+		t.Errorf("unexpected call handle %+v", handle)
+	}
+}
@@ -0,0 +1,216 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigLayer is one level of a LayeredConfigSource
+type ConfigLayer interface {
+	// Lookup returns the value of a key in this layer and a description of where it came from
+	Lookup(key string) (value string, origin string, ok bool)
+}
+
+// ConfigValue is the effective value of a config key along with where it came from
+type ConfigValue struct {
+	Key   string
+	Value string
+	// Origin describes where the value came from, e.g. "environment variable FN_API_URL", and is empty if the key is not set
+	Origin string
+	// Overridden lists the origins of lower precedence values for the key that were ignored
+	Overridden []string
+}
+
+// IsSet reports whether any layer set the key
+func (v ConfigValue) IsSet() bool {
+	return v.Origin != ""
+}
+
+// String describes where the value came from without including the value itself, which may be a secret
+func (v ConfigValue) String() string {
+	if !v.IsSet() {
+		return v.Key + " is not set"
+	}
+	s := v.Key + " is set by " + v.Origin
+	if len(v.Overridden) > 0 {
+		s += ", overriding " + strings.Join(v.Overridden, ", ")
+	}
+	return s
+}
+
+// LayeredConfigSource is a ConfigSource that stacks layers of configuration, such as defaults, files, environment
+// variables and explicit overrides. Layers are given in increasing order of precedence: the value of a key comes from
+// the last layer that sets it.
+type LayeredConfigSource struct {
+	layers []ConfigLayer
+}
+
+var _ ConfigSource = &LayeredConfigSource{}
+
+// NewLayeredConfigSource creates a config source from layers in increasing order of precedence
+func NewLayeredConfigSource(layers ...ConfigLayer) *LayeredConfigSource {
+	return &LayeredConfigSource{layers: layers}
+}
+
+// With returns a new source with an extra layer that takes precedence over all of the existing ones
+func (s *LayeredConfigSource) With(layer ConfigLayer) *LayeredConfigSource {
+	layers := make([]ConfigLayer, 0, len(s.layers)+1)
+	layers = append(layers, s.layers...)
+	return &LayeredConfigSource{layers: append(layers, layer)}
+}
+
+// Lookup returns the effective value of a key and where it came from
+func (s *LayeredConfigSource) Lookup(key string) ConfigValue {
+	result := ConfigValue{Key: key}
+	for i := len(s.layers) - 1; i >= 0; i-- {
+		value, origin, ok := s.layers[i].Lookup(key)
+		if !ok {
+			continue
+		}
+		if result.IsSet() {
+			result.Overridden = append(result.Overridden, origin)
+		} else {
+			result.Value, result.Origin = value, origin
+		}
+	}
+	return result
+}
+
+// Explain returns the effective values of the given keys, for reporting how a configuration was resolved
+func (s *LayeredConfigSource) Explain(keys ...string) []ConfigValue {
+	result := make([]ConfigValue, 0, len(keys))
+	for _, key := range keys {
+		result = append(result, s.Lookup(key))
+	}
+	return result
+}
+
+func (s *LayeredConfigSource) GetString(key string) string {
+	return s.Lookup(key).Value
+}
+
+// GetBool returns true for any value accepted as true by strconv.ParseBool
+func (s *LayeredConfigSource) GetBool(key string) bool {
+	b, _ := strconv.ParseBool(s.Lookup(key).Value)
+	return b
+}
+
+func (s *LayeredConfigSource) IsSet(key string) bool {
+	return s.Lookup(key).IsSet()
+}
+
+type mapConfigLayer struct {
+	origin string
+	values map[string]string
+}
+
+// NewMapConfigLayer creates a layer from literal values, e.g. defaults or explicit overrides; origin describes the layer
+// in ConfigValue reports
+func NewMapConfigLayer(origin string, values map[string]string) ConfigLayer {
+	return &mapConfigLayer{origin: origin, values: values}
+}
+
+func (l *mapConfigLayer) Lookup(key string) (string, string, bool) {
+	value, ok := l.values[key]
+	return value, l.origin, ok
+}
+
+type sourceConfigLayer struct {
+	origin string
+	source ConfigSource
+}
+
+// NewConfigSourceLayer uses an existing ConfigSource as a layer
+func NewConfigSourceLayer(origin string, source ConfigSource) ConfigLayer {
+	return &sourceConfigLayer{origin: origin, source: source}
+}
+
+func (l *sourceConfigLayer) Lookup(key string) (string, string, bool) {
+	if !l.source.IsSet(key) {
+		return "", "", false
+	}
+	return l.source.GetString(key), l.origin, true
+}
+
+type envConfigLayer struct {
+	varName func(key string) string
+}
+
+// NewEnvConfigLayer creates a layer from environment variables named by a prefix followed by the key in upper case
+// with dots and dashes replaced by underscores, e.g. with the prefix "FN_" oracle.compartment-id is read from
+// FN_ORACLE_COMPARTMENT_ID. Variables that are set to "" are ignored.
+func NewEnvConfigLayer(prefix string) ConfigLayer {
+	replacer := strings.NewReplacer("-", "_", ".", "_")
+	return &envConfigLayer{varName: func(key string) string {
+		return prefix + strings.ToUpper(replacer.Replace(key))
+	}}
+}
+
+// NewEnvVarsConfigLayer creates a layer that reads the given keys from the environment variables they map to, other
+// keys are never set. Variables that are set to "" are ignored.
+func NewEnvVarsConfigLayer(vars map[string]string) ConfigLayer {
+	return &envConfigLayer{varName: func(key string) string {
+		return vars[key]
+	}}
+}
+
+func (l *envConfigLayer) Lookup(key string) (string, string, bool) {
+	name := l.varName(key)
+	if name == "" {
+		return "", "", false
+	}
+	value := os.Getenv(name)
+	if value == "" {
+		return "", "", false
+	}
+	return value, "environment variable " + name, true
+}
+
+// NewFileConfigLayer creates a layer from a YAML or JSON file, files ending in .json are read as JSON and anything
+// else as YAML. Nested objects map to dotted keys, so that oracle.compartment-id can be given either literally or as
+// compartment-id inside an oracle object. The file is read once, when the layer is created.
+func NewFileConfigLayer(path string) (ConfigLayer, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]interface{}{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&values)
+	} else {
+		err = yaml.Unmarshal(data, &values)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	flat := map[string]string{}
+	flattenConfig("", values, flat)
+	return NewMapConfigLayer("file "+path, flat), nil
+}
+
+// flattenConfig converts nested config values into dotted string keys
+func flattenConfig(prefix string, values map[string]interface{}, into map[string]string) {
+	for k, v := range values {
+		key := prefix + k
+		switch v := v.(type) {
+		case map[string]interface{}:
+			flattenConfig(key+".", v, into)
+		case nil:
+			into[key] = ""
+		default:
+			into[key] = fmt.Sprint(v)
+		}
+	}
+}
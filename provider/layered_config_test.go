@@ -0,0 +1,89 @@
+package provider
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLayeredConfigSourcePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	writeFnConfig(t, dir, "config.yaml", `
+api-url: http://file.example.com
+oracle:
+  compartment-id: ocid1.compartment.oc1..file
+  disable-certs: true
+`)
+	writeFnConfig(t, dir, "config.json", `{"oracle": {"profile": "JSON", "retries": 12345678}}`)
+
+	yamlLayer, err := NewFileConfigLayer(filepath.Join(dir, "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonLayer, err := NewFileConfigLayer(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("FN_TEST_ORACLE_COMPARTMENT_ID", "ocid1.compartment.oc1..env")
+	t.Setenv("FN_TEST_TOKEN", "")
+
+	source := NewLayeredConfigSource(
+		NewMapConfigLayer("defaults", map[string]string{CfgFnAPIURL: "http://localhost:8080", CfgFnToken: "default"}),
+		yamlLayer,
+		jsonLayer,
+		NewEnvConfigLayer("FN_TEST_"),
+	).With(NewMapConfigLayer("overrides", map[string]string{"oracle.profile": "OVERRIDE"}))
+
+	for key, expected := range map[string]ConfigValue{
+		CfgFnAPIURL: {
+			Key: CfgFnAPIURL, Value: "http://file.example.com",
+			Origin: "file " + filepath.Join(dir, "config.yaml"), Overridden: []string{"defaults"},
+		},
+		CfgFnToken: {Key: CfgFnToken, Value: "default", Origin: "defaults"},
+		"oracle.compartment-id": {
+			Key: "oracle.compartment-id", Value: "ocid1.compartment.oc1..env",
+			Origin: "environment variable FN_TEST_ORACLE_COMPARTMENT_ID", Overridden: []string{"file " + filepath.Join(dir, "config.yaml")},
+		},
+		"oracle.profile": {
+			Key: "oracle.profile", Value: "OVERRIDE",
+			Origin: "overrides", Overridden: []string{"file " + filepath.Join(dir, "config.json")},
+		},
+		"oracle.retries": {Key: "oracle.retries", Value: "12345678", Origin: "file " + filepath.Join(dir, "config.json")},
+		"registry":       {Key: "registry"},
+	} {
+		if got := source.Lookup(key); !reflect.DeepEqual(got, expected) {
+			t.Errorf("unexpected value for %s:\n got %+v\nwant %+v", key, got, expected)
+		}
+	}
+
+	if !source.GetBool("oracle.disable-certs") {
+		t.Errorf("expected oracle.disable-certs to be true")
+	}
+	if source.IsSet("registry") {
+		t.Errorf("registry should not be set")
+	}
+
+	explained := source.Explain(CfgFnToken, "registry")
+	if len(explained) != 2 || explained[0].String() != "token is set by defaults" || explained[1].String() != "registry is not set" {
+		t.Errorf("unexpected explanation %v", explained)
+	}
+}
+
+func TestConfigSourceLayer(t *testing.T) {
+	source := NewLayeredConfigSource(
+		NewConfigSourceLayer("context", NewConfigSourceFromMap(map[string]string{CfgFnToken: ""})),
+		NewEnvVarsConfigLayer(map[string]string{CfgFnAPIURL: "TEST_FN_API_URL"}),
+	)
+	t.Setenv("TEST_FN_API_URL", "http://env.example.com")
+
+	if v := source.Lookup(CfgFnToken); !v.IsSet() || v.Value != "" || v.Origin != "context" {
+		t.Errorf("expected an empty token from the context, got %+v", v)
+	}
+	if got := source.GetString(CfgFnAPIURL); got != "http://env.example.com" {
+		t.Errorf("expected the api-url from the environment, got %q", got)
+	}
+	if source.IsSet(CfgFnProvider) {
+		t.Errorf("keys without a variable should not be read from the environment")
+	}
+}
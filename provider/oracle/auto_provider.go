@@ -0,0 +1,238 @@
+package oracle
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fnproject/fn_go/provider"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+)
+
+// Auth modes that the oracle-auto provider chooses between, named after the providers that implement them
+const (
+	AuthModeResourcePrincipal = "oracle-rp"
+	AuthModeOKEWorkload       = "oracle-oke"
+	AuthModeCloudShell        = "oracle-cs"
+	AuthModeUserKey           = "oracle"
+	AuthModeInstancePrincipal = "oracle-ip"
+
+	// OCI_CLI_CLOUD_SHELL_ENV_VAR is set to "True" inside OCI CloudShell
+	OCI_CLI_CLOUD_SHELL_ENV_VAR = "OCI_CLI_CLOUD_SHELL"
+
+	instanceMetadataURL     = "http://169.254.169.254/opc/v2/instance/"
+	instanceMetadataTimeout = 2 * time.Second
+)
+
+// AuthDetection records the auth mode that the oracle-auto provider chose and why
+type AuthDetection struct {
+	// Mode is the name of the provider that was used, e.g. "oracle-cs"
+	Mode string
+	// Reason explains why Mode was chosen
+	Reason string
+	// Skipped explains why each of the modes that are checked before Mode was not used
+	Skipped []string
+}
+
+func (d *AuthDetection) String() string {
+	s := fmt.Sprintf("using %s: %s", d.Mode, d.Reason)
+	if len(d.Skipped) > 0 {
+		s += " (skipped " + strings.Join(d.Skipped, "; ") + ")"
+	}
+	return s
+}
+
+// AuthProbes are the checks that auth mode detection makes against its environment, they can be replaced in tests
+type AuthProbes struct {
+	Getenv   func(key string) string
+	ReadFile func(path string) ([]byte, error)
+	HomeDir  func() (string, error)
+	// MetadataURL is the instance metadata endpoint that is probed to detect an OCI instance
+	MetadataURL string
+	HTTPClient  *http.Client
+}
+
+// DefaultAuthProbes checks the real environment, files and instance metadata service
+func DefaultAuthProbes() AuthProbes {
+	return AuthProbes{
+		Getenv:      os.Getenv,
+		ReadFile:    ioutil.ReadFile,
+		HomeDir:     homedir.Dir,
+		MetadataURL: instanceMetadataURL,
+		HTTPClient:  &http.Client{Timeout: instanceMetadataTimeout},
+	}
+}
+
+// authMode is a candidate for oracle-auto, detect returns why the mode applies or an error saying why it doesn't
+type authMode struct {
+	name        string
+	detect      func(config provider.ConfigSource, probes AuthProbes) (string, error)
+	newProvider provider.ProviderFunc
+}
+
+// authModes are checked in order, the first mode that is detected and whose provider can be created is used
+var authModes = []authMode{
+	{name: AuthModeResourcePrincipal, detect: detectResourcePrincipal, newProvider: NewRPProvider},
+	{name: AuthModeOKEWorkload, detect: detectOKEWorkload, newProvider: NewOKEProvider},
+	{name: AuthModeCloudShell, detect: detectCloudShell, newProvider: NewCSProvider},
+	{name: AuthModeUserKey, detect: detectUserKey, newProvider: NewFromConfig},
+	{name: AuthModeInstancePrincipal, detect: detectInstancePrincipal, newProvider: NewIPProvider},
+}
+
+// NewAutoProvider creates an "oracle-auto" provider, which picks the auth mode that suits the environment. In order it
+// checks for the resource principal environment of an OCI function, an OKE workload identity (a Kubernetes pod with the
+// resource principal region set and a readable service account token), a CloudShell delegation token, API key settings
+// in the environment, Fn config or OCI config file, and finally a reachable instance metadata service. Resource
+// principal and OKE come first because their environments are set up explicitly for the workload, while an OCI config
+// file or the instance metadata service may also be present on the host. If the provider for a detected mode can't be
+// created the next mode is tried. The returned provider's AuthDetection field records which mode was chosen and why.
+func NewAutoProvider(configSource provider.ConfigSource, passphraseSource provider.PassPhraseSource) (provider.Provider, error) {
+	return newAutoProvider(configSource, passphraseSource, DefaultAuthProbes(), authModes)
+}
+
+// DetectAuthMode returns the first auth mode that applies to the environment, without creating a provider
+func DetectAuthMode(configSource provider.ConfigSource, probes AuthProbes) (*AuthDetection, error) {
+	detection := &AuthDetection{}
+	for _, mode := range authModes {
+		reason, err := mode.detect(configSource, probes)
+		if err != nil {
+			detection.Skipped = append(detection.Skipped, fmt.Sprintf("%s: %s", mode.name, err))
+			continue
+		}
+		detection.Mode, detection.Reason = mode.name, reason
+		return detection, nil
+	}
+	return nil, noAuthModeError(detection)
+}
+
+func newAutoProvider(configSource provider.ConfigSource, passphraseSource provider.PassPhraseSource, probes AuthProbes, modes []authMode) (provider.Provider, error) {
+	detection := &AuthDetection{}
+	for _, mode := range modes {
+		reason, err := mode.detect(configSource, probes)
+		if err != nil {
+			detection.Skipped = append(detection.Skipped, fmt.Sprintf("%s: %s", mode.name, err))
+			continue
+		}
+
+		p, err := mode.newProvider(configSource, passphraseSource)
+		if err != nil {
+			detection.Skipped = append(detection.Skipped, fmt.Sprintf("%s: %s, but the provider failed: %s", mode.name, reason, err))
+			continue
+		}
+		detection.Mode, detection.Reason = mode.name, reason
+		if op, ok := p.(*OracleProvider); ok {
+			op.AuthDetection = detection
+		}
+		return p, nil
+	}
+	return nil, noAuthModeError(detection)
+}
+
+func noAuthModeError(detection *AuthDetection) error {
+	return fmt.Errorf("unable to detect an OCI auth mode: %s", strings.Join(detection.Skipped, "; "))
+}
+
+func detectResourcePrincipal(_ provider.ConfigSource, probes AuthProbes) (string, error) {
+	if version := probes.Getenv(auth.ResourcePrincipalVersionEnvVar); version != "" {
+		return fmt.Sprintf("%s is set to %s", auth.ResourcePrincipalVersionEnvVar, version), nil
+	}
+	return "", fmt.Errorf("%s is not set", auth.ResourcePrincipalVersionEnvVar)
+}
+
+func detectOKEWorkload(config provider.ConfigSource, probes AuthProbes) (string, error) {
+	if probes.Getenv(auth.KubernetesServiceHostEnvVar) == "" && config.GetString(CfgOKEProxymuxURL) == "" {
+		return "", fmt.Errorf("%s is not set", auth.KubernetesServiceHostEnvVar)
+	}
+	// every pod has a service account token, the region is only set for pods that use OKE workload identity
+	if probes.Getenv(auth.ResourcePrincipalRegionEnvVar) == "" {
+		return "", fmt.Errorf("running in Kubernetes but %s is not set", auth.ResourcePrincipalRegionEnvVar)
+	}
+	tokenFile := config.GetString(CfgOKETokenFile)
+	if tokenFile == "" {
+		tokenFile = auth.KubernetesServiceAccountTokenPath
+	}
+	if _, err := probes.ReadFile(tokenFile); err != nil {
+		return "", fmt.Errorf("running in Kubernetes but the service account token can't be read: %s", err)
+	}
+	return fmt.Sprintf("running in Kubernetes with %s set and service account token %s", auth.ResourcePrincipalRegionEnvVar, tokenFile), nil
+}
+
+func detectCloudShell(_ provider.ConfigSource, probes AuthProbes) (string, error) {
+	if tokenFile := probes.Getenv(OCI_CLI_DELEGATION_TOKEN_FILE_ENV_VAR); tokenFile != "" {
+		if _, err := probes.ReadFile(tokenFile); err != nil {
+			return "", fmt.Errorf("%s is set but the delegation token can't be read: %s", OCI_CLI_DELEGATION_TOKEN_FILE_ENV_VAR, err)
+		}
+		return fmt.Sprintf("delegation token file %s is set by %s", tokenFile, OCI_CLI_DELEGATION_TOKEN_FILE_ENV_VAR), nil
+	}
+	if strings.EqualFold(probes.Getenv(OCI_CLI_CLOUD_SHELL_ENV_VAR), "true") {
+		return "", fmt.Errorf("%s is set but %s is not", OCI_CLI_CLOUD_SHELL_ENV_VAR, OCI_CLI_DELEGATION_TOKEN_FILE_ENV_VAR)
+	}
+	return "", fmt.Errorf("%s is not set", OCI_CLI_DELEGATION_TOKEN_FILE_ENV_VAR)
+}
+
+func detectUserKey(config provider.ConfigSource, probes AuthProbes) (string, error) {
+	// API key settings given entirely through the environment or Fn config don't need a config file, the environment
+	// takes precedence over the Fn config as it does in the oracle provider
+	setting := func(key string) string {
+		if value := probes.Getenv(oracleEnvVars[key]); value != "" {
+			return value
+		}
+		return config.GetString(key)
+	}
+	keys := []string{CfgTenancyID, CfgUserID, CfgFingerprint, CfgKeyFile}
+	complete := true
+	for _, key := range keys {
+		if setting(key) == "" {
+			complete = false
+		}
+	}
+	if complete {
+		return "API key settings " + strings.Join(keys, ", ") + " are set in the environment or Fn config", nil
+	}
+
+	profile := setting(CfgProfile)
+	if profile == "" {
+		profile = "DEFAULT"
+	}
+
+	path := probes.Getenv(OCI_CLI_CONFIG_FILE_ENV_VAR)
+	if path == "" {
+		home, err := probes.HomeDir()
+		if err != nil {
+			return "", fmt.Errorf("error getting home directory %s", err)
+		}
+		path = filepath.Join(home, ".oci", "config")
+	}
+	data, err := probes.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("no OCI config file at %s", path)
+	}
+	if _, ok := ParseOCIConfigFile(path, data).Profile(profile); !ok {
+		return "", fmt.Errorf("OCI config file %s has no profile %s", path, profile)
+	}
+	return fmt.Sprintf("profile %s found in OCI config file %s", profile, path), nil
+}
+
+func detectInstancePrincipal(_ provider.ConfigSource, probes AuthProbes) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, probes.MetadataURL, nil)
+	if err != nil {
+		return "", err
+	}
+	// IMDS v2 requires authorisation header for any request
+	req.Header.Add("Authorization", "Bearer Oracle")
+
+	resp, err := probes.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("instance metadata service at %s is not reachable", probes.MetadataURL)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("instance metadata service at %s returned %s", probes.MetadataURL, resp.Status)
+	}
+	return fmt.Sprintf("instance metadata service at %s is reachable", probes.MetadataURL), nil
+}
@@ -0,0 +1,184 @@
+package oracle
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fnproject/fn_go/provider"
+)
+
+// testAuthProbes returns probes that see only the given environment, files under home and a fake metadata service
+func testAuthProbes(t *testing.T, env map[string]string, metadataStatus int) (AuthProbes, string) {
+	home := t.TempDir()
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer Oracle" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(metadataStatus)
+	}))
+	t.Cleanup(metadata.Close)
+
+	return AuthProbes{
+		Getenv:      func(key string) string { return env[key] },
+		ReadFile:    ioutil.ReadFile,
+		HomeDir:     func() (string, error) { return home, nil },
+		MetadataURL: metadata.URL + "/opc/v2/instance/",
+		HTTPClient:  metadata.Client(),
+	}, home
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDetectAuthMode(t *testing.T) {
+	noConfig := provider.NewConfigSourceFromMap(map[string]string{})
+
+	t.Run("resource principal", func(t *testing.T) {
+		env := map[string]string{"OCI_RESOURCE_PRINCIPAL_VERSION": "2.2", OCI_CLI_DELEGATION_TOKEN_FILE_ENV_VAR: "/etc/oci/delegation_token"}
+		probes, _ := testAuthProbes(t, env, http.StatusOK)
+
+		detection, err := DetectAuthMode(noConfig, probes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if detection.Mode != AuthModeResourcePrincipal || len(detection.Skipped) != 0 {
+			t.Errorf("expected the resource principal to take precedence, got %s", detection)
+		}
+	})
+
+	t.Run("oke workload identity", func(t *testing.T) {
+		env := map[string]string{"KUBERNETES_SERVICE_HOST": "10.96.0.1"}
+		probes, home := testAuthProbes(t, env, http.StatusNotFound)
+		tokenFile := filepath.Join(home, "token")
+		writeTestFile(t, tokenFile, "sa-token")
+		config := provider.NewConfigSourceFromMap(map[string]string{CfgOKETokenFile: tokenFile})
+
+		_, err := DetectAuthMode(config, probes)
+		if err == nil || !strings.Contains(err.Error(), "oracle-oke: running in Kubernetes but OCI_RESOURCE_PRINCIPAL_REGION is not set") {
+			t.Errorf("expected pods without workload identity to be skipped, got %v", err)
+		}
+
+		env["OCI_RESOURCE_PRINCIPAL_REGION"] = "us-ashburn-1"
+		detection, err := DetectAuthMode(config, probes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if detection.Mode != AuthModeOKEWorkload || !strings.Contains(detection.Reason, tokenFile) {
+			t.Errorf("expected OKE workload identity to be detected, got %s", detection)
+		}
+	})
+
+	t.Run("cloud shell", func(t *testing.T) {
+		env := map[string]string{}
+		probes, home := testAuthProbes(t, env, http.StatusOK)
+		tokenFile := filepath.Join(home, "delegation_token")
+		writeTestFile(t, tokenFile, "token")
+		env[OCI_CLI_DELEGATION_TOKEN_FILE_ENV_VAR] = tokenFile
+
+		detection, err := DetectAuthMode(noConfig, probes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if detection.Mode != AuthModeCloudShell || !strings.Contains(detection.Reason, tokenFile) {
+			t.Errorf("expected CloudShell to be detected from the token file, got %s", detection)
+		}
+	})
+
+	t.Run("unreadable delegation token falls back to the OCI config file", func(t *testing.T) {
+		env := map[string]string{OCI_CLI_PROFILE_ENV_VAR: "WORK"}
+		probes, home := testAuthProbes(t, env, http.StatusOK)
+		env[OCI_CLI_DELEGATION_TOKEN_FILE_ENV_VAR] = filepath.Join(home, "missing")
+		configFile := filepath.Join(home, "oci_config")
+		writeTestFile(t, configFile, "[DEFAULT]\nregion=us-ashburn-1\n\n[WORK] ; the profile for fn\nregion=eu-frankfurt-1\n")
+		env[OCI_CLI_CONFIG_FILE_ENV_VAR] = configFile
+
+		detection, err := DetectAuthMode(noConfig, probes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if detection.Mode != AuthModeUserKey || !strings.Contains(detection.Reason, "profile WORK") {
+			t.Errorf("expected the WORK profile to be detected, got %s", detection)
+		}
+		if len(detection.Skipped) != 3 || !strings.Contains(detection.Skipped[2], "delegation token can't be read") {
+			t.Errorf("expected CloudShell to be skipped, got %v", detection.Skipped)
+		}
+	})
+
+	t.Run("api key settings without a config file", func(t *testing.T) {
+		env := map[string]string{OCI_CLI_KEY_FILE_ENV_VAR: "/keys/key.pem"}
+		probes, _ := testAuthProbes(t, env, http.StatusOK)
+		config := provider.NewConfigSourceFromMap(map[string]string{
+			CfgTenancyID:   "ocid1.tenancy.oc1..test",
+			CfgUserID:      "ocid1.user.oc1..test",
+			CfgFingerprint: "aa:bb",
+		})
+
+		detection, err := DetectAuthMode(config, probes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if detection.Mode != AuthModeUserKey {
+			t.Errorf("expected API key settings to be detected, got %s", detection)
+		}
+	})
+
+	t.Run("instance principal", func(t *testing.T) {
+		probes, home := testAuthProbes(t, map[string]string{}, http.StatusOK)
+		writeTestFile(t, filepath.Join(home, "config"), "[DEFAULT]\n")
+
+		detection, err := DetectAuthMode(noConfig, probes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if detection.Mode != AuthModeInstancePrincipal {
+			t.Errorf("expected instance principals to be detected, got %s", detection)
+		}
+		if len(detection.Skipped) != 4 || !strings.Contains(detection.Skipped[3], "no OCI config file") {
+			t.Errorf("expected the missing ~/.oci/config to be reported, got %v", detection.Skipped)
+		}
+	})
+
+	t.Run("nothing detected", func(t *testing.T) {
+		probes, _ := testAuthProbes(t, map[string]string{}, http.StatusNotFound)
+
+		_, err := DetectAuthMode(noConfig, probes)
+		if err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("expected an error explaining each mode, got %v", err)
+		}
+	})
+}
+
+func TestAutoProviderFallsBack(t *testing.T) {
+	probes, _ := testAuthProbes(t, map[string]string{}, http.StatusOK)
+	detected := func(provider.ConfigSource, AuthProbes) (string, error) { return "detected", nil }
+	built := &OracleProvider{}
+
+	p, err := newAutoProvider(provider.NewConfigSourceFromMap(nil), &provider.NopPassPhraseSource{}, probes, []authMode{
+		{name: "missing", detect: func(provider.ConfigSource, AuthProbes) (string, error) { return "", errors.New("not here") }},
+		{name: "broken", detect: detected, newProvider: func(provider.ConfigSource, provider.PassPhraseSource) (provider.Provider, error) {
+			return nil, errors.New("bad key")
+		}},
+		{name: "working", detect: detected, newProvider: func(provider.ConfigSource, provider.PassPhraseSource) (provider.Provider, error) {
+			return built, nil
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != built || built.AuthDetection == nil || built.AuthDetection.Mode != "working" {
+		t.Fatalf("expected the working mode to be used, got %+v", built.AuthDetection)
+	}
+	if skipped := built.AuthDetection.Skipped; len(skipped) != 2 || skipped[0] != "missing: not here" || !strings.Contains(skipped[1], "bad key") {
+		t.Errorf("unexpected skipped modes %v", skipped)
+	}
+}
@@ -31,7 +31,7 @@ func NewCSProvider(configSource provider.ConfigSource, passphraseSource provider
 	var err error
 
 	// Derive oracle.profile from context or environment
-	oraProfile := getEnv(OCI_CLI_PROFILE_ENV_VAR, configSource.GetString(CfgProfile))
+	oraProfile := oracleSettings(configSource).GetString(CfgProfile)
 
 	// If the oracle.profile in env or context isn't empty then derive config from profile in OCI config
 	if oraProfile != "" {
@@ -114,6 +114,11 @@ func NewCSProvider(configSource provider.ConfigSource, passphraseSource provider
 
 	ociClient.UserAgent = fmt.Sprintf("%s %s", userAgentPrefixCs, ociClient.UserAgent)
 
+	retryPolicy, err := configureRetries(configSource, &ociClient)
+	if err != nil {
+		return nil, err
+	}
+
 	disableCerts := configSource.GetBool(CfgDisableCerts)
 	if disableCerts {
 		c := ociClient.HTTPClient.(*http.Client)
@@ -130,6 +135,7 @@ func NewCSProvider(configSource provider.ConfigSource, passphraseSource provider
 		CompartmentID:         compartmentID,
 		ImageCompartmentID:    configSource.GetString(CfgImageCompartmentID),
 		ConfigurationProvider: configProvider,
+		RetryPolicy:           retryPolicy,
 		ociClient:             ociClient,
 	}, nil
 }
@@ -0,0 +1,142 @@
+package oracle
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/pager"
+	"github.com/fnproject/fn_go/provider"
+	"github.com/fnproject/fn_go/provider/oracle/shim"
+	"github.com/fnproject/fn_go/provider/oracle/shim/client"
+)
+
+// AnnotationCompartmentID is the app and function annotation that holds the OCID of their compartment
+const AnnotationCompartmentID = shim.AnnotationCompartmentId
+
+// CompartmentID returns the compartment recorded in a resource's annotations, or "" if there is none
+func CompartmentID(annotations map[string]interface{}) string {
+	compartmentID, _ := annotations[AnnotationCompartmentID].(string)
+	return compartmentID
+}
+
+// ListAppsInCompartments lists the applications in each of the given compartments, in order, using a single client.
+// Every application carries the compartment annotation of the compartment it was found in.
+func ListAppsInCompartments(ctx context.Context, client *clientv2.Fn, compartmentIDs []string) ([]*modelsv2.App, error) {
+	var result []*modelsv2.App
+	seen := map[string]bool{}
+	for _, compartmentID := range compartmentIDs {
+		if compartmentID == "" || seen[compartmentID] {
+			continue
+		}
+		seen[compartmentID] = true
+
+		compartmentCtx := provider.WithCompartmentID(ctx, compartmentID)
+		compartmentApps, err := pager.Apps(compartmentCtx, client, apps.NewListAppsParams(), pager.Options{}).All()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list apps in compartment %s: %w", compartmentID, err)
+		}
+		for _, app := range compartmentApps {
+			if app.Annotations == nil {
+				app.Annotations = map[string]interface{}{}
+			}
+			if CompartmentID(app.Annotations) == "" {
+				app.Annotations[AnnotationCompartmentID] = compartmentID
+			}
+			result = append(result, app)
+		}
+	}
+	return result, nil
+}
+
+// MoveApp moves an application and its functions into another compartment and waits for the move to complete. Once
+// the application is ACTIVE in the new compartment its config and functions are checked against their state before
+// the move, and an error is returned if anything other than the compartment changed. Moving an application into the
+// compartment it is already in does nothing.
+func (op *OracleProvider) MoveApp(ctx context.Context, appID, compartmentID string, opts *WaitOptions) (*modelsv2.App, error) {
+	return moveApp(ctx, op.APIClientv2(), op.ociClient, appID, compartmentID, opts)
+}
+
+func moveApp(ctx context.Context, fnClient *clientv2.Fn, ociClient client.FunctionsManagementClient, appID, compartmentID string, opts *WaitOptions) (*modelsv2.App, error) {
+	if compartmentID == "" {
+		return nil, fmt.Errorf("no target compartment specified for app %s", appID)
+	}
+
+	// the app has to be ACTIVE before OCI accepts the move
+	before, err := WaitForAppActive(ctx, fnClient, appID, opts)
+	if err != nil {
+		return nil, err
+	}
+	if CompartmentID(before.Annotations) == compartmentID {
+		return before, nil
+	}
+	fnsBefore, err := pager.Fns(ctx, fnClient, fns.NewListFnsParams().WithAppID(&appID), pager.Options{}).All()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list functions of app %s: %w", before.Name, err)
+	}
+
+	if err := shim.ChangeAppCompartment(ctx, ociClient, appID, compartmentID); err != nil {
+		return nil, err
+	}
+
+	var after *modelsv2.App
+	err = poll(ctx, opts, func() (bool, error) {
+		res, err := fnClient.Apps.GetApp(apps.NewGetAppParams().WithContext(ctx).WithAppID(appID))
+		if err != nil {
+			return false, err
+		}
+		after = res.Payload
+		active, err := checkActive("app", appID, AppLifecycleState(after))
+		return active && CompartmentID(after.Annotations) == compartmentID, err
+	})
+	if err != nil {
+		return nil, waitError(ctx, err, "app", appID, "moved to compartment "+compartmentID, AppLifecycleState(after))
+	}
+
+	if !sameConfig(before.Config, after.Config) {
+		return after, fmt.Errorf("config of app %s changed while moving it to compartment %s", before.Name, compartmentID)
+	}
+	fnsAfter, err := pager.Fns(ctx, fnClient, fns.NewListFnsParams().WithAppID(&appID), pager.Options{}).All()
+	if err != nil {
+		return after, fmt.Errorf("failed to list functions of app %s: %w", before.Name, err)
+	}
+	if err := verifyMovedFns(fnsBefore, fnsAfter, compartmentID); err != nil {
+		return after, fmt.Errorf("app %s was moved to compartment %s but %s", before.Name, compartmentID, err)
+	}
+	return after, nil
+}
+
+// verifyMovedFns checks that the functions of a moved app are the same as before the move, apart from their compartment
+func verifyMovedFns(before, after []*modelsv2.Fn, compartmentID string) error {
+	afterByID := map[string]*modelsv2.Fn{}
+	for _, fn := range after {
+		afterByID[fn.ID] = fn
+	}
+	if len(after) != len(before) {
+		return fmt.Errorf("it had %d functions before the move and %d after", len(before), len(after))
+	}
+	for _, b := range before {
+		a, ok := afterByID[b.ID]
+		if !ok {
+			return fmt.Errorf("function %s is missing after the move", b.Name)
+		}
+		if a.Name != b.Name || a.Image != b.Image || a.Memory != b.Memory || !sameConfig(a.Config, b.Config) {
+			return fmt.Errorf("function %s changed during the move", b.Name)
+		}
+		if moved := CompartmentID(a.Annotations); moved != "" && moved != compartmentID {
+			return fmt.Errorf("function %s is still in compartment %s", b.Name, moved)
+		}
+	}
+	return nil
+}
+
+func sameConfig(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,121 @@
+package oracle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/fnerrors"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider"
+	"github.com/fnproject/fn_go/provider/oracle/ocitest"
+	"github.com/fnproject/fn_go/provider/oracle/shim"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoveApp(t *testing.T) {
+	c := ocitest.NewClient()
+	c.TransitionDelay = 20 * time.Millisecond
+	client := &clientv2.Fn{
+		Apps:     shim.NewAppsShim(c, "ocid1.compartment.oc1..source"),
+		Fns:      shim.NewFnsShim(c),
+		Triggers: shim.NewTriggersShimWithClient(c),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	opts := &WaitOptions{InitialInterval: 5 * time.Millisecond, MaxInterval: 20 * time.Millisecond}
+
+	app, err := client.Apps.CreateApp(apps.NewCreateAppParams().WithBody(&modelsv2.App{
+		Name:        "moving",
+		Config:      map[string]string{"KEY": "value"},
+		Annotations: map[string]interface{}{"oracle.com/oci/subnetIds": []interface{}{"subnet"}},
+	}))
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = WaitForAppActive(ctx, client, app.Payload.ID, opts)
+	assert.NoError(t, err)
+	fn, err := client.Fns.CreateFn(fns.NewCreateFnParams().WithBody(&modelsv2.Fn{
+		AppID:  app.Payload.ID,
+		Name:   "fn",
+		Image:  "fnproject/hello",
+		Config: map[string]string{"FN_KEY": "fn value"},
+	}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	moved, err := moveApp(ctx, client, c, app.Payload.ID, "ocid1.compartment.oc1..target", opts)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "ocid1.compartment.oc1..target", CompartmentID(moved.Annotations))
+	assert.Equal(t, LifecycleStateActive, AppLifecycleState(moved))
+	assert.Equal(t, map[string]string{"KEY": "value"}, moved.Config)
+
+	got, err := client.Fns.GetFn(fns.NewGetFnParams().WithFnID(fn.Payload.ID))
+	if assert.NoError(t, err) {
+		assert.Equal(t, "ocid1.compartment.oc1..target", CompartmentID(got.Payload.Annotations))
+		assert.Equal(t, map[string]string{"FN_KEY": "fn value"}, got.Payload.Config)
+	}
+
+	again, err := moveApp(ctx, client, c, app.Payload.ID, "ocid1.compartment.oc1..target", opts)
+	if assert.NoError(t, err, "moving an app into its own compartment is a no-op") {
+		assert.Equal(t, moved.ID, again.ID)
+	}
+
+	other, err := shim.NewAppsShim(c, "ocid1.compartment.oc1..source").CreateApp(apps.NewCreateAppParams().WithBody(&modelsv2.App{
+		Name:        "moving",
+		Annotations: map[string]interface{}{"oracle.com/oci/subnetIds": []interface{}{"subnet"}},
+	}))
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = moveApp(ctx, client, c, other.Payload.ID, "ocid1.compartment.oc1..target", opts)
+	assert.True(t, fnerrors.IsConflict(err), "an app can't be moved next to an app with the same name")
+}
+
+func TestVerifyMovedFns(t *testing.T) {
+	before := []*modelsv2.Fn{{ID: "fn1", Name: "fn1", Image: "image:1", Config: map[string]string{"A": "B"}}}
+
+	assert.NoError(t, verifyMovedFns(before, []*modelsv2.Fn{{ID: "fn1", Name: "fn1", Image: "image:1", Config: map[string]string{"A": "B"},
+		Annotations: map[string]interface{}{AnnotationCompartmentID: "target"}}}, "target"))
+	assert.Error(t, verifyMovedFns(before, nil, "target"))
+	assert.Error(t, verifyMovedFns(before, []*modelsv2.Fn{{ID: "fn1", Name: "fn1", Image: "image:2", Config: map[string]string{"A": "B"}}}, "target"))
+	assert.Error(t, verifyMovedFns(before, []*modelsv2.Fn{{ID: "fn1", Name: "fn1", Image: "image:1", Config: map[string]string{"A": "B"},
+		Annotations: map[string]interface{}{AnnotationCompartmentID: "source"}}}, "target"))
+}
+
+func TestCompartmentOverrideAndMultiCompartmentListing(t *testing.T) {
+	c := ocitest.NewClient()
+	client := &clientv2.Fn{
+		Apps:     shim.NewAppsShim(c, "ocid1.compartment.oc1..default"),
+		Fns:      shim.NewFnsShim(c),
+		Triggers: shim.NewTriggersShimWithClient(c),
+	}
+	ctx := context.Background()
+	subnets := map[string]interface{}{"oracle.com/oci/subnetIds": []interface{}{"subnet"}}
+
+	for _, compartmentID := range []string{"", "ocid1.compartment.oc1..a", "ocid1.compartment.oc1..b"} {
+		createCtx := ctx
+		if compartmentID != "" {
+			createCtx = provider.WithCompartmentID(ctx, compartmentID)
+		}
+		_, err := client.Apps.CreateApp(apps.NewCreateAppParamsWithContext(createCtx).WithBody(&modelsv2.App{Name: "app", Annotations: subnets}))
+		assert.NoError(t, err)
+	}
+
+	defaultApps, err := client.Apps.ListApps(apps.NewListAppsParams())
+	if assert.NoError(t, err) && assert.Len(t, defaultApps.Payload.Items, 1) {
+		assert.Equal(t, "ocid1.compartment.oc1..default", CompartmentID(defaultApps.Payload.Items[0].Annotations))
+	}
+
+	all, err := ListAppsInCompartments(ctx, client, []string{"ocid1.compartment.oc1..b", "ocid1.compartment.oc1..a", "ocid1.compartment.oc1..b"})
+	if assert.NoError(t, err) && assert.Len(t, all, 2) {
+		assert.Equal(t, "ocid1.compartment.oc1..b", CompartmentID(all[0].Annotations))
+		assert.Equal(t, "ocid1.compartment.oc1..a", CompartmentID(all[1].Annotations))
+	}
+}
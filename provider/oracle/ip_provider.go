@@ -53,6 +53,11 @@ func NewIPProvider(configSource provider.ConfigSource, passphraseSource provider
 
 	ociClient.UserAgent = fmt.Sprintf("%s %s", userAgentPrefixIp, ociClient.UserAgent)
 
+	retryPolicy, err := configureRetries(configSource, &ociClient)
+	if err != nil {
+		return nil, err
+	}
+
 	disableCerts := configSource.GetBool(CfgDisableCerts)
 	if disableCerts {
 		c := ociClient.HTTPClient.(*http.Client)
@@ -85,6 +90,7 @@ func NewIPProvider(configSource provider.ConfigSource, passphraseSource provider
 		CompartmentID:         compartmentID,
 		ImageCompartmentID:    configSource.GetString(CfgImageCompartmentID),
 		ConfigurationProvider: configProvider,
+		RetryPolicy:           retryPolicy,
 		ociClient:             ociClient,
 	}, nil
 }
@@ -0,0 +1,191 @@
+package oracle
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// PrivateKeyFormat is the encoding of a PEM private key
+type PrivateKeyFormat string
+
+// Private key formats that the oracle provider can use
+const (
+	// KeyFormatPKCS1 is an unencrypted "RSA PRIVATE KEY", as written by `openssl genrsa` before OpenSSL 3
+	KeyFormatPKCS1 PrivateKeyFormat = "PKCS#1"
+	// KeyFormatEncryptedPKCS1 is an "RSA PRIVATE KEY" with legacy PEM encryption (a Proc-Type: 4,ENCRYPTED header)
+	KeyFormatEncryptedPKCS1 PrivateKeyFormat = "encrypted PKCS#1"
+	// KeyFormatPKCS8 is an unencrypted "PRIVATE KEY"
+	KeyFormatPKCS8 PrivateKeyFormat = "PKCS#8"
+	// KeyFormatEncryptedPKCS8 is an "ENCRYPTED PRIVATE KEY", as written by `openssl genrsa -aes256` since OpenSSL 3.
+	// The OCI SDK can't decrypt these, so they are detected in order to explain how to convert them.
+	KeyFormatEncryptedPKCS8 PrivateKeyFormat = "encrypted PKCS#8"
+
+	defaultAPIKeyBits = 2048
+)
+
+// Encrypted reports whether a key in this format needs a passphrase
+func (f PrivateKeyFormat) Encrypted() bool {
+	return f == KeyFormatEncryptedPKCS1 || f == KeyFormatEncryptedPKCS8
+}
+
+// DetectPrivateKeyFormat returns the format of a PEM encoded RSA private key. Unencrypted keys are parsed to check that
+// they are intact, and the error for anything that isn't an RSA private key says what was found instead.
+func DetectPrivateKeyFormat(pemData []byte) (PrivateKeyFormat, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		if _, err := x509.ParsePKCS1PrivateKey(pemData); err == nil {
+			return "", fmt.Errorf("the key is DER encoded, OCI API keys must be PEM encoded: convert it with `openssl rsa -inform DER -outform PEM`")
+		}
+		return "", fmt.Errorf("no PEM data found, OCI API keys must be PEM encoded RSA private keys")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		if x509.IsEncryptedPEMBlock(block) {
+			return KeyFormatEncryptedPKCS1, nil
+		}
+		if _, err := x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return "", fmt.Errorf("invalid PKCS#1 RSA private key: %s", err)
+		}
+		return KeyFormatPKCS1, nil
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return "", fmt.Errorf("invalid PKCS#8 private key: %s", err)
+		}
+		if _, ok := key.(*rsa.PrivateKey); !ok {
+			return "", fmt.Errorf("the PKCS#8 key is a %T, OCI API keys must be RSA keys", key)
+		}
+		return KeyFormatPKCS8, nil
+	case "ENCRYPTED PRIVATE KEY":
+		return KeyFormatEncryptedPKCS8, nil
+	case "PUBLIC KEY", "RSA PUBLIC KEY":
+		return "", fmt.Errorf("this is a public key, use the private key that it was generated with")
+	case "OPENSSH PRIVATE KEY":
+		return "", fmt.Errorf("OpenSSH private keys are not supported, convert it to PEM with `ssh-keygen -p -m PEM -f <key>`")
+	case "EC PRIVATE KEY", "DSA PRIVATE KEY":
+		return "", fmt.Errorf("the key is a %s, OCI API keys must be RSA keys", block.Type)
+	default:
+		return "", fmt.Errorf("unexpected PEM block %q, OCI API keys must be RSA private keys", block.Type)
+	}
+}
+
+// readPrivateKey reads a PEM private key file along with its format, rejecting keys that the OCI SDK can't use
+func readPrivateKey(path string) ([]byte, PrivateKeyFormat, error) {
+	expanded, err := homedir.Expand(path)
+	if err != nil {
+		return nil, "", err
+	}
+	keyBytes, err := ioutil.ReadFile(expanded)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to read private key from file due to error: %s", err)
+	}
+	format, err := DetectPrivateKeyFormat(keyBytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to use private key: %s", err)
+	}
+	if format == KeyFormatEncryptedPKCS8 {
+		return nil, "", fmt.Errorf("unable to use private key: encrypted PKCS#8 keys are not supported by the OCI SDK, "+
+			"convert it to an encrypted PKCS#1 key with `openssl rsa -aes256 -traditional -in %s -out <new key file>`", path)
+	}
+	return keyBytes, format, nil
+}
+
+// APIKey is an OCI API signing key pair
+type APIKey struct {
+	// PrivateKeyPEM is the private key, in PKCS#1 format and encrypted if a passphrase was given
+	PrivateKeyPEM []byte
+	// PublicKeyPEM is the public key that is uploaded to the user's API keys in OCI
+	PublicKeyPEM []byte
+	// Fingerprint is the fingerprint that OCI shows for the public key
+	Fingerprint string
+}
+
+// GenerateAPIKey generates an RSA key pair for signing OCI API requests, bits defaults to 2048. If passphrase is not
+// empty then the private key is encrypted with it (using AES-256 PEM encryption, which the OCI SDKs and CLI can read).
+func GenerateAPIKey(bits int, passphrase string) (*APIKey, error) {
+	if bits == 0 {
+		bits = defaultAPIKeyBits
+	}
+	if bits < defaultAPIKeyBits {
+		return nil, fmt.Errorf("OCI API keys must be at least %d bits", defaultAPIKeyBits)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, err
+	}
+
+	block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	if passphrase != "" {
+		block, err = x509.EncryptPEMBlock(rand.Reader, block.Type, block.Bytes, []byte(passphrase), x509.PEMCipherAES256)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &APIKey{
+		PrivateKeyPEM: pem.EncodeToMemory(block),
+		PublicKeyPEM:  pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey}),
+		Fingerprint:   fingerprintOf(publicKey),
+	}, nil
+}
+
+// Fingerprint returns the fingerprint that OCI uses to identify an API public key, the MD5 hash of its DER encoding
+func Fingerprint(publicKey *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	return fingerprintOf(der), nil
+}
+
+func fingerprintOf(der []byte) string {
+	sum := md5.Sum(der)
+	hex := make([]string, len(sum))
+	for i, b := range sum {
+		hex[i] = fmt.Sprintf("%02x", b)
+	}
+	return strings.Join(hex, ":")
+}
+
+// WriteFiles writes the private key (readable only by the owner) and the public key, creating their directories. An
+// existing private key is never overwritten.
+func (k *APIKey) WriteFiles(privateKeyPath, publicKeyPath string) error {
+	for _, path := range []string{privateKeyPath, publicKeyPath} {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(privateKeyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("unable to write private key: %s", err)
+	}
+	if _, err := f.Write(k.PrivateKeyPEM); err != nil {
+		f.Close()
+		return fmt.Errorf("unable to write private key: %s", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("unable to write private key: %s", err)
+	}
+
+	if err := ioutil.WriteFile(publicKeyPath, k.PublicKeyPEM, 0644); err != nil {
+		return fmt.Errorf("unable to write public key: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package oracle
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/provider"
+	oci "github.com/oracle/oci-go-sdk/v65/common"
+)
+
+func TestDetectPrivateKeyFormat(t *testing.T) {
+	plain, err := GenerateAPIKey(0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := GenerateAPIKey(0, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := oci.PrivateKeyFromBytes(plain.PrivateKeyPEM, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPKCS8, err := x509.MarshalPKCS8PrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(typ string, der []byte) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	}
+
+	for _, tc := range []struct {
+		name   string
+		data   []byte
+		format PrivateKeyFormat
+		err    string
+	}{
+		{"pkcs1", plain.PrivateKeyPEM, KeyFormatPKCS1, ""},
+		{"encrypted pkcs1", encrypted.PrivateKeyPEM, KeyFormatEncryptedPKCS1, ""},
+		{"pkcs8", encode("PRIVATE KEY", pkcs8), KeyFormatPKCS8, ""},
+		{"encrypted pkcs8", encode("ENCRYPTED PRIVATE KEY", []byte("opaque")), KeyFormatEncryptedPKCS8, ""},
+		{"public key", plain.PublicKeyPEM, "", "this is a public key"},
+		{"ec key", encode("PRIVATE KEY", ecPKCS8), "", "must be RSA keys"},
+		{"openssh key", encode("OPENSSH PRIVATE KEY", []byte("opaque")), "", "ssh-keygen -p -m PEM"},
+		{"der", x509.MarshalPKCS1PrivateKey(key), "", "DER encoded"},
+		{"corrupt pkcs1", encode("RSA PRIVATE KEY", []byte("corrupt")), "", "invalid PKCS#1"},
+		{"not a key", []byte("hello"), "", "no PEM data"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			format, err := DetectPrivateKeyFormat(tc.data)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("expected an error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil || format != tc.format {
+				t.Errorf("expected %s, got %s %v", tc.format, format, err)
+			}
+		})
+	}
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	if _, err := GenerateAPIKey(1024, ""); err == nil {
+		t.Errorf("expected short keys to be rejected")
+	}
+
+	apiKey, err := GenerateAPIKey(2048, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fingerprintPattern.MatchString(apiKey.Fingerprint) {
+		t.Errorf("unexpected fingerprint format %s", apiKey.Fingerprint)
+	}
+	passphrase := "s3cret"
+	key, err := oci.PrivateKeyFromBytes(apiKey.PrivateKeyPEM, &passphrase)
+	if err != nil {
+		t.Fatalf("expected the OCI SDK to load the encrypted key: %s", err)
+	}
+	if fingerprint, _ := Fingerprint(&key.PublicKey); fingerprint != apiKey.Fingerprint {
+		t.Errorf("expected the fingerprint of the private key to match, got %s and %s", fingerprint, apiKey.Fingerprint)
+	}
+
+	dir := t.TempDir()
+	keyFile, publicKeyFile := filepath.Join(dir, "oci", "key.pem"), filepath.Join(dir, "oci", "key_public.pem")
+	if err := apiKey.WriteFiles(keyFile, publicKeyFile); err != nil {
+		t.Fatal(err)
+	}
+	if err := apiKey.WriteFiles(keyFile, publicKeyFile); err == nil {
+		t.Errorf("expected an existing private key not to be overwritten")
+	}
+
+	// the generated key can be used with the oracle provider, getting its passphrase from a passphrase source
+	for _, env := range []string{OCI_CLI_PROFILE_ENV_VAR, OCI_CLI_TENANCY_ENV_VAR, OCI_CLI_USER_ENV_VAR, OCI_CLI_FINGERPRINT_ENV_VAR, OCI_CLI_KEY_FILE_ENV_VAR} {
+		t.Setenv(env, "")
+	}
+	t.Setenv(OCI_CLI_CONFIG_FILE_ENV_VAR, filepath.Join(dir, "missing"))
+	t.Setenv("FN_TEST_KEY_PASSPHRASE", "s3cret")
+	endpoint, authorizations := recordingEndpoint(t)
+	config := map[string]string{
+		provider.CfgFnAPIURL: endpoint,
+		CfgTenancyID:         "ocid1.tenancy.oc1..keys",
+		CfgUserID:            "ocid1.user.oc1..keys",
+		CfgFingerprint:       apiKey.Fingerprint,
+		CfgKeyFile:           keyFile,
+		CfgCompartmentID:     "ocid1.compartment.oc1..keys",
+	}
+	p, err := NewFromConfig(provider.NewConfigSourceFromMap(config), &provider.EnvPassPhraseSource{Var: "FN_TEST_KEY_PASSPHRASE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.APIClientv2().Apps.ListApps(apps.NewListAppsParams()); err != nil {
+		t.Fatal(err)
+	}
+	expected := `keyId="ocid1.tenancy.oc1..keys/ocid1.user.oc1..keys/` + apiKey.Fingerprint + `"`
+	if got := authorizations(); len(got) != 1 || !strings.Contains(got[0], expected) {
+		t.Errorf("expected requests to be signed with the generated key, got %v", got)
+	}
+
+	t.Setenv("FN_TEST_KEY_PASSPHRASE", "wrong")
+	_, err = NewFromConfig(provider.NewConfigSourceFromMap(config), &provider.EnvPassPhraseSource{Var: "FN_TEST_KEY_PASSPHRASE"})
+	if err == nil || !strings.Contains(err.Error(), "unable to decrypt private key") {
+		t.Errorf("expected a wrong passphrase to be reported up front, got %v", err)
+	}
+}
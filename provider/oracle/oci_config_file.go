@@ -0,0 +1,319 @@
+package oracle
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	oci "github.com/oracle/oci-go-sdk/v65/common"
+)
+
+// Keys of a profile in an OCI config file
+const (
+	ProfileUser              = "user"
+	ProfileFingerprint       = "fingerprint"
+	ProfileKeyFile           = "key_file"
+	ProfileTenancy           = "tenancy"
+	ProfileRegion            = "region"
+	ProfilePassPhrase        = "pass_phrase"
+	ProfileSecurityTokenFile = "security_token_file"
+
+	defaultOCIProfile = "DEFAULT"
+)
+
+var (
+	fingerprintPattern   = regexp.MustCompile(`^([0-9a-f]{2}:){15}[0-9a-f]{2}$`)
+	profileHeaderPattern = regexp.MustCompile(`^\[(.*)\]`)
+)
+
+// OCIConfigFile is an OCI CLI/SDK config file. It is edited line by line, so that comments, the order of profiles
+// and keys, and keys that fn_go doesn't know about are kept when it is saved.
+type OCIConfigFile struct {
+	Path  string
+	lines []string
+}
+
+// LoadOCIConfigFile reads an OCI config file, a file that doesn't exist yet is loaded as an empty file
+func LoadOCIConfigFile(path string) (*OCIConfigFile, error) {
+	expanded, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(expanded)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return ParseOCIConfigFile(expanded, data), nil
+}
+
+// ParseOCIConfigFile parses the contents of an OCI config file, path is used when it is saved
+func ParseOCIConfigFile(path string, data []byte) *OCIConfigFile {
+	f := &OCIConfigFile{Path: path}
+	if len(data) > 0 {
+		f.lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+	return f
+}
+
+// Bytes returns the contents of the file
+func (f *OCIConfigFile) Bytes() []byte {
+	if len(f.lines) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(f.lines, "\n") + "\n")
+}
+
+// Save writes the file, creating its directory. The file is only readable by its owner, as it may hold passphrases.
+func (f *OCIConfigFile) Save() error {
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0700); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(f.Path), "."+filepath.Base(f.Path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(f.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// Profiles returns the names of the profiles in the file, in the order they appear
+func (f *OCIConfigFile) Profiles() []string {
+	var names []string
+	for _, line := range f.lines {
+		if name, ok := profileHeader(line); ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Profile returns the keys set in a profile, key names are lower case. Keys inherited from DEFAULT are not included,
+// see ResolvedProfile.
+func (f *OCIConfigFile) Profile(name string) (map[string]string, bool) {
+	start, end := f.section(name)
+	if start < 0 {
+		return nil, false
+	}
+	values := map[string]string{}
+	for _, line := range f.lines[start+1 : end] {
+		if key, value, ok := profileEntry(line); ok {
+			values[strings.ToLower(key)] = value
+		}
+	}
+	return values, true
+}
+
+// ResolvedProfile returns the keys of a profile along with those it inherits from the DEFAULT profile
+func (f *OCIConfigFile) ResolvedProfile(name string) (map[string]string, error) {
+	values, ok := f.Profile(name)
+	if !ok {
+		return nil, fmt.Errorf("profile %s not found in %s", name, f.Path)
+	}
+	if name == defaultOCIProfile {
+		return values, nil
+	}
+	resolved, _ := f.Profile(defaultOCIProfile)
+	if resolved == nil {
+		resolved = map[string]string{}
+	}
+	for k, v := range values {
+		resolved[k] = v
+	}
+	return resolved, nil
+}
+
+// SetProfile sets keys of a profile, creating the profile at the end of the file if it doesn't exist. Existing keys
+// are updated in place and new keys are added at the end of the profile; a key with an empty value is removed. Keys
+// that are not in values are left as they are.
+func (f *OCIConfigFile) SetProfile(name string, values map[string]string) {
+	start, end := f.section(name)
+	if start < 0 {
+		if len(f.lines) > 0 && strings.TrimSpace(f.lines[len(f.lines)-1]) != "" {
+			f.lines = append(f.lines, "")
+		}
+		f.lines = append(f.lines, "["+name+"]")
+		start, end = len(f.lines)-1, len(f.lines)
+	}
+
+	remaining := map[string]string{}
+	for k, v := range values {
+		remaining[strings.ToLower(k)] = v
+	}
+
+	var section []string
+	for _, line := range f.lines[start+1 : end] {
+		key, _, ok := profileEntry(line)
+		if value, set := remaining[strings.ToLower(key)]; ok && set {
+			delete(remaining, strings.ToLower(key))
+			if value == "" {
+				continue
+			}
+			line = key + "=" + value
+		}
+		section = append(section, line)
+	}
+
+	// new keys go after the last key, ahead of any blank lines or comments that lead into the next profile
+	insert := len(section)
+	for insert > 0 {
+		if _, _, ok := profileEntry(section[insert-1]); ok {
+			break
+		}
+		insert--
+	}
+	var added []string
+	for _, key := range sortedKeys(remaining) {
+		if remaining[key] != "" {
+			added = append(added, key+"="+remaining[key])
+		}
+	}
+	section = append(section[:insert], append(added, section[insert:]...)...)
+
+	lines := append([]string{}, f.lines[:start+1]...)
+	lines = append(lines, section...)
+	f.lines = append(lines, f.lines[end:]...)
+}
+
+// RemoveProfile removes a profile and all of its lines, reporting whether it existed
+func (f *OCIConfigFile) RemoveProfile(name string) bool {
+	start, end := f.section(name)
+	if start < 0 {
+		return false
+	}
+	f.lines = append(f.lines[:start], f.lines[end:]...)
+	return true
+}
+
+// ValidateProfile checks that a profile (with the keys it inherits from DEFAULT) can be used to sign requests: that the
+// OCIDs look right, the key file is a usable RSA private key, and the fingerprint matches the key. The fingerprint of
+// an encrypted key is only checked if the profile has its pass_phrase.
+func (f *OCIConfigFile) ValidateProfile(name string) error {
+	values, err := f.ResolvedProfile(name)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	require := func(key, prefix string) {
+		value := values[key]
+		switch {
+		case value == "":
+			problems = append(problems, fmt.Sprintf("%s is not set", key))
+		case prefix != "" && !strings.HasPrefix(value, prefix):
+			problems = append(problems, fmt.Sprintf("%s %s is not a %s OCID", key, value, strings.TrimSuffix(strings.TrimPrefix(prefix, "ocid1."), ".")))
+		}
+	}
+	require(ProfileTenancy, "ocid1.tenancy.")
+	require(ProfileRegion, "")
+
+	if tokenFile := values[ProfileSecurityTokenFile]; tokenFile != "" {
+		expanded, err := homedir.Expand(tokenFile)
+		if err == nil {
+			_, err = ioutil.ReadFile(expanded)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s can't be read: %s", ProfileSecurityTokenFile, err))
+		}
+	} else {
+		require(ProfileUser, "ocid1.user.")
+		require(ProfileFingerprint, "")
+	}
+
+	fingerprint := values[ProfileFingerprint]
+	if fingerprint != "" && !fingerprintPattern.MatchString(fingerprint) {
+		problems = append(problems, fmt.Sprintf("%s %s is not 16 colon separated hex bytes", ProfileFingerprint, fingerprint))
+	}
+
+	require(ProfileKeyFile, "")
+	if keyFile := values[ProfileKeyFile]; keyFile != "" {
+		keyBytes, format, err := readPrivateKey(keyFile)
+		switch {
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("%s %s: %s", ProfileKeyFile, keyFile, err))
+		case format.Encrypted() && values[ProfilePassPhrase] == "":
+			// the passphrase will be asked for, so the fingerprint can't be checked now
+		case values[ProfileSecurityTokenFile] == "" && fingerprintPattern.MatchString(fingerprint):
+			var passphrase *string
+			if format.Encrypted() {
+				p := values[ProfilePassPhrase]
+				passphrase = &p
+			}
+			key, err := oci.PrivateKeyFromBytes(keyBytes, passphrase)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s %s can't be loaded: %s", ProfileKeyFile, keyFile, err))
+				break
+			}
+			if actual, err := Fingerprint(&key.PublicKey); err == nil && actual != fingerprint {
+				problems = append(problems, fmt.Sprintf("%s %s does not match %s %s, whose fingerprint is %s", ProfileFingerprint, fingerprint, ProfileKeyFile, keyFile, actual))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("profile %s in %s is invalid: %s", name, f.Path, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// section returns the line of a profile's header and the line that follows its last line, or -1 if it is missing
+func (f *OCIConfigFile) section(name string) (int, int) {
+	start := -1
+	for i, line := range f.lines {
+		header, ok := profileHeader(line)
+		if !ok {
+			continue
+		}
+		if start >= 0 {
+			return start, i
+		}
+		if header == name {
+			start = i
+		}
+	}
+	if start < 0 {
+		return -1, -1
+	}
+	return start, len(f.lines)
+}
+
+// profileHeader returns the name of the profile that a line starts, headers are matched the way the OCI SDK matches them
+func profileHeader(line string) (string, bool) {
+	match := profileHeaderPattern.FindStringSubmatch(line)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+func profileEntry(line string) (string, string, bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+		return "", "", false
+	}
+	i := strings.Index(trimmed, "=")
+	if i <= 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(trimmed[:i]), strings.TrimSpace(trimmed[i+1:]), true
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
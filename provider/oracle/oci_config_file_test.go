@@ -0,0 +1,146 @@
+package oracle
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testOCIConfig = `# managed by the platform team
+[DEFAULT]
+region = us-ashburn-1
+tenancy=ocid1.tenancy.oc1..default
+custom_setting=kept
+
+; legacy profile, remove after the migration
+[LEGACY]
+user=ocid1.user.oc1..legacy
+fingerprint=00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff
+key_file=/keys/legacy.pem
+pass_phrase=old
+
+# leads into the next profile
+[WORK]
+user=ocid1.user.oc1..work
+`
+
+func TestOCIConfigFileEditsKeepUnknownContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".oci", "config")
+	f := ParseOCIConfigFile(path, []byte(testOCIConfig))
+
+	if got := f.Profiles(); !reflect.DeepEqual(got, []string{"DEFAULT", "LEGACY", "WORK"}) {
+		t.Errorf("unexpected profiles %v", got)
+	}
+	work, err := f.ResolvedProfile("WORK")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if work[ProfileRegion] != "us-ashburn-1" || work[ProfileUser] != "ocid1.user.oc1..work" || work["custom_setting"] != "kept" {
+		t.Errorf("expected WORK to inherit from DEFAULT, got %v", work)
+	}
+	if _, ok := f.Profile("MISSING"); ok {
+		t.Errorf("expected a missing profile not to be found")
+	}
+
+	f.SetProfile("LEGACY", map[string]string{"pass_phrase": "", "fingerprint": "ff:ee:dd:cc:bb:aa:99:88:77:66:55:44:33:22:11:00", "region": "eu-frankfurt-1"})
+	f.SetProfile("NEW", map[string]string{"user": "ocid1.user.oc1..new", "key_file": "~/.oci/new.pem"})
+	if err := f.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadOCIConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `# managed by the platform team
+[DEFAULT]
+region = us-ashburn-1
+tenancy=ocid1.tenancy.oc1..default
+custom_setting=kept
+
+; legacy profile, remove after the migration
+[LEGACY]
+user=ocid1.user.oc1..legacy
+fingerprint=ff:ee:dd:cc:bb:aa:99:88:77:66:55:44:33:22:11:00
+key_file=/keys/legacy.pem
+region=eu-frankfurt-1
+
+# leads into the next profile
+[WORK]
+user=ocid1.user.oc1..work
+
+[NEW]
+key_file=~/.oci/new.pem
+user=ocid1.user.oc1..new
+`
+	if string(loaded.Bytes()) != expected {
+		t.Errorf("unexpected file after edits:\n%s", loaded.Bytes())
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected the config file to be private, got %v %v", info.Mode(), err)
+	}
+
+	if !loaded.RemoveProfile("LEGACY") || loaded.RemoveProfile("LEGACY") {
+		t.Errorf("expected LEGACY to be removed once")
+	}
+	if got := loaded.Profiles(); !reflect.DeepEqual(got, []string{"DEFAULT", "WORK", "NEW"}) {
+		t.Errorf("unexpected profiles after removal %v", got)
+	}
+
+	missing, err := LoadOCIConfigFile(filepath.Join(t.TempDir(), "config"))
+	if err != nil || len(missing.Profiles()) != 0 {
+		t.Errorf("expected a missing file to load empty, got %v %v", missing.Profiles(), err)
+	}
+}
+
+func TestValidateOCIConfigProfile(t *testing.T) {
+	dir := t.TempDir()
+	apiKey, err := GenerateAPIKey(0, "s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := apiKey.WriteFiles(keyFile, filepath.Join(dir, "key_public.pem")); err != nil {
+		t.Fatal(err)
+	}
+	publicKeyFile := filepath.Join(dir, "key_public.pem")
+
+	f := ParseOCIConfigFile(filepath.Join(dir, "config"), nil)
+	f.SetProfile("DEFAULT", map[string]string{
+		ProfileRegion:      "us-phoenix-1",
+		ProfileTenancy:     "ocid1.tenancy.oc1..onboard",
+		ProfileUser:        "ocid1.user.oc1..onboard",
+		ProfileFingerprint: apiKey.Fingerprint,
+		ProfileKeyFile:     keyFile,
+	})
+	if err := f.ValidateProfile("DEFAULT"); err != nil {
+		t.Errorf("expected a profile with an encrypted key and no pass_phrase to be valid, got %v", err)
+	}
+
+	f.SetProfile("DEFAULT", map[string]string{ProfilePassPhrase: "s3cret"})
+	if err := f.ValidateProfile("DEFAULT"); err != nil {
+		t.Errorf("expected a valid profile, got %v", err)
+	}
+
+	f.SetProfile("BROKEN", map[string]string{
+		ProfileTenancy:     "ocid1.compartment.oc1..oops",
+		ProfileUser:        "",
+		ProfileFingerprint: "00:11:22:33:44:55:66:77:88:99:aa:bb:cc:dd:ee:ff",
+	})
+	err = f.ValidateProfile("BROKEN")
+	if err == nil || !strings.Contains(err.Error(), "not a tenancy OCID") || !strings.Contains(err.Error(), "whose fingerprint is "+apiKey.Fingerprint) {
+		t.Errorf("expected the bad tenancy and fingerprint to be reported, got %v", err)
+	}
+
+	f.SetProfile("PUBLIC", map[string]string{ProfileKeyFile: publicKeyFile, ProfileFingerprint: "aa:bb"})
+	err = f.ValidateProfile("PUBLIC")
+	if err == nil || !strings.Contains(err.Error(), "this is a public key") || !strings.Contains(err.Error(), "not 16 colon separated hex bytes") {
+		t.Errorf("expected the public key and bad fingerprint to be reported, got %v", err)
+	}
+
+	if err := f.ValidateProfile("MISSING"); err == nil {
+		t.Errorf("expected a missing profile to be reported")
+	}
+}
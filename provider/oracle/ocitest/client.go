@@ -0,0 +1,767 @@
+// Package ocitest provides a stateful in-memory simulation of the OCI Functions management API for testing the oracle
+// provider without a tenancy.
+//
+// Client implements client.FunctionsManagementClient and can be handed straight to the shims, Server exposes the same
+// state over HTTP so that a provider created with NewFromConfig can be pointed at it through api-url.
+//
+// Resources go through the lifecycle states of the real service: they are CREATING (or UPDATING, DELETING) until
+// TransitionDelay has passed, after which they become ACTIVE (or are removed). Updates and deletes honour IfMatch
+// against the resource's ETag, lists are paged through OpcNextPage and can be filtered by display name.
+package ocitest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/fnproject/fn_go/provider/oracle/shim/client"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/functions"
+)
+
+const (
+	// DefaultInvokeEndpoint is the invoke endpoint reported for functions unless Client.InvokeEndpoint is set
+	DefaultInvokeEndpoint = "https://functions.ocitest.invalid"
+
+	defaultLimit = 50
+	maxLimit     = 1000
+)
+
+// Error is returned for failed operations, it implements common.ServiceError just like errors from the real client
+type Error struct {
+	StatusCode   int
+	Code         string
+	Message      string
+	OpcRequestID string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("Error returned by Functions Service. Http Status Code: %d. Error Code: %s. Opc request id: %s. Message: %s",
+		e.StatusCode, e.Code, e.OpcRequestID, e.Message)
+}
+
+func (e *Error) GetHTTPStatusCode() int  { return e.StatusCode }
+func (e *Error) GetMessage() string      { return e.Message }
+func (e *Error) GetCode() string         { return e.Code }
+func (e *Error) GetOpcRequestID() string { return e.OpcRequestID }
+
+var _ common.ServiceError = &Error{}
+
+type application struct {
+	functions.Application
+	seq   int
+	etag  int
+	until time.Time
+	// movingTo is the compartment that the application moves to once its update completes
+	movingTo *string
+}
+
+type function struct {
+	functions.Function
+	seq   int
+	etag  int
+	until time.Time
+}
+
+// Client is an in-memory implementation of the OCI Functions management client
+type Client struct {
+	// TransitionDelay is how long resources stay in a transitional lifecycle state. With no delay a transition
+	// completes before the next call is handled, so a created resource is ACTIVE the next time it is read.
+	TransitionDelay time.Duration
+	// InvokeEndpoint is the invoke endpoint reported for functions
+	InvokeEndpoint string
+
+	mu          sync.Mutex
+	seq         int
+	apps        map[string]*application
+	fns         map[string]*function
+	pbfListings map[string]*pbfListing
+	pbfVersions map[string]*pbfListingVersion
+}
+
+var _ client.FunctionsManagementClient = &Client{}
+
+// NewClient creates an empty simulated client
+func NewClient() *Client {
+	return &Client{
+		InvokeEndpoint: DefaultInvokeEndpoint,
+		apps:           map[string]*application{},
+		fns:            map[string]*function{},
+		pbfListings:    map[string]*pbfListing{},
+		pbfVersions:    map[string]*pbfListingVersion{},
+	}
+}
+
+func (c *Client) nextSeq() int {
+	c.seq++
+	return c.seq
+}
+
+func (c *Client) newID(kind string) string {
+	return fmt.Sprintf("ocid1.fn%s.oc1.ocitest.%012d", kind, c.nextSeq())
+}
+
+func now() *common.SDKTime {
+	return &common.SDKTime{Time: time.Now().UTC()}
+}
+
+func etag(n int) *string {
+	s := strconv.Itoa(n)
+	return &s
+}
+
+func requestID(id *string) *string {
+	if id != nil && *id != "" {
+		return id
+	}
+	generated := fmt.Sprintf("ocitest-%d", time.Now().UnixNano())
+	return &generated
+}
+
+func serviceError(status int, code string, opcRequestID *string, format string, args ...interface{}) error {
+	return &Error{StatusCode: status, Code: code, Message: fmt.Sprintf(format, args...), OpcRequestID: *opcRequestID}
+}
+
+func notFound(opcRequestID *string, kind, id string) error {
+	return serviceError(http.StatusNotFound, "NotAuthorizedOrNotFound", opcRequestID, "%s %s not found or not authorized", kind, id)
+}
+
+func checkIfMatch(ifMatch *string, current int, opcRequestID *string) error {
+	if ifMatch != nil && *ifMatch != "" && *ifMatch != *etag(current) {
+		return serviceError(http.StatusPreconditionFailed, "PreconditionFailed", opcRequestID, "The resource has been modified, ETag %s does not match", *ifMatch)
+	}
+	return nil
+}
+
+// advance completes any lifecycle transitions that are due
+func (c *Client) advance() {
+	t := time.Now()
+	for id, app := range c.apps {
+		if t.Before(app.until) {
+			continue
+		}
+		switch app.LifecycleState {
+		case functions.ApplicationLifecycleStateCreating, functions.ApplicationLifecycleStateUpdating:
+			app.LifecycleState = functions.ApplicationLifecycleStateActive
+			if app.movingTo != nil {
+				c.completeMove(app)
+			}
+		case functions.ApplicationLifecycleStateDeleting:
+			delete(c.apps, id)
+		}
+	}
+	for id, fn := range c.fns {
+		if t.Before(fn.until) {
+			continue
+		}
+		switch fn.LifecycleState {
+		case functions.FunctionLifecycleStateCreating, functions.FunctionLifecycleStateUpdating:
+			fn.LifecycleState = functions.FunctionLifecycleStateActive
+		case functions.FunctionLifecycleStateDeleting:
+			delete(c.fns, id)
+		}
+	}
+}
+
+func (c *Client) transitionEnd() time.Time {
+	return time.Now().Add(c.TransitionDelay)
+}
+
+func (c *Client) CreateApplication(ctx context.Context, request functions.CreateApplicationRequest) (functions.CreateApplicationResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance()
+
+	rid := requestID(request.OpcRequestId)
+	details := request.CreateApplicationDetails
+	if details.CompartmentId == nil || *details.CompartmentId == "" {
+		return functions.CreateApplicationResponse{}, serviceError(http.StatusBadRequest, "MissingParameter", rid, "compartmentId is required")
+	}
+	if details.DisplayName == nil || *details.DisplayName == "" {
+		return functions.CreateApplicationResponse{}, serviceError(http.StatusBadRequest, "MissingParameter", rid, "displayName is required")
+	}
+	if len(details.SubnetIds) == 0 {
+		return functions.CreateApplicationResponse{}, serviceError(http.StatusBadRequest, "MissingParameter", rid, "subnetIds is required")
+	}
+	for _, app := range c.apps {
+		if *app.CompartmentId == *details.CompartmentId && *app.DisplayName == *details.DisplayName {
+			return functions.CreateApplicationResponse{}, serviceError(http.StatusConflict, "Conflict", rid, "An application with the name %s already exists", *details.DisplayName)
+		}
+	}
+
+	shape := functions.ApplicationShapeEnum(details.Shape)
+	if shape == "" {
+		shape = functions.ApplicationShapeX86
+	}
+
+	id := c.newID("app")
+	app := &application{
+		Application: functions.Application{
+			Id:                      &id,
+			CompartmentId:           details.CompartmentId,
+			DisplayName:             details.DisplayName,
+			LifecycleState:          functions.ApplicationLifecycleStateCreating,
+			Config:                  copyConfig(details.Config),
+			SubnetIds:               details.SubnetIds,
+			Shape:                   shape,
+			NetworkSecurityGroupIds: details.NetworkSecurityGroupIds,
+			SyslogUrl:               details.SyslogUrl,
+			TraceConfig:             details.TraceConfig,
+			FreeformTags:            details.FreeformTags,
+			DefinedTags:             details.DefinedTags,
+			TimeCreated:             now(),
+			TimeUpdated:             now(),
+			ImagePolicyConfig:       details.ImagePolicyConfig,
+		},
+		seq:   c.nextSeq(),
+		etag:  1,
+		until: c.transitionEnd(),
+	}
+	c.apps[id] = app
+
+	return functions.CreateApplicationResponse{
+		Application:  app.snapshot(),
+		Etag:         etag(app.etag),
+		OpcRequestId: rid,
+	}, nil
+}
+
+func (c *Client) GetApplication(ctx context.Context, request functions.GetApplicationRequest) (functions.GetApplicationResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance()
+
+	rid := requestID(request.OpcRequestId)
+	app, err := c.getApp(request.ApplicationId, rid)
+	if err != nil {
+		return functions.GetApplicationResponse{}, err
+	}
+	return functions.GetApplicationResponse{
+		Application:  app.snapshot(),
+		Etag:         etag(app.etag),
+		OpcRequestId: rid,
+	}, nil
+}
+
+func (c *Client) getApp(id *string, rid *string) (*application, error) {
+	if id == nil {
+		return nil, serviceError(http.StatusBadRequest, "MissingParameter", rid, "applicationId is required")
+	}
+	app, ok := c.apps[*id]
+	if !ok {
+		return nil, notFound(rid, "Application", *id)
+	}
+	return app, nil
+}
+
+func (c *Client) ListApplications(ctx context.Context, request functions.ListApplicationsRequest) (functions.ListApplicationsResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance()
+
+	rid := requestID(request.OpcRequestId)
+	if request.CompartmentId == nil || *request.CompartmentId == "" {
+		return functions.ListApplicationsResponse{}, serviceError(http.StatusBadRequest, "MissingParameter", rid, "compartmentId is required")
+	}
+
+	var matches []*application
+	for _, app := range c.apps {
+		if *app.CompartmentId != *request.CompartmentId ||
+			(request.DisplayName != nil && *request.DisplayName != *app.DisplayName) ||
+			(request.Id != nil && *request.Id != *app.Id) ||
+			(request.LifecycleState != "" && request.LifecycleState != app.LifecycleState) {
+			continue
+		}
+		matches = append(matches, app)
+	}
+	byName := request.SortBy == functions.ListApplicationsSortByDisplayname
+	sort.Slice(matches, func(i, j int) bool {
+		if byName && *matches[i].DisplayName != *matches[j].DisplayName {
+			return *matches[i].DisplayName < *matches[j].DisplayName
+		}
+		return matches[i].seq < matches[j].seq
+	})
+	if request.SortOrder == functions.ListApplicationsSortOrderDesc {
+		reverseApps(matches)
+	}
+
+	start, end, nextPage, err := page(len(matches), request.Limit, request.Page, rid)
+	if err != nil {
+		return functions.ListApplicationsResponse{}, err
+	}
+
+	items := []functions.ApplicationSummary{}
+	for _, app := range matches[start:end] {
+		items = append(items, app.summary())
+	}
+	return functions.ListApplicationsResponse{
+		Items:        items,
+		OpcNextPage:  nextPage,
+		OpcRequestId: rid,
+	}, nil
+}
+
+func (c *Client) UpdateApplication(ctx context.Context, request functions.UpdateApplicationRequest) (functions.UpdateApplicationResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance()
+
+	rid := requestID(request.OpcRequestId)
+	app, err := c.getApp(request.ApplicationId, rid)
+	if err != nil {
+		return functions.UpdateApplicationResponse{}, err
+	}
+	if err := checkIfMatch(request.IfMatch, app.etag, rid); err != nil {
+		return functions.UpdateApplicationResponse{}, err
+	}
+	if app.LifecycleState != functions.ApplicationLifecycleStateActive {
+		return functions.UpdateApplicationResponse{}, serviceError(http.StatusConflict, "IncorrectState", rid, "Application %s is %s", *app.Id, app.LifecycleState)
+	}
+
+	details := request.UpdateApplicationDetails
+	// OCI replaces config wholesale, merging is left to the caller
+	if details.Config != nil {
+		app.Config = copyConfig(details.Config)
+	}
+	if details.NetworkSecurityGroupIds != nil {
+		app.NetworkSecurityGroupIds = details.NetworkSecurityGroupIds
+	}
+	if details.SyslogUrl != nil {
+		app.SyslogUrl = details.SyslogUrl
+	}
+	if details.TraceConfig != nil {
+		app.TraceConfig = details.TraceConfig
+	}
+	if details.FreeformTags != nil {
+		app.FreeformTags = details.FreeformTags
+	}
+	if details.DefinedTags != nil {
+		app.DefinedTags = details.DefinedTags
+	}
+	if details.ImagePolicyConfig != nil {
+		app.ImagePolicyConfig = details.ImagePolicyConfig
+	}
+	app.TimeUpdated = now()
+	app.etag++
+	app.LifecycleState = functions.ApplicationLifecycleStateUpdating
+	app.until = c.transitionEnd()
+
+	return functions.UpdateApplicationResponse{
+		Application:  app.snapshot(),
+		Etag:         etag(app.etag),
+		OpcRequestId: rid,
+	}, nil
+}
+
+func (c *Client) ChangeApplicationCompartment(ctx context.Context, request functions.ChangeApplicationCompartmentRequest) (functions.ChangeApplicationCompartmentResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance()
+
+	rid := requestID(request.OpcRequestId)
+	app, err := c.getApp(request.ApplicationId, rid)
+	if err != nil {
+		return functions.ChangeApplicationCompartmentResponse{}, err
+	}
+	if err := checkIfMatch(request.IfMatch, app.etag, rid); err != nil {
+		return functions.ChangeApplicationCompartmentResponse{}, err
+	}
+	target := request.ChangeApplicationCompartmentDetails.CompartmentId
+	if target == nil || *target == "" {
+		return functions.ChangeApplicationCompartmentResponse{}, serviceError(http.StatusBadRequest, "MissingParameter", rid, "compartmentId is required")
+	}
+	if app.LifecycleState != functions.ApplicationLifecycleStateActive {
+		return functions.ChangeApplicationCompartmentResponse{}, serviceError(http.StatusConflict, "IncorrectState", rid, "Application %s is %s", *app.Id, app.LifecycleState)
+	}
+	for _, other := range c.apps {
+		if *other.CompartmentId == *target && *other.DisplayName == *app.DisplayName {
+			return functions.ChangeApplicationCompartmentResponse{}, serviceError(http.StatusConflict, "Conflict", rid, "An application with the name %s already exists in compartment %s", *app.DisplayName, *target)
+		}
+	}
+
+	compartment := *target
+	app.movingTo = &compartment
+	app.etag++
+	app.LifecycleState = functions.ApplicationLifecycleStateUpdating
+	app.until = c.transitionEnd()
+	return functions.ChangeApplicationCompartmentResponse{OpcRequestId: rid}, nil
+}
+
+// completeMove moves an application and its functions into the compartment it is moving to
+func (c *Client) completeMove(app *application) {
+	app.CompartmentId = app.movingTo
+	app.movingTo = nil
+	app.TimeUpdated = now()
+	for _, fn := range c.fns {
+		if *fn.ApplicationId == *app.Id {
+			fn.CompartmentId = app.CompartmentId
+		}
+	}
+}
+
+func (c *Client) DeleteApplication(ctx context.Context, request functions.DeleteApplicationRequest) (functions.DeleteApplicationResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance()
+
+	rid := requestID(request.OpcRequestId)
+	app, err := c.getApp(request.ApplicationId, rid)
+	if err != nil {
+		return functions.DeleteApplicationResponse{}, err
+	}
+	if err := checkIfMatch(request.IfMatch, app.etag, rid); err != nil {
+		return functions.DeleteApplicationResponse{}, err
+	}
+	if app.LifecycleState == functions.ApplicationLifecycleStateDeleting {
+		return functions.DeleteApplicationResponse{}, serviceError(http.StatusConflict, "IncorrectState", rid, "Application %s is already being deleted", *app.Id)
+	}
+	for _, fn := range c.fns {
+		if *fn.ApplicationId == *app.Id {
+			return functions.DeleteApplicationResponse{}, serviceError(http.StatusConflict, "Conflict", rid, "Application %s still contains functions", *app.Id)
+		}
+	}
+
+	app.LifecycleState = functions.ApplicationLifecycleStateDeleting
+	app.until = c.transitionEnd()
+	return functions.DeleteApplicationResponse{OpcRequestId: rid}, nil
+}
+
+func (c *Client) CreateFunction(ctx context.Context, request functions.CreateFunctionRequest) (functions.CreateFunctionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance()
+
+	rid := requestID(request.OpcRequestId)
+	details := request.CreateFunctionDetails
+	if details.DisplayName == nil || *details.DisplayName == "" {
+		return functions.CreateFunctionResponse{}, serviceError(http.StatusBadRequest, "MissingParameter", rid, "displayName is required")
+	}
+	if details.MemoryInMBs == nil {
+		return functions.CreateFunctionResponse{}, serviceError(http.StatusBadRequest, "MissingParameter", rid, "memoryInMBs is required")
+	}
+	if (details.Image == nil || *details.Image == "") && details.SourceDetails == nil {
+		return functions.CreateFunctionResponse{}, serviceError(http.StatusBadRequest, "MissingParameter", rid, "image or sourceDetails is required")
+	}
+	if details.SourceDetails != nil {
+		if details.Image != nil && *details.Image != "" {
+			return functions.CreateFunctionResponse{}, serviceError(http.StatusBadRequest, "InvalidParameter", rid, "image and sourceDetails cannot both be set")
+		}
+		if err := c.checkPbfSource(details.SourceDetails, *details.MemoryInMBs, rid); err != nil {
+			return functions.CreateFunctionResponse{}, err
+		}
+	}
+	app, err := c.getApp(details.ApplicationId, rid)
+	if err != nil {
+		return functions.CreateFunctionResponse{}, err
+	}
+	if app.LifecycleState != functions.ApplicationLifecycleStateActive {
+		return functions.CreateFunctionResponse{}, serviceError(http.StatusConflict, "IncorrectState", rid, "Application %s is %s", *app.Id, app.LifecycleState)
+	}
+	for _, fn := range c.fns {
+		if *fn.ApplicationId == *app.Id && *fn.DisplayName == *details.DisplayName {
+			return functions.CreateFunctionResponse{}, serviceError(http.StatusConflict, "Conflict", rid, "A function with the name %s already exists", *details.DisplayName)
+		}
+	}
+
+	timeout := 30
+	if details.TimeoutInSeconds != nil {
+		timeout = *details.TimeoutInSeconds
+	}
+	id := c.newID("fn")
+	invokeEndpoint := c.InvokeEndpoint
+	fn := &function{
+		Function: functions.Function{
+			Id:                           &id,
+			DisplayName:                  details.DisplayName,
+			LifecycleState:               functions.FunctionLifecycleStateCreating,
+			ApplicationId:                app.Id,
+			CompartmentId:                app.CompartmentId,
+			Image:                        details.Image,
+			ImageDigest:                  details.ImageDigest,
+			SourceDetails:                details.SourceDetails,
+			Shape:                        functions.FunctionShapeEnum(app.Shape),
+			MemoryInMBs:                  details.MemoryInMBs,
+			Config:                       copyConfig(details.Config),
+			TimeoutInSeconds:             &timeout,
+			ProvisionedConcurrencyConfig: details.ProvisionedConcurrencyConfig,
+			TraceConfig:                  details.TraceConfig,
+			FreeformTags:                 details.FreeformTags,
+			InvokeEndpoint:               &invokeEndpoint,
+			DefinedTags:                  details.DefinedTags,
+			TimeCreated:                  now(),
+			TimeUpdated:                  now(),
+		},
+		seq:   c.nextSeq(),
+		etag:  1,
+		until: c.transitionEnd(),
+	}
+	c.fns[id] = fn
+
+	return functions.CreateFunctionResponse{
+		Function:     fn.snapshot(),
+		Etag:         etag(fn.etag),
+		OpcRequestId: rid,
+	}, nil
+}
+
+func (c *Client) GetFunction(ctx context.Context, request functions.GetFunctionRequest) (functions.GetFunctionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance()
+
+	rid := requestID(request.OpcRequestId)
+	fn, err := c.getFn(request.FunctionId, rid)
+	if err != nil {
+		return functions.GetFunctionResponse{}, err
+	}
+	return functions.GetFunctionResponse{
+		Function:     fn.snapshot(),
+		Etag:         etag(fn.etag),
+		OpcRequestId: rid,
+	}, nil
+}
+
+func (c *Client) getFn(id *string, rid *string) (*function, error) {
+	if id == nil {
+		return nil, serviceError(http.StatusBadRequest, "MissingParameter", rid, "functionId is required")
+	}
+	fn, ok := c.fns[*id]
+	if !ok {
+		return nil, notFound(rid, "Function", *id)
+	}
+	return fn, nil
+}
+
+func (c *Client) ListFunctions(ctx context.Context, request functions.ListFunctionsRequest) (functions.ListFunctionsResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance()
+
+	rid := requestID(request.OpcRequestId)
+	if request.ApplicationId == nil || *request.ApplicationId == "" {
+		return functions.ListFunctionsResponse{}, serviceError(http.StatusBadRequest, "MissingParameter", rid, "applicationId is required")
+	}
+
+	var matches []*function
+	for _, fn := range c.fns {
+		if *fn.ApplicationId != *request.ApplicationId ||
+			(request.DisplayName != nil && *request.DisplayName != *fn.DisplayName) ||
+			(request.Id != nil && *request.Id != *fn.Id) ||
+			(request.LifecycleState != "" && request.LifecycleState != fn.LifecycleState) {
+			continue
+		}
+		matches = append(matches, fn)
+	}
+	byName := request.SortBy == functions.ListFunctionsSortByDisplayname
+	sort.Slice(matches, func(i, j int) bool {
+		if byName && *matches[i].DisplayName != *matches[j].DisplayName {
+			return *matches[i].DisplayName < *matches[j].DisplayName
+		}
+		return matches[i].seq < matches[j].seq
+	})
+	if request.SortOrder == functions.ListFunctionsSortOrderDesc {
+		reverseFns(matches)
+	}
+
+	start, end, nextPage, err := page(len(matches), request.Limit, request.Page, rid)
+	if err != nil {
+		return functions.ListFunctionsResponse{}, err
+	}
+
+	items := []functions.FunctionSummary{}
+	for _, fn := range matches[start:end] {
+		items = append(items, fn.summary())
+	}
+	return functions.ListFunctionsResponse{
+		Items:        items,
+		OpcNextPage:  nextPage,
+		OpcRequestId: rid,
+	}, nil
+}
+
+func (c *Client) UpdateFunction(ctx context.Context, request functions.UpdateFunctionRequest) (functions.UpdateFunctionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance()
+
+	rid := requestID(request.OpcRequestId)
+	fn, err := c.getFn(request.FunctionId, rid)
+	if err != nil {
+		return functions.UpdateFunctionResponse{}, err
+	}
+	if err := checkIfMatch(request.IfMatch, fn.etag, rid); err != nil {
+		return functions.UpdateFunctionResponse{}, err
+	}
+	if fn.LifecycleState != functions.FunctionLifecycleStateActive {
+		return functions.UpdateFunctionResponse{}, serviceError(http.StatusConflict, "IncorrectState", rid, "Function %s is %s", *fn.Id, fn.LifecycleState)
+	}
+
+	details := request.UpdateFunctionDetails
+	if details.Image != nil {
+		fn.Image = details.Image
+		// a new image without a digest clears the old digest
+		fn.ImageDigest = details.ImageDigest
+	} else if details.ImageDigest != nil {
+		fn.ImageDigest = details.ImageDigest
+	}
+	if details.MemoryInMBs != nil {
+		fn.MemoryInMBs = details.MemoryInMBs
+	}
+	if details.Config != nil {
+		fn.Config = copyConfig(details.Config)
+	}
+	if details.TimeoutInSeconds != nil {
+		fn.TimeoutInSeconds = details.TimeoutInSeconds
+	}
+	if details.ProvisionedConcurrencyConfig != nil {
+		fn.ProvisionedConcurrencyConfig = details.ProvisionedConcurrencyConfig
+	}
+	if details.TraceConfig != nil {
+		fn.TraceConfig = details.TraceConfig
+	}
+	if details.FreeformTags != nil {
+		fn.FreeformTags = details.FreeformTags
+	}
+	if details.DefinedTags != nil {
+		fn.DefinedTags = details.DefinedTags
+	}
+	fn.TimeUpdated = now()
+	fn.etag++
+	fn.LifecycleState = functions.FunctionLifecycleStateUpdating
+	fn.until = c.transitionEnd()
+
+	return functions.UpdateFunctionResponse{
+		Function:     fn.snapshot(),
+		Etag:         etag(fn.etag),
+		OpcRequestId: rid,
+	}, nil
+}
+
+func (c *Client) DeleteFunction(ctx context.Context, request functions.DeleteFunctionRequest) (functions.DeleteFunctionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.advance()
+
+	rid := requestID(request.OpcRequestId)
+	fn, err := c.getFn(request.FunctionId, rid)
+	if err != nil {
+		return functions.DeleteFunctionResponse{}, err
+	}
+	if err := checkIfMatch(request.IfMatch, fn.etag, rid); err != nil {
+		return functions.DeleteFunctionResponse{}, err
+	}
+	if fn.LifecycleState == functions.FunctionLifecycleStateDeleting {
+		return functions.DeleteFunctionResponse{}, serviceError(http.StatusConflict, "IncorrectState", rid, "Function %s is already being deleted", *fn.Id)
+	}
+
+	fn.LifecycleState = functions.FunctionLifecycleStateDeleting
+	fn.until = c.transitionEnd()
+	return functions.DeleteFunctionResponse{OpcRequestId: rid}, nil
+}
+
+// page works out the slice of a list of n items to return, page tokens are the offset of the first item
+func page(n int, limit *int, token *string, rid *string) (int, int, *string, error) {
+	size := defaultLimit
+	if limit != nil {
+		if *limit < 1 || *limit > maxLimit {
+			return 0, 0, nil, serviceError(http.StatusBadRequest, "InvalidParameter", rid, "limit must be between 1 and %d", maxLimit)
+		}
+		size = *limit
+	}
+
+	start := 0
+	if token != nil && *token != "" {
+		var err error
+		start, err = strconv.Atoi(*token)
+		if err != nil || start < 0 || start > n {
+			return 0, 0, nil, serviceError(http.StatusBadRequest, "InvalidParameter", rid, "invalid page %s", *token)
+		}
+	}
+
+	end := start + size
+	if end >= n {
+		return start, n, nil, nil
+	}
+	next := strconv.Itoa(end)
+	return start, end, &next, nil
+}
+
+func (app *application) snapshot() functions.Application {
+	result := app.Application
+	result.Config = copyConfig(app.Config)
+	return result
+}
+
+func (app *application) summary() functions.ApplicationSummary {
+	return functions.ApplicationSummary{
+		Id:                      app.Id,
+		CompartmentId:           app.CompartmentId,
+		DisplayName:             app.DisplayName,
+		LifecycleState:          app.LifecycleState,
+		SubnetIds:               app.SubnetIds,
+		Shape:                   functions.ApplicationSummaryShapeEnum(app.Shape),
+		NetworkSecurityGroupIds: app.NetworkSecurityGroupIds,
+		TraceConfig:             app.TraceConfig,
+		FreeformTags:            app.FreeformTags,
+		DefinedTags:             app.DefinedTags,
+		TimeCreated:             app.TimeCreated,
+		TimeUpdated:             app.TimeUpdated,
+		ImagePolicyConfig:       app.ImagePolicyConfig,
+	}
+}
+
+func (fn *function) snapshot() functions.Function {
+	result := fn.Function
+	result.Config = copyConfig(fn.Config)
+	return result
+}
+
+func (fn *function) summary() functions.FunctionSummary {
+	return functions.FunctionSummary{
+		Id:                           fn.Id,
+		DisplayName:                  fn.DisplayName,
+		ApplicationId:                fn.ApplicationId,
+		CompartmentId:                fn.CompartmentId,
+		LifecycleState:               fn.LifecycleState,
+		Image:                        fn.Image,
+		ImageDigest:                  fn.ImageDigest,
+		SourceDetails:                fn.SourceDetails,
+		Shape:                        functions.FunctionSummaryShapeEnum(fn.Shape),
+		MemoryInMBs:                  fn.MemoryInMBs,
+		TimeoutInSeconds:             fn.TimeoutInSeconds,
+		ProvisionedConcurrencyConfig: fn.ProvisionedConcurrencyConfig,
+		TraceConfig:                  fn.TraceConfig,
+		FreeformTags:                 fn.FreeformTags,
+		InvokeEndpoint:               fn.InvokeEndpoint,
+		DefinedTags:                  fn.DefinedTags,
+		TimeCreated:                  fn.TimeCreated,
+		TimeUpdated:                  fn.TimeUpdated,
+	}
+}
+
+func reverseApps(apps []*application) {
+	for i, j := 0, len(apps)-1; i < j; i, j = i+1, j-1 {
+		apps[i], apps[j] = apps[j], apps[i]
+	}
+}
+
+func reverseFns(fns []*function) {
+	for i, j := 0, len(fns)-1; i < j; i, j = i+1, j-1 {
+		fns[i], fns[j] = fns[j], fns[i]
+	}
+}
+
+func copyConfig(config map[string]string) map[string]string {
+	if config == nil {
+		return nil
+	}
+	c := make(map[string]string, len(config))
+	for k, v := range config {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,206 @@
+package ocitest
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/fnerrors"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/pager"
+	"github.com/fnproject/fn_go/provider"
+	"github.com/fnproject/fn_go/provider/oracle"
+	"github.com/fnproject/fn_go/provider/oracle/shim"
+	"github.com/oracle/oci-go-sdk/v65/functions"
+	"github.com/stretchr/testify/assert"
+)
+
+const compartmentID = "ocid1.compartment.oc1..ocitest"
+
+var subnets = map[string]interface{}{"oracle.com/oci/subnetIds": []interface{}{"ocid1.subnet.oc1..ocitest"}}
+
+func TestLifecycleTransitions(t *testing.T) {
+	c := NewClient()
+	c.TransitionDelay = 50 * time.Millisecond
+	ctx := context.Background()
+
+	name := "myapp"
+	created, err := c.CreateApplication(ctx, functions.CreateApplicationRequest{CreateApplicationDetails: functions.CreateApplicationDetails{
+		CompartmentId: &[]string{compartmentID}[0],
+		DisplayName:   &name,
+		SubnetIds:     []string{"subnet"},
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, functions.ApplicationLifecycleStateCreating, created.LifecycleState)
+
+	_, err = c.CreateFunction(ctx, functions.CreateFunctionRequest{CreateFunctionDetails: functions.CreateFunctionDetails{
+		DisplayName:   &name,
+		ApplicationId: created.Id,
+		Image:         &name,
+		MemoryInMBs:   &[]int64{128}[0],
+	}})
+	assert.Equal(t, http.StatusConflict, fnerrors.StatusCode(fnerrors.Wrap(err, "")), "functions can't be created in a CREATING app")
+
+	time.Sleep(c.TransitionDelay)
+	got, err := c.GetApplication(ctx, functions.GetApplicationRequest{ApplicationId: created.Id})
+	if assert.NoError(t, err) {
+		assert.Equal(t, functions.ApplicationLifecycleStateActive, got.LifecycleState)
+	}
+
+	_, err = c.DeleteApplication(ctx, functions.DeleteApplicationRequest{ApplicationId: created.Id})
+	assert.NoError(t, err)
+	got, err = c.GetApplication(ctx, functions.GetApplicationRequest{ApplicationId: created.Id})
+	if assert.NoError(t, err) {
+		assert.Equal(t, functions.ApplicationLifecycleStateDeleting, got.LifecycleState)
+	}
+
+	time.Sleep(c.TransitionDelay)
+	_, err = c.GetApplication(ctx, functions.GetApplicationRequest{ApplicationId: created.Id})
+	assert.True(t, fnerrors.IsNotFound(fnerrors.Wrap(err, "")))
+}
+
+func TestShimsAgainstClient(t *testing.T) {
+	c := NewClient()
+	appsShim := shim.NewAppsShim(c, compartmentID)
+	fnsShim := shim.NewFnsShim(c)
+
+	app, err := appsShim.CreateApp(apps.NewCreateAppParams().WithBody(&modelsv2.App{Name: "myapp", Annotations: subnets}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	fn, err := fnsShim.CreateFn(fns.NewCreateFnParams().WithBody(&modelsv2.Fn{
+		AppID:  app.Payload.ID,
+		Name:   "hello",
+		Image:  "fnproject/hello",
+		Config: map[string]string{"A": "1"},
+	}))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// the shim merges config with a get and an IfMatch guarded update
+	updated, err := fnsShim.UpdateFn(fns.NewUpdateFnParams().WithFnID(fn.Payload.ID).WithBody(&modelsv2.Fn{Config: map[string]string{"B": "2"}}))
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]string{"A": "1", "B": "2"}, updated.Payload.Config)
+	}
+
+	_, err = c.UpdateFunction(context.Background(), functions.UpdateFunctionRequest{FunctionId: &fn.Payload.ID, IfMatch: &[]string{"1"}[0]})
+	assert.Equal(t, http.StatusPreconditionFailed, fnerrors.StatusCode(fnerrors.Wrap(err, "")))
+
+	_, err = appsShim.DeleteApp(apps.NewDeleteAppParams().WithAppID(app.Payload.ID))
+	assert.True(t, fnerrors.IsConflict(err), "apps with functions can't be deleted")
+}
+
+func TestPaginationAndFiltering(t *testing.T) {
+	c := NewClient()
+	appsShim := shim.NewAppsShim(c, compartmentID)
+	for i := 0; i < 5; i++ {
+		_, err := appsShim.CreateApp(apps.NewCreateAppParams().WithBody(&modelsv2.App{Name: fmt.Sprintf("app%d", i), Annotations: subnets}))
+		assert.NoError(t, err)
+	}
+
+	res, err := appsShim.ListApps(apps.NewListAppsParams().WithPerPage(&[]int64{2}[0]))
+	if assert.NoError(t, err) {
+		assert.Len(t, res.Payload.Items, 2)
+		assert.NotEmpty(t, res.Payload.NextCursor)
+	}
+
+	name := "app3"
+	res, err = appsShim.ListApps(apps.NewListAppsParams().WithName(&name))
+	if assert.NoError(t, err) && assert.Len(t, res.Payload.Items, 1) {
+		assert.Equal(t, name, res.Payload.Items[0].Name)
+	}
+}
+
+// writeOCIConfig writes an OCI config file with a throwaway key, the server does not check signatures
+func writeOCIConfig(t *testing.T) string {
+	dir := t.TempDir()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "key.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	configFile := filepath.Join(dir, "config")
+	config := fmt.Sprintf("[DEFAULT]\nuser=ocid1.user.oc1..ocitest\nfingerprint=00:11:22:33\ntenancy=ocid1.tenancy.oc1..ocitest\nregion=us-ashburn-1\nkey_file=%s\n", keyFile)
+	if err := ioutil.WriteFile(configFile, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return configFile
+}
+
+func TestProviderAgainstServer(t *testing.T) {
+	srv := NewServer(nil)
+	defer srv.Close()
+
+	for _, env := range []string{"OCI_CLI_PROFILE", "OCI_CLI_TENANCY", "OCI_CLI_USER", "OCI_CLI_FINGERPRINT", "OCI_CLI_KEY_FILE"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("OCI_CLI_CONFIG_FILE", writeOCIConfig(t))
+
+	p, err := oracle.NewFromConfig(provider.NewConfigSourceFromMap(map[string]string{
+		provider.CfgFnAPIURL:    srv.URL(),
+		oracle.CfgCompartmentID: compartmentID,
+	}), nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	client := p.APIClientv2()
+
+	app, err := client.Apps.CreateApp(apps.NewCreateAppParams().WithBody(&modelsv2.App{Name: "myapp", Annotations: subnets}))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for i := 0; i < 3; i++ {
+		_, err := client.Fns.CreateFn(fns.NewCreateFnParams().WithBody(&modelsv2.Fn{AppID: app.Payload.ID, Name: fmt.Sprintf("fn%d", i), Image: "fnproject/hello"}))
+		assert.NoError(t, err)
+	}
+
+	all, err := pager.Fns(context.Background(), client, fns.NewListFnsParams().WithAppID(&app.Payload.ID), pager.Options{PageSize: 2}).All()
+	if assert.NoError(t, err) && assert.Len(t, all, 3) {
+		endpoint, err := provider.InvokeEndpoint(all[0])
+		assert.NoError(t, err)
+		assert.Contains(t, endpoint, srv.URL())
+	}
+
+	_, err = client.Apps.GetApp(apps.NewGetAppParams().WithAppID("ocid1.fnapp.oc1.ocitest.missing"))
+	assert.True(t, fnerrors.IsNotFound(err))
+
+	listingName := "translate"
+	listingID := srv.Client.AddPbfListing(functions.PbfListing{Name: &listingName})
+	versionName := "1.0.0"
+	versionID := srv.Client.AddPbfListingVersion(functions.PbfListingVersion{PbfListingId: &listingID, Name: &versionName})
+
+	catalog := p.(*oracle.OracleProvider).PbfCatalog()
+	listings, err := catalog.ListListings(context.Background(), &shim.PbfListingFilter{NameContains: "TRANS"})
+	if assert.NoError(t, err) && assert.Len(t, listings, 1) {
+		assert.Equal(t, listingID, listings[0].ID)
+	}
+
+	pbf := &modelsv2.Fn{AppID: app.Payload.ID, Name: "pbf"}
+	if !assert.NoError(t, catalog.PrepareFn(context.Background(), versionID, pbf)) {
+		return
+	}
+	created, err := client.Fns.CreateFn(fns.NewCreateFnParams().WithBody(pbf))
+	if assert.NoError(t, err) {
+		assert.Empty(t, created.Payload.Image)
+		assert.Equal(t, listingID, created.Payload.Annotations[shim.AnnotationPbfListingId])
+	}
+}
@@ -0,0 +1,356 @@
+package ocitest
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v65/functions"
+)
+
+type pbfListing struct {
+	functions.PbfListing
+	seq int
+	// current is the ID of the most recently added version
+	current string
+}
+
+type pbfListingVersion struct {
+	functions.PbfListingVersion
+	seq int
+}
+
+// AddPbfListing adds a pre-built function listing to the catalog and returns its ID. Fields left empty in listing are
+// filled in with an ID, timestamps and the ACTIVE lifecycle state.
+func (c *Client) AddPbfListing(listing functions.PbfListing) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if listing.Id == nil {
+		id := c.newID("pbflisting")
+		listing.Id = &id
+	}
+	if listing.Description == nil {
+		description := ""
+		listing.Description = &description
+	}
+	if listing.PublisherDetails == nil {
+		publisher := "Oracle"
+		listing.PublisherDetails = &functions.PublisherDetails{Name: &publisher}
+	}
+	if listing.TimeCreated == nil {
+		listing.TimeCreated = now()
+	}
+	if listing.TimeUpdated == nil {
+		listing.TimeUpdated = listing.TimeCreated
+	}
+	if listing.LifecycleState == "" {
+		listing.LifecycleState = functions.PbfListingLifecycleStateActive
+	}
+	c.pbfListings[*listing.Id] = &pbfListing{PbfListing: listing, seq: c.nextSeq()}
+	return *listing.Id
+}
+
+// AddPbfListingVersion adds a version to the listing identified by version.PbfListingId and returns its ID. The most
+// recently added version of a listing is its current version, which is the one deployed by functions created from the
+// listing. Empty fields are filled in as for AddPbfListing, and the listing's triggers are copied onto the version if it
+// has none of its own.
+func (c *Client) AddPbfListingVersion(version functions.PbfListingVersion) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if version.PbfListingId == nil {
+		panic("ocitest: PbfListingId is required")
+	}
+	listing, ok := c.pbfListings[*version.PbfListingId]
+	if !ok {
+		panic("ocitest: unknown PBF listing " + *version.PbfListingId)
+	}
+
+	if version.Id == nil {
+		id := c.newID("pbflistingversion")
+		version.Id = &id
+	}
+	if version.ChangeSummary == nil {
+		summary := ""
+		version.ChangeSummary = &summary
+	}
+	if version.Requirements == nil {
+		memory := int64(128)
+		version.Requirements = &functions.RequirementDetails{MinMemoryRequiredInMBs: &memory}
+	}
+	if version.Triggers == nil {
+		version.Triggers = listing.Triggers
+	}
+	if version.TimeCreated == nil {
+		version.TimeCreated = now()
+	}
+	if version.TimeUpdated == nil {
+		version.TimeUpdated = version.TimeCreated
+	}
+	if version.LifecycleState == "" {
+		version.LifecycleState = functions.PbfListingVersionLifecycleStateActive
+	}
+	c.pbfVersions[*version.Id] = &pbfListingVersion{PbfListingVersion: version, seq: c.nextSeq()}
+	listing.current = *version.Id
+	return *version.Id
+}
+
+func (c *Client) GetPbfListing(ctx context.Context, request functions.GetPbfListingRequest) (functions.GetPbfListingResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rid := requestID(request.OpcRequestId)
+	listing, err := c.getPbfListing(request.PbfListingId, rid)
+	if err != nil {
+		return functions.GetPbfListingResponse{}, err
+	}
+	return functions.GetPbfListingResponse{
+		PbfListing:   listing.PbfListing,
+		Etag:         etag(1),
+		OpcRequestId: rid,
+	}, nil
+}
+
+func (c *Client) getPbfListing(id *string, rid *string) (*pbfListing, error) {
+	if id == nil {
+		return nil, serviceError(http.StatusBadRequest, "MissingParameter", rid, "pbfListingId is required")
+	}
+	listing, ok := c.pbfListings[*id]
+	if !ok {
+		return nil, notFound(rid, "PbfListing", *id)
+	}
+	return listing, nil
+}
+
+func (c *Client) ListPbfListings(ctx context.Context, request functions.ListPbfListingsRequest) (functions.ListPbfListingsResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rid := requestID(request.OpcRequestId)
+	var matches []*pbfListing
+	for _, listing := range c.pbfListings {
+		name := *listing.Name
+		if (request.PbfListingId != nil && *request.PbfListingId != *listing.Id) ||
+			(request.Name != nil && *request.Name != name) ||
+			(request.NameContains != nil && !strings.Contains(strings.ToLower(name), strings.ToLower(*request.NameContains))) ||
+			(request.NameStartsWith != nil && !strings.HasPrefix(strings.ToLower(name), strings.ToLower(*request.NameStartsWith))) ||
+			(request.LifecycleState != "" && request.LifecycleState != listing.LifecycleState) ||
+			!hasTriggers(listing.Triggers, request.Trigger) {
+			continue
+		}
+		matches = append(matches, listing)
+	}
+	byName := request.SortBy == functions.ListPbfListingsSortByName
+	sort.Slice(matches, func(i, j int) bool {
+		if byName && *matches[i].Name != *matches[j].Name {
+			return *matches[i].Name < *matches[j].Name
+		}
+		return matches[i].seq < matches[j].seq
+	})
+	if request.SortOrder == functions.ListPbfListingsSortOrderDesc {
+		for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+			matches[i], matches[j] = matches[j], matches[i]
+		}
+	}
+
+	start, end, nextPage, err := page(len(matches), request.Limit, request.Page, rid)
+	if err != nil {
+		return functions.ListPbfListingsResponse{}, err
+	}
+
+	items := []functions.PbfListingSummary{}
+	for _, listing := range matches[start:end] {
+		items = append(items, listing.summary())
+	}
+	return functions.ListPbfListingsResponse{
+		PbfListingsCollection: functions.PbfListingsCollection{Items: items},
+		OpcNextPage:           nextPage,
+		OpcRequestId:          rid,
+	}, nil
+}
+
+func (c *Client) GetPbfListingVersion(ctx context.Context, request functions.GetPbfListingVersionRequest) (functions.GetPbfListingVersionResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rid := requestID(request.OpcRequestId)
+	if request.PbfListingVersionId == nil {
+		return functions.GetPbfListingVersionResponse{}, serviceError(http.StatusBadRequest, "MissingParameter", rid, "pbfListingVersionId is required")
+	}
+	version, ok := c.pbfVersions[*request.PbfListingVersionId]
+	if !ok {
+		return functions.GetPbfListingVersionResponse{}, notFound(rid, "PbfListingVersion", *request.PbfListingVersionId)
+	}
+	return functions.GetPbfListingVersionResponse{
+		PbfListingVersion: version.PbfListingVersion,
+		Etag:              etag(1),
+		OpcRequestId:      rid,
+	}, nil
+}
+
+func (c *Client) ListPbfListingVersions(ctx context.Context, request functions.ListPbfListingVersionsRequest) (functions.ListPbfListingVersionsResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rid := requestID(request.OpcRequestId)
+	listing, err := c.getPbfListing(request.PbfListingId, rid)
+	if err != nil {
+		return functions.ListPbfListingVersionsResponse{}, err
+	}
+
+	var matches []*pbfListingVersion
+	for _, version := range c.pbfVersions {
+		if *version.PbfListingId != *listing.Id ||
+			(request.PbfListingVersionId != nil && *request.PbfListingVersionId != *version.Id) ||
+			(request.Name != nil && *request.Name != *version.Name) ||
+			(request.IsCurrentVersion != nil && *request.IsCurrentVersion != (*version.Id == listing.current)) ||
+			(request.LifecycleState != "" && request.LifecycleState != version.LifecycleState) {
+			continue
+		}
+		matches = append(matches, version)
+	}
+	byName := request.SortBy == functions.ListPbfListingVersionsSortByName
+	sort.Slice(matches, func(i, j int) bool {
+		if byName && *matches[i].Name != *matches[j].Name {
+			return *matches[i].Name < *matches[j].Name
+		}
+		return matches[i].seq < matches[j].seq
+	})
+	if request.SortOrder == functions.ListPbfListingVersionsSortOrderDesc {
+		for i, j := 0, len(matches)-1; i < j; i, j = i+1, j-1 {
+			matches[i], matches[j] = matches[j], matches[i]
+		}
+	}
+
+	start, end, nextPage, err := page(len(matches), request.Limit, request.Page, rid)
+	if err != nil {
+		return functions.ListPbfListingVersionsResponse{}, err
+	}
+
+	items := []functions.PbfListingVersionSummary{}
+	for _, version := range matches[start:end] {
+		items = append(items, version.summary())
+	}
+	return functions.ListPbfListingVersionsResponse{
+		PbfListingVersionsCollection: functions.PbfListingVersionsCollection{Items: items},
+		OpcNextPage:                  nextPage,
+		OpcRequestId:                 rid,
+	}, nil
+}
+
+// ListTriggers lists the triggers supported by the listings in the catalog, ordered by name
+func (c *Client) ListTriggers(ctx context.Context, request functions.ListTriggersRequest) (functions.ListTriggersResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rid := requestID(request.OpcRequestId)
+	names := map[string]bool{}
+	for _, listing := range c.pbfListings {
+		for _, t := range listing.Triggers {
+			if t.Name != nil && (request.Name == nil || *request.Name == *t.Name) {
+				names[*t.Name] = true
+			}
+		}
+	}
+	var sorted []string
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	if request.SortOrder == functions.ListTriggersSortOrderDesc {
+		sort.Sort(sort.Reverse(sort.StringSlice(sorted)))
+	}
+
+	start, end, nextPage, err := page(len(sorted), request.Limit, request.Page, rid)
+	if err != nil {
+		return functions.ListTriggersResponse{}, err
+	}
+
+	items := []functions.TriggerSummary{}
+	for i := range sorted[start:end] {
+		items = append(items, functions.TriggerSummary{Name: &sorted[start+i]})
+	}
+	return functions.ListTriggersResponse{
+		TriggersCollection: functions.TriggersCollection{Items: items},
+		OpcNextPage:        nextPage,
+		OpcRequestId:       rid,
+	}, nil
+}
+
+// checkPbfSource validates the source of a function being created from a pre-built function listing
+func (c *Client) checkPbfSource(source functions.FunctionSourceDetails, memory int64, rid *string) error {
+	var listingID *string
+	switch s := source.(type) {
+	case functions.PreBuiltFunctionSourceDetails:
+		listingID = s.PbfListingId
+	case *functions.PreBuiltFunctionSourceDetails:
+		listingID = s.PbfListingId
+	default:
+		return serviceError(http.StatusBadRequest, "InvalidParameter", rid, "unsupported sourceDetails %T", source)
+	}
+
+	listing, err := c.getPbfListing(listingID, rid)
+	if err != nil {
+		return err
+	}
+	if listing.LifecycleState != functions.PbfListingLifecycleStateActive || listing.current == "" {
+		return serviceError(http.StatusConflict, "IncorrectState", rid, "PbfListing %s cannot be deployed", *listing.Id)
+	}
+	required := c.pbfVersions[listing.current].Requirements.MinMemoryRequiredInMBs
+	if required != nil && memory < *required {
+		return serviceError(http.StatusBadRequest, "InvalidParameter", rid, "PbfListing %s requires at least %d MB of memory", *listing.Id, *required)
+	}
+	return nil
+}
+
+func hasTriggers(triggers []functions.Trigger, names []string) bool {
+	for _, name := range names {
+		found := false
+		for _, t := range triggers {
+			if t.Name != nil && strings.EqualFold(*t.Name, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (listing *pbfListing) summary() functions.PbfListingSummary {
+	return functions.PbfListingSummary{
+		Id:               listing.Id,
+		Name:             listing.Name,
+		Description:      listing.Description,
+		PublisherDetails: listing.PublisherDetails,
+		TimeCreated:      listing.TimeCreated,
+		TimeUpdated:      listing.TimeUpdated,
+		LifecycleState:   listing.LifecycleState,
+		Triggers:         listing.Triggers,
+		FreeformTags:     listing.FreeformTags,
+		DefinedTags:      listing.DefinedTags,
+		SystemTags:       listing.SystemTags,
+	}
+}
+
+func (version *pbfListingVersion) summary() functions.PbfListingVersionSummary {
+	return functions.PbfListingVersionSummary{
+		Id:             version.Id,
+		PbfListingId:   version.PbfListingId,
+		Name:           version.Name,
+		Requirements:   version.Requirements,
+		ChangeSummary:  version.ChangeSummary,
+		Triggers:       version.Triggers,
+		TimeCreated:    version.TimeCreated,
+		TimeUpdated:    version.TimeUpdated,
+		LifecycleState: version.LifecycleState,
+		Config:         version.Config,
+		FreeformTags:   version.FreeformTags,
+		DefinedTags:    version.DefinedTags,
+		SystemTags:     version.SystemTags,
+	}
+}
@@ -0,0 +1,400 @@
+package ocitest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"github.com/oracle/oci-go-sdk/v65/functions"
+)
+
+const apiVersionPath = "/20181201"
+
+// Server serves a Client's state over the OCI Functions management REST API. Request signatures are not checked, so
+// any credentials can be used to talk to it.
+type Server struct {
+	// Client holds the state served by the server
+	Client *Client
+
+	srv *httptest.Server
+}
+
+// NewServer starts serving c, or a new empty client if c is nil. Functions report the server as their invoke endpoint
+// unless c already has one set. Callers must call Close when done.
+func NewServer(c *Client) *Server {
+	if c == nil {
+		c = NewClient()
+	}
+	s := &Server{Client: c}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	if c.InvokeEndpoint == "" || c.InvokeEndpoint == DefaultInvokeEndpoint {
+		c.InvokeEndpoint = s.srv.URL
+	}
+	return s
+}
+
+// URL is the base URL of the server, suitable for use as the api-url of the oracle provider
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Close shuts down the server
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, apiVersionPath)
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if !strings.HasPrefix(r.URL.Path, apiVersionPath+"/") {
+		writeError(w, &Error{StatusCode: http.StatusNotFound, Code: "NotFound", Message: "Not found"})
+		return
+	}
+	if len(parts) == 4 && parts[0] == "applications" && parts[2] == "actions" && parts[3] == "changeCompartment" && r.Method == http.MethodPost {
+		if err := s.changeApplicationCompartment(w, r, parts[1]); err != nil {
+			writeError(w, err)
+		}
+		return
+	}
+	if len(parts) > 2 {
+		writeError(w, &Error{StatusCode: http.StatusNotFound, Code: "NotFound", Message: "Not found"})
+		return
+	}
+
+	var id *string
+	if len(parts) == 2 {
+		id = &parts[1]
+	}
+
+	var err error
+	switch parts[0] {
+	case "applications":
+		err = s.serveApplications(w, r, id)
+	case "functions":
+		err = s.serveFunctions(w, r, id)
+	case "pbfListings":
+		err = s.servePbfListings(w, r, id)
+	case "pbfListingVersions":
+		err = s.servePbfListingVersions(w, r, id)
+	default:
+		err = &Error{StatusCode: http.StatusNotFound, Code: "NotFound", Message: "Not found"}
+	}
+	if err != nil {
+		writeError(w, err)
+	}
+}
+
+func (s *Server) serveApplications(w http.ResponseWriter, r *http.Request, id *string) error {
+	ctx := r.Context()
+	rid := header(r, "opc-request-id")
+	ifMatch := header(r, "if-match")
+	query := r.URL.Query()
+
+	switch {
+	case id == nil && r.Method == http.MethodGet:
+		limit, err := intParam(query.Get("limit"))
+		if err != nil {
+			return err
+		}
+		res, err := s.Client.ListApplications(ctx, functions.ListApplicationsRequest{
+			CompartmentId:  param(query.Get("compartmentId")),
+			Limit:          limit,
+			Page:           param(query.Get("page")),
+			OpcRequestId:   rid,
+			LifecycleState: functions.ApplicationLifecycleStateEnum(query.Get("lifecycleState")),
+			DisplayName:    param(query.Get("displayName")),
+			Id:             param(query.Get("id")),
+			SortOrder:      functions.ListApplicationsSortOrderEnum(query.Get("sortOrder")),
+			SortBy:         functions.ListApplicationsSortByEnum(query.Get("sortBy")),
+		})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, res.Items, nil, res.OpcRequestId, res.OpcNextPage)
+	case id == nil && r.Method == http.MethodPost:
+		var details functions.CreateApplicationDetails
+		if err := decode(r, &details); err != nil {
+			return err
+		}
+		res, err := s.Client.CreateApplication(ctx, functions.CreateApplicationRequest{CreateApplicationDetails: details, OpcRequestId: rid})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, res.Application, res.Etag, res.OpcRequestId, nil)
+	case id != nil && r.Method == http.MethodGet:
+		res, err := s.Client.GetApplication(ctx, functions.GetApplicationRequest{ApplicationId: id, OpcRequestId: rid})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, res.Application, res.Etag, res.OpcRequestId, nil)
+	case id != nil && r.Method == http.MethodPut:
+		var details functions.UpdateApplicationDetails
+		if err := decode(r, &details); err != nil {
+			return err
+		}
+		res, err := s.Client.UpdateApplication(ctx, functions.UpdateApplicationRequest{ApplicationId: id, UpdateApplicationDetails: details, IfMatch: ifMatch, OpcRequestId: rid})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, res.Application, res.Etag, res.OpcRequestId, nil)
+	case id != nil && r.Method == http.MethodDelete:
+		res, err := s.Client.DeleteApplication(ctx, functions.DeleteApplicationRequest{ApplicationId: id, IfMatch: ifMatch, OpcRequestId: rid})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusNoContent, nil, nil, res.OpcRequestId, nil)
+	default:
+		return &Error{StatusCode: http.StatusMethodNotAllowed, Code: "MethodNotAllowed", Message: "Method not allowed"}
+	}
+	return nil
+}
+
+func (s *Server) changeApplicationCompartment(w http.ResponseWriter, r *http.Request, id string) error {
+	var details functions.ChangeApplicationCompartmentDetails
+	if err := decode(r, &details); err != nil {
+		return err
+	}
+	res, err := s.Client.ChangeApplicationCompartment(r.Context(), functions.ChangeApplicationCompartmentRequest{
+		ApplicationId:                       &id,
+		ChangeApplicationCompartmentDetails: details,
+		IfMatch:                             header(r, "if-match"),
+		OpcRequestId:                        header(r, "opc-request-id"),
+	})
+	if err != nil {
+		return err
+	}
+	writeJSON(w, http.StatusOK, nil, nil, res.OpcRequestId, nil)
+	return nil
+}
+
+func (s *Server) serveFunctions(w http.ResponseWriter, r *http.Request, id *string) error {
+	ctx := r.Context()
+	rid := header(r, "opc-request-id")
+	ifMatch := header(r, "if-match")
+	query := r.URL.Query()
+
+	switch {
+	case id == nil && r.Method == http.MethodGet:
+		limit, err := intParam(query.Get("limit"))
+		if err != nil {
+			return err
+		}
+		res, err := s.Client.ListFunctions(ctx, functions.ListFunctionsRequest{
+			ApplicationId:  param(query.Get("applicationId")),
+			Limit:          limit,
+			Page:           param(query.Get("page")),
+			OpcRequestId:   rid,
+			LifecycleState: functions.FunctionLifecycleStateEnum(query.Get("lifecycleState")),
+			DisplayName:    param(query.Get("displayName")),
+			Id:             param(query.Get("id")),
+			SortOrder:      functions.ListFunctionsSortOrderEnum(query.Get("sortOrder")),
+			SortBy:         functions.ListFunctionsSortByEnum(query.Get("sortBy")),
+		})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, res.Items, nil, res.OpcRequestId, res.OpcNextPage)
+	case id == nil && r.Method == http.MethodPost:
+		var details functions.CreateFunctionDetails
+		if err := decode(r, &details); err != nil {
+			return err
+		}
+		res, err := s.Client.CreateFunction(ctx, functions.CreateFunctionRequest{CreateFunctionDetails: details, OpcRequestId: rid})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, res.Function, res.Etag, res.OpcRequestId, nil)
+	case id != nil && r.Method == http.MethodGet:
+		res, err := s.Client.GetFunction(ctx, functions.GetFunctionRequest{FunctionId: id, OpcRequestId: rid})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, res.Function, res.Etag, res.OpcRequestId, nil)
+	case id != nil && r.Method == http.MethodPut:
+		var details functions.UpdateFunctionDetails
+		if err := decode(r, &details); err != nil {
+			return err
+		}
+		res, err := s.Client.UpdateFunction(ctx, functions.UpdateFunctionRequest{FunctionId: id, UpdateFunctionDetails: details, IfMatch: ifMatch, OpcRequestId: rid})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, res.Function, res.Etag, res.OpcRequestId, nil)
+	case id != nil && r.Method == http.MethodDelete:
+		res, err := s.Client.DeleteFunction(ctx, functions.DeleteFunctionRequest{FunctionId: id, IfMatch: ifMatch, OpcRequestId: rid})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusNoContent, nil, nil, res.OpcRequestId, nil)
+	default:
+		return &Error{StatusCode: http.StatusMethodNotAllowed, Code: "MethodNotAllowed", Message: "Method not allowed"}
+	}
+	return nil
+}
+
+func (s *Server) servePbfListings(w http.ResponseWriter, r *http.Request, id *string) error {
+	ctx := r.Context()
+	rid := header(r, "opc-request-id")
+	query := r.URL.Query()
+
+	switch {
+	case id != nil && *id == "triggers" && r.Method == http.MethodGet:
+		limit, err := intParam(query.Get("limit"))
+		if err != nil {
+			return err
+		}
+		res, err := s.Client.ListTriggers(ctx, functions.ListTriggersRequest{
+			Name:         param(query.Get("name")),
+			Limit:        limit,
+			Page:         param(query.Get("page")),
+			SortOrder:    functions.ListTriggersSortOrderEnum(query.Get("sortOrder")),
+			OpcRequestId: rid,
+		})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, res.TriggersCollection, nil, res.OpcRequestId, res.OpcNextPage)
+	case id == nil && r.Method == http.MethodGet:
+		limit, err := intParam(query.Get("limit"))
+		if err != nil {
+			return err
+		}
+		res, err := s.Client.ListPbfListings(ctx, functions.ListPbfListingsRequest{
+			PbfListingId:   param(query.Get("pbfListingId")),
+			Name:           param(query.Get("name")),
+			NameContains:   param(query.Get("nameContains")),
+			NameStartsWith: param(query.Get("nameStartsWith")),
+			Trigger:        query["trigger"],
+			LifecycleState: functions.PbfListingLifecycleStateEnum(query.Get("lifecycleState")),
+			Limit:          limit,
+			Page:           param(query.Get("page")),
+			SortOrder:      functions.ListPbfListingsSortOrderEnum(query.Get("sortOrder")),
+			SortBy:         functions.ListPbfListingsSortByEnum(query.Get("sortBy")),
+			OpcRequestId:   rid,
+		})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, res.PbfListingsCollection, nil, res.OpcRequestId, res.OpcNextPage)
+	case id != nil && r.Method == http.MethodGet:
+		res, err := s.Client.GetPbfListing(ctx, functions.GetPbfListingRequest{PbfListingId: id, OpcRequestId: rid})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, res.PbfListing, res.Etag, res.OpcRequestId, nil)
+	default:
+		return &Error{StatusCode: http.StatusMethodNotAllowed, Code: "MethodNotAllowed", Message: "Method not allowed"}
+	}
+	return nil
+}
+
+func (s *Server) servePbfListingVersions(w http.ResponseWriter, r *http.Request, id *string) error {
+	ctx := r.Context()
+	rid := header(r, "opc-request-id")
+	query := r.URL.Query()
+
+	switch {
+	case id == nil && r.Method == http.MethodGet:
+		limit, err := intParam(query.Get("limit"))
+		if err != nil {
+			return err
+		}
+		var isCurrentVersion *bool
+		if value := query.Get("isCurrentVersion"); value != "" {
+			current, err := strconv.ParseBool(value)
+			if err != nil {
+				return &Error{StatusCode: http.StatusBadRequest, Code: "InvalidParameter", Message: "invalid isCurrentVersion " + value}
+			}
+			isCurrentVersion = &current
+		}
+		res, err := s.Client.ListPbfListingVersions(ctx, functions.ListPbfListingVersionsRequest{
+			PbfListingId:        param(query.Get("pbfListingId")),
+			PbfListingVersionId: param(query.Get("pbfListingVersionId")),
+			Name:                param(query.Get("name")),
+			IsCurrentVersion:    isCurrentVersion,
+			LifecycleState:      functions.PbfListingVersionLifecycleStateEnum(query.Get("lifecycleState")),
+			Limit:               limit,
+			Page:                param(query.Get("page")),
+			SortOrder:           functions.ListPbfListingVersionsSortOrderEnum(query.Get("sortOrder")),
+			SortBy:              functions.ListPbfListingVersionsSortByEnum(query.Get("sortBy")),
+			OpcRequestId:        rid,
+		})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, res.PbfListingVersionsCollection, nil, res.OpcRequestId, res.OpcNextPage)
+	case id != nil && r.Method == http.MethodGet:
+		res, err := s.Client.GetPbfListingVersion(ctx, functions.GetPbfListingVersionRequest{PbfListingVersionId: id, OpcRequestId: rid})
+		if err != nil {
+			return err
+		}
+		writeJSON(w, http.StatusOK, res.PbfListingVersion, res.Etag, res.OpcRequestId, nil)
+	default:
+		return &Error{StatusCode: http.StatusMethodNotAllowed, Code: "MethodNotAllowed", Message: "Method not allowed"}
+	}
+	return nil
+}
+
+func header(r *http.Request, name string) *string {
+	return param(r.Header.Get(name))
+}
+
+func param(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func intParam(value string) (*int, error) {
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, &Error{StatusCode: http.StatusBadRequest, Code: "InvalidParameter", Message: "invalid limit " + value}
+	}
+	return &n, nil
+}
+
+func decode(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return &Error{StatusCode: http.StatusBadRequest, Code: "InvalidParameter", Message: "invalid request body: " + err.Error()}
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}, etag, opcRequestID, opcNextPage *string) {
+	if etag != nil {
+		w.Header().Set("etag", *etag)
+	}
+	if opcRequestID != nil {
+		w.Header().Set("opc-request-id", *opcRequestID)
+	}
+	if opcNextPage != nil {
+		w.Header().Set("opc-next-page", *opcNextPage)
+	}
+	if body == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var serviceErr *Error
+	if !errors.As(err, &serviceErr) {
+		serviceErr = &Error{StatusCode: http.StatusInternalServerError, Code: "InternalServerError", Message: err.Error()}
+	}
+	if serviceErr.OpcRequestID != "" {
+		w.Header().Set("opc-request-id", serviceErr.OpcRequestID)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(serviceErr.StatusCode)
+	json.NewEncoder(w).Encode(map[string]string{"code": serviceErr.Code, "message": serviceErr.Message})
+}
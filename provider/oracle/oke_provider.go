@@ -0,0 +1,355 @@
+package oracle
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+
+	"github.com/fnproject/fn_go/provider"
+)
+
+const (
+	// CfgOKETokenFile is the projected service account token that the pod authenticates with, it defaults to the
+	// token that Kubernetes mounts into every pod
+	CfgOKETokenFile = "oracle.oke-token-file"
+	// CfgOKECAFile is the CA bundle used to verify the OKE workload identity endpoint
+	CfgOKECAFile = "oracle.oke-ca-file"
+	// CfgOKEProxymuxURL overrides the OKE workload identity endpoint, which is normally found on the Kubernetes API host
+	CfgOKEProxymuxURL = "oracle.oke-proxymux-url"
+
+	userAgentPrefixOKE = "fn_go-oracle-oke"
+
+	okeProxymuxPath    = "/resourcePrincipalSessionTokens"
+	okeProxymuxTimeout = 30 * time.Second
+)
+
+// NewOKEProvider creates an "oracle-oke" provider, which authenticates as the OKE workload identity of the Kubernetes
+// pod that it runs in. The pod's service account token is exchanged for a resource principal session token, and the
+// token file is re-read each time the session is renewed so that rotated projected tokens are picked up.
+// oracle.compartment-id defaults to the compartment of the workload identity when the session token names one.
+func NewOKEProvider(configSource provider.ConfigSource, passphraseSource provider.PassPhraseSource) (provider.Provider, error) {
+	configProvider, err := newWorkloadIdentityConfigurationProvider(configSource, os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	return newRPProvider(configSource, configProvider, userAgentPrefixOKE)
+}
+
+// workloadIdentityConfigurationProvider signs requests with a resource principal session token (RPST) that is issued
+// to the pod by the OKE proxymux service in exchange for its service account token. A new session key is generated
+// for each session, and sessions are renewed halfway through their lifetime.
+type workloadIdentityConfigurationProvider struct {
+	region      string
+	proxymuxURL string
+	tokenFile   *provider.TokenFile
+	client      *http.Client
+	now         func() time.Time
+
+	mu      sync.Mutex
+	session *workloadIdentitySession
+	// renewing is closed when the renewal in progress finishes, it is nil when there is none
+	renewing chan struct{}
+}
+
+// workloadIdentitySession is a session key along with the token issued for it, it is never modified so that a request
+// is always signed with a key and token from the same session
+type workloadIdentitySession struct {
+	key      *rsa.PrivateKey
+	rpst     string
+	claims   map[string]interface{}
+	expiry   time.Time
+	renewsAt time.Time
+}
+
+func (s *workloadIdentitySession) PrivateRSAKey() (*rsa.PrivateKey, error) {
+	return s.key, nil
+}
+
+// KeyID returns the session token in the form that OCI expects in place of a tenancy/user/fingerprint key ID
+func (s *workloadIdentitySession) KeyID() (string, error) {
+	return "ST$" + s.rpst, nil
+}
+
+// workloadIdentitySigner signs each request with a single session, which the SDK's signer can't guarantee as it asks
+// the configuration provider for the key and the key ID separately
+type workloadIdentitySigner struct {
+	provider *workloadIdentityConfigurationProvider
+}
+
+func (s workloadIdentitySigner) Sign(request *http.Request) error {
+	session, err := s.provider.currentSession()
+	if err != nil {
+		return err
+	}
+	return common.DefaultRequestSigner(session).Sign(request)
+}
+
+func newWorkloadIdentityConfigurationProvider(configSource provider.ConfigSource, getenv func(string) string) (*workloadIdentityConfigurationProvider, error) {
+	region := getenv(auth.ResourcePrincipalRegionEnvVar)
+	if region == "" {
+		return nil, fmt.Errorf("%s must be set to the region of the cluster", auth.ResourcePrincipalRegionEnvVar)
+	}
+
+	proxymuxURL := configSource.GetString(CfgOKEProxymuxURL)
+	if proxymuxURL == "" {
+		host := getenv(auth.KubernetesServiceHostEnvVar)
+		if host == "" {
+			return nil, fmt.Errorf("%s is not set, and no workload identity endpoint is configured in %s", auth.KubernetesServiceHostEnvVar, CfgOKEProxymuxURL)
+		}
+		proxymuxURL = "https://" + net.JoinHostPort(host, auth.KubernetesProxymuxServicePort) + okeProxymuxPath
+	}
+
+	tokenPath := configSource.GetString(CfgOKETokenFile)
+	if tokenPath == "" {
+		tokenPath = auth.KubernetesServiceAccountTokenPath
+	}
+	tokenFile, err := provider.NewTokenFile(tokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the service account token: %s", err)
+	}
+
+	caPath := configSource.GetString(CfgOKECAFile)
+	if caPath == "" {
+		caPath = auth.KubernetesServiceAccountCertPath
+	}
+	caPEM, err := ioutil.ReadFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the workload identity CA: %s", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", caPath)
+	}
+
+	p := &workloadIdentityConfigurationProvider{
+		region:      region,
+		proxymuxURL: proxymuxURL,
+		tokenFile:   tokenFile,
+		client: &http.Client{
+			Timeout:   okeProxymuxTimeout,
+			Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}},
+		},
+		now: time.Now,
+	}
+	// fail early if the pod can't get a session
+	if p.session, err = p.newSession(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// requestSignerProvider is a configuration provider that signs requests itself, rather than through the SDK's signer
+type requestSignerProvider interface {
+	requestSigner() common.HTTPRequestSigner
+}
+
+// requestSigner returns the signer for requests made with this provider
+func (p *workloadIdentityConfigurationProvider) requestSigner() common.HTTPRequestSigner {
+	return workloadIdentitySigner{provider: p}
+}
+
+// currentSession returns the session to sign with, renewing it when it is due. Only one renewal runs at a time, and
+// it runs without holding the lock so that requests carry on with the current session until it expires.
+func (p *workloadIdentityConfigurationProvider) currentSession() (*workloadIdentitySession, error) {
+	for {
+		p.mu.Lock()
+		current := p.session
+		if p.now().Before(current.renewsAt) {
+			p.mu.Unlock()
+			return current, nil
+		}
+		if renewing := p.renewing; renewing != nil {
+			p.mu.Unlock()
+			if p.now().Before(current.expiry) {
+				return current, nil
+			}
+			<-renewing
+			continue
+		}
+		renewing := make(chan struct{})
+		p.renewing = renewing
+		p.mu.Unlock()
+
+		renewed, err := p.newSession()
+
+		p.mu.Lock()
+		if err == nil {
+			p.session = renewed
+		}
+		p.renewing = nil
+		p.mu.Unlock()
+		close(renewing)
+
+		if err != nil {
+			// the current session is still usable if renewal fails before it expires
+			if p.now().Before(current.expiry) {
+				return current, nil
+			}
+			return nil, err
+		}
+		return renewed, nil
+	}
+}
+
+// PrivateRSAKey returns the key of the current session. Requests are signed through requestSigner, which takes the key
+// and key ID from the same session.
+func (p *workloadIdentityConfigurationProvider) PrivateRSAKey() (*rsa.PrivateKey, error) {
+	session, err := p.currentSession()
+	if err != nil {
+		return nil, err
+	}
+	return session.PrivateRSAKey()
+}
+
+// KeyID returns the token of the current session
+func (p *workloadIdentityConfigurationProvider) KeyID() (string, error) {
+	session, err := p.currentSession()
+	if err != nil {
+		return "", err
+	}
+	return session.KeyID()
+}
+
+func (p *workloadIdentityConfigurationProvider) TenancyOCID() (string, error) {
+	claim, err := p.GetClaim(auth.TenancyOCIDClaimKey)
+	if err != nil {
+		return "", err
+	}
+	tenancy, _ := claim.(string)
+	return tenancy, nil
+}
+
+// UserOCID is empty, the session belongs to the workload rather than a user
+func (p *workloadIdentityConfigurationProvider) UserOCID() (string, error) {
+	return "", nil
+}
+
+func (p *workloadIdentityConfigurationProvider) KeyFingerprint() (string, error) {
+	return "", nil
+}
+
+func (p *workloadIdentityConfigurationProvider) Region() (string, error) {
+	return p.region, nil
+}
+
+func (p *workloadIdentityConfigurationProvider) AuthType() (common.AuthConfig, error) {
+	return common.AuthConfig{AuthType: common.UnknownAuthenticationType}, fmt.Errorf("unsupported, keep the interface")
+}
+
+// GetClaim returns a claim of the current session token
+func (p *workloadIdentityConfigurationProvider) GetClaim(key string) (interface{}, error) {
+	p.mu.Lock()
+	session := p.session
+	p.mu.Unlock()
+	claim, ok := session.claims[key]
+	if !ok {
+		return nil, fmt.Errorf("the workload identity session token has no %s claim", key)
+	}
+	return claim, nil
+}
+
+// newSession exchanges the current service account token for a session token bound to a new session key
+func (p *workloadIdentityConfigurationProvider) newSession() (*workloadIdentitySession, error) {
+	saToken, err := p.tokenFile.Token()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the service account token: %s", err)
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(map[string]string{
+		"podKey": string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKey})),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.proxymuxURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+saToken)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get a workload identity session token: %s", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get a workload identity session token: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to get a workload identity session token from %s: %s %s", p.proxymuxURL, resp.Status, strings.TrimSpace(string(respBody)))
+	}
+
+	rpst, err := parseProxymuxResponse(respBody)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workload identity session token from %s: %s", p.proxymuxURL, err)
+	}
+	claims, err := jwtClaims(rpst)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workload identity session token from %s: %s", p.proxymuxURL, err)
+	}
+	expiry, err := sessionTokenExpiry(rpst)
+	if err != nil {
+		return nil, fmt.Errorf("invalid workload identity session token from %s: %s", p.proxymuxURL, err)
+	}
+	if expiry.IsZero() {
+		return nil, fmt.Errorf("invalid workload identity session token from %s: it has no expiry", p.proxymuxURL)
+	}
+
+	now := p.now()
+	return &workloadIdentitySession{
+		key:      key,
+		rpst:     rpst,
+		claims:   claims,
+		expiry:   expiry,
+		renewsAt: now.Add(expiry.Sub(now) / 2),
+	}, nil
+}
+
+// parseProxymuxResponse unwraps a session token from a proxymux response, which is a JSON string holding base64
+// encoded JSON of the form {"token": "ST$<token>"}
+func parseProxymuxResponse(body []byte) (string, error) {
+	var encoded string
+	if err := json.Unmarshal(body, &encoded); err != nil {
+		return "", err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+	var parsed struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(decoded, &parsed); err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(parsed.Token, "ST$") || len(parsed.Token) == len("ST$") {
+		return "", fmt.Errorf("unexpected token format")
+	}
+	return strings.TrimPrefix(parsed.Token, "ST$"), nil
+}
@@ -0,0 +1,225 @@
+package oracle
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/provider"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+)
+
+// proxymuxSession is a session issued by the fake proxymux, with the service account token and pod key it was issued for
+type proxymuxSession struct {
+	saToken string
+	podKey  *rsa.PublicKey
+	rpst    string
+}
+
+// fakeProxymux issues unsigned session tokens over TLS, returning its URL and CA file along with the sessions issued
+func fakeProxymux(t *testing.T, claims map[string]interface{}) (string, string, func() []proxymuxSession) {
+	var mu sync.Mutex
+	var sessions []proxymuxSession
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			PodKey string `json:"podKey"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		block, _ := pem.Decode([]byte(body.PodKey))
+		if block == nil {
+			http.Error(w, "no pod key", http.StatusBadRequest)
+			return
+		}
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		sessionClaims := map[string]interface{}{"session": len(sessions)}
+		for k, v := range claims {
+			sessionClaims[k] = v
+		}
+		rpst := unsignedJWT(t, sessionClaims)
+		sessions = append(sessions, proxymuxSession{
+			saToken: strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "),
+			podKey:  key.(*rsa.PublicKey),
+			rpst:    rpst,
+		})
+		token, _ := json.Marshal(map[string]string{"token": "ST$" + rpst})
+		json.NewEncoder(w).Encode(base64.StdEncoding.EncodeToString(token))
+	}))
+	t.Cleanup(srv.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	writeTestFile(t, caFile, string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})))
+
+	return srv.URL + okeProxymuxPath, caFile, func() []proxymuxSession {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]proxymuxSession(nil), sessions...)
+	}
+}
+
+func TestOKEProvider(t *testing.T) {
+	proxymuxURL, caFile, sessions := fakeProxymux(t, map[string]interface{}{
+		"exp":                        time.Now().Add(20 * time.Minute).Unix(),
+		auth.TenancyOCIDClaimKey:     "ocid1.tenancy.oc1..oke",
+		auth.CompartmentOCIDClaimKey: "ocid1.compartment.oc1..oke",
+	})
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	writeTestFile(t, tokenFile, "sa-token-1\n")
+	t.Setenv(auth.ResourcePrincipalRegionEnvVar, "us-ashburn-1")
+
+	endpoint, authorizations := recordingEndpoint(t)
+	p, err := NewOKEProvider(provider.NewConfigSourceFromMap(map[string]string{
+		provider.CfgFnAPIURL: endpoint,
+		CfgOKEProxymuxURL:    proxymuxURL,
+		CfgOKECAFile:         caFile,
+		CfgOKETokenFile:      tokenFile,
+	}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := p.(*OracleProvider)
+	if op.CompartmentID != "ocid1.compartment.oc1..oke" {
+		t.Errorf("expected the compartment to default to the workload's, got %s", op.CompartmentID)
+	}
+
+	client := op.APIClientv2()
+	if _, err := client.Apps.ListApps(apps.NewListAppsParams()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the kubelet rotates the projected token, and the next session is requested with it
+	writeTestFile(t, tokenFile, "sa-token-2")
+	configProvider := op.ConfigurationProvider.(*workloadIdentityConfigurationProvider)
+	configProvider.now = func() time.Time { return time.Now().Add(15 * time.Minute) }
+	if _, err := client.Apps.ListApps(apps.NewListAppsParams()); err != nil {
+		t.Fatal(err)
+	}
+
+	issued := sessions()
+	if len(issued) != 2 || issued[0].saToken != "sa-token-1" || issued[1].saToken != "sa-token-2" {
+		t.Fatalf("expected a session for each service account token, got %+v", issued)
+	}
+	got := authorizations()
+	if len(got) != 2 || !strings.Contains(got[0], `keyId="ST$`+issued[0].rpst+`"`) || !strings.Contains(got[1], `keyId="ST$`+issued[1].rpst+`"`) {
+		t.Errorf("expected requests to be signed with the current session token, got %v", got)
+	}
+	key, err := configProvider.PrivateRSAKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !key.PublicKey.Equal(issued[1].podKey) {
+		t.Errorf("expected requests to be signed with the key that the session was issued for")
+	}
+}
+
+func TestOKEProviderSignsWithOneSessionDuringRenewal(t *testing.T) {
+	proxymuxURL, caFile, sessions := fakeProxymux(t, map[string]interface{}{
+		"exp":                        time.Now().Add(20 * time.Minute).Unix(),
+		auth.TenancyOCIDClaimKey:     "ocid1.tenancy.oc1..oke",
+		auth.CompartmentOCIDClaimKey: "ocid1.compartment.oc1..oke",
+	})
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	writeTestFile(t, tokenFile, "sa-token")
+	t.Setenv(auth.ResourcePrincipalRegionEnvVar, "us-ashburn-1")
+
+	p, err := NewOKEProvider(provider.NewConfigSourceFromMap(map[string]string{
+		provider.CfgFnAPIURL: "https://functions.example.com",
+		CfgOKEProxymuxURL:    proxymuxURL,
+		CfgOKECAFile:         caFile,
+		CfgOKETokenFile:      tokenFile,
+	}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := p.(*OracleProvider)
+	// every session is due for renewal, so requests are signed while sessions are being renewed
+	op.ConfigurationProvider.(*workloadIdentityConfigurationProvider).now = func() time.Time { return time.Now().Add(15 * time.Minute) }
+
+	var wg sync.WaitGroup
+	requests := make([]*http.Request, 50)
+	errs := make([]error, len(requests))
+	for i := range requests {
+		requests[i], _ = http.NewRequest(http.MethodGet, "https://functions.example.com/20181201/applications", nil)
+		requests[i].Header.Set("Date", time.Now().UTC().Format(http.TimeFormat))
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = op.Signer.Sign(requests[i])
+		}(i)
+	}
+	wg.Wait()
+
+	podKeys := map[string]*rsa.PublicKey{}
+	for _, session := range sessions() {
+		podKeys[session.rpst] = session.podKey
+	}
+	keyIDPattern := regexp.MustCompile(`keyId="ST\$([^"]+)"`)
+	signaturePattern := regexp.MustCompile(`signature="([^"]+)"`)
+	for i, r := range requests {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		authorization := r.Header.Get("Authorization")
+		keyID, signature := keyIDPattern.FindStringSubmatch(authorization), signaturePattern.FindStringSubmatch(authorization)
+		if keyID == nil || signature == nil || podKeys[keyID[1]] == nil {
+			t.Fatalf("unexpected authorization %s", authorization)
+		}
+		signingString := "date: " + r.Header.Get("Date") + "\n(request-target): get " + r.URL.RequestURI() + "\nhost: " + r.URL.Host
+		hashed := sha256.Sum256([]byte(signingString))
+		sig, _ := base64.StdEncoding.DecodeString(signature[1])
+		if err := rsa.VerifyPKCS1v15(podKeys[keyID[1]], crypto.SHA256, hashed[:], sig); err != nil {
+			t.Errorf("request %d was not signed with the key of the session it names: %s", i, err)
+		}
+	}
+	if len(sessions()) < 2 {
+		t.Errorf("expected the session to be renewed")
+	}
+}
+
+func TestOKEProviderErrors(t *testing.T) {
+	proxymuxURL, caFile, _ := fakeProxymux(t, map[string]interface{}{auth.TenancyOCIDClaimKey: "ocid1.tenancy.oc1..oke"})
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	writeTestFile(t, tokenFile, "sa-token")
+	config := map[string]string{
+		CfgOKEProxymuxURL: proxymuxURL,
+		CfgOKECAFile:      caFile,
+		CfgOKETokenFile:   tokenFile,
+	}
+
+	t.Setenv(auth.ResourcePrincipalRegionEnvVar, "")
+	if _, err := NewOKEProvider(provider.NewConfigSourceFromMap(config), nil); err == nil || !strings.Contains(err.Error(), auth.ResourcePrincipalRegionEnvVar) {
+		t.Errorf("expected an error without a region, got %v", err)
+	}
+
+	t.Setenv(auth.ResourcePrincipalRegionEnvVar, "us-ashburn-1")
+	if _, err := NewOKEProvider(provider.NewConfigSourceFromMap(config), nil); err == nil || !strings.Contains(err.Error(), "no expiry") {
+		t.Errorf("expected an error for a session token without an expiry, got %v", err)
+	}
+
+	config[CfgOKECAFile] = tokenFile
+	if _, err := NewOKEProvider(provider.NewConfigSourceFromMap(config), nil); err == nil || !strings.Contains(err.Error(), "no certificates") {
+		t.Errorf("expected an error for an invalid CA file, got %v", err)
+	}
+}
@@ -51,7 +51,7 @@ type Response struct {
 	CreatedAt   string      `json:"created_at"`
 	UpdatedAt   string      `json:"updated_at"`
 	Name        string      `json:"name"`
-	Shape       string      `json:shape`
+	Shape       string      `json:"shape"`
 }
 
 type Annotations struct {
@@ -81,6 +81,12 @@ type OracleProvider struct {
 	// ConfigurationProvider is the OCI configuration provider for signing requests
 	ConfigurationProvider common.ConfigurationProvider
 
+	// RetryPolicy is used to retry failed management and invoke requests, nil disables retries
+	RetryPolicy *provider.RetryPolicy
+
+	// AuthDetection records how the auth mode was chosen when the provider was created by oracle-auto, nil otherwise
+	AuthDetection *AuthDetection
+
 	ociClient functions.FunctionsManagementClient
 }
 
@@ -140,8 +146,12 @@ func (t ociSigningRoundTripper) intercept(request *http.Request) (err error) {
 		request.Header.Set(requestHeaderOpcRequestID, requestID)
 
 	}
-	// set opc-compartment-id
-	request.Header.Set(requestHeaderOpcCompId, t.compartmentID)
+	// set opc-compartment-id, preferring a compartment set on the request's context
+	compartmentID := provider.GetCompartmentID(request.Context())
+	if compartmentID == "" {
+		compartmentID = t.compartmentID
+	}
+	request.Header.Set(requestHeaderOpcCompId, compartmentID)
 
 	// call interceptor
 	if t.interceptor != nil {
@@ -174,10 +184,15 @@ func (op *OracleProvider) APIClientv2() *clientv2.Fn {
 	return &clientv2.Fn{
 		Apps:     shim.NewAppsShim(op.ociClient, op.CompartmentID),
 		Fns:      shim.NewFnsShim(op.ociClient),
-		Triggers: shim.NewTriggersShim(),
+		Triggers: shim.NewTriggersShimWithClient(op.ociClient),
 	}
 }
 
+// PbfCatalog returns a client for browsing the pre-built functions that can be deployed in the provider's region
+func (op *OracleProvider) PbfCatalog() *shim.PbfCatalog {
+	return shim.NewPbfCatalog(op.ociClient)
+}
+
 func (op *OracleProvider) APIURL() *url.URL {
 	return op.FnApiUrl
 }
@@ -186,6 +201,11 @@ func (op *OracleProvider) UnavailableResources() []provider.FnResourceType {
 	return []provider.FnResourceType{provider.TriggerResourceType}
 }
 
+// ReadOnlyResources implements provider.ReadOnlyResourcesProvider, triggers can be listed but are managed by OCI
+func (op *OracleProvider) ReadOnlyResources() []provider.FnResourceType {
+	return []provider.FnResourceType{provider.TriggerResourceType}
+}
+
 func (op *OracleProvider) VersionClient() *version.Client {
 	runtime := openapi.New(op.FnApiUrl.Host, op.FnApiUrl.Path, []string{op.FnApiUrl.Scheme})
 	runtime.Transport = op.WrapCallTransport(runtime.Transport)
@@ -204,7 +224,70 @@ func (op *OracleProvider) WrapCallTransport(roundTripper http.RoundTripper) http
 		compartmentID: op.CompartmentID,
 	}
 
-	return signingRoundTripper
+	// Retries wrap signing so that every attempt carries a fresh signature
+	return provider.RetryRoundTripper(op.RetryPolicy, signingRoundTripper)
+}
+
+// configureRetries reads the retry policy from config and applies it to the OCI management client
+func configureRetries(configSource provider.ConfigSource, ociClient *functions.FunctionsManagementClient) (*provider.RetryPolicy, error) {
+	retryPolicy, err := provider.RetryPolicyFromConfig(configSource)
+	if err != nil || retryPolicy == nil {
+		return nil, err
+	}
+
+	ociPolicy := ociRetryPolicy(retryPolicy)
+	ociClient.SetCustomClientConfiguration(common.CustomClientConfiguration{RetryPolicy: &ociPolicy})
+	return retryPolicy, nil
+}
+
+// ociRetryPolicy maps a retry policy onto the OCI SDK's retry mechanism so that both providers retry the same requests
+func ociRetryPolicy(policy *provider.RetryPolicy) common.RetryPolicy {
+	shouldRetry := func(r common.OCIOperationResponse) bool {
+		resp := ociHTTPResponse(r.Response)
+		if r.Error == nil && resp != nil && resp.StatusCode < 300 {
+			return false
+		}
+
+		method := ociRequestMethod(r.Response)
+		if resp == nil {
+			if serviceErr, ok := common.IsServiceError(r.Error); ok {
+				resp = &http.Response{StatusCode: serviceErr.GetHTTPStatusCode(), Header: http.Header{}}
+			} else {
+				return policy.ShouldRetry(method, nil, r.Error)
+			}
+		}
+		return policy.ShouldRetry(method, resp, nil)
+	}
+
+	nextDuration := func(r common.OCIOperationResponse) time.Duration {
+		return policy.Backoff(int(r.AttemptNumber), provider.RetryAfter(ociHTTPResponse(r.Response)))
+	}
+
+	return common.NewRetryPolicy(uint(policy.MaxAttempts), shouldRetry, nextDuration)
+}
+
+func ociHTTPResponse(response common.OCIResponse) *http.Response {
+	if response == nil {
+		return nil
+	}
+	return response.HTTPResponse()
+}
+
+// ociRequestMethod works out the HTTP method of an OCI operation, even when it failed before a response was received
+func ociRequestMethod(response common.OCIResponse) string {
+	if resp := ociHTTPResponse(response); resp != nil && resp.Request != nil {
+		return resp.Request.Method
+	}
+	switch response.(type) {
+	case functions.CreateApplicationResponse, functions.CreateFunctionResponse, functions.InvokeFunctionResponse:
+		return http.MethodPost
+	}
+	return ""
+}
+
+// InvokeClient returns a client that invokes functions through their OCI invoke endpoint, signing each request
+func (op *OracleProvider) InvokeClient() provider.InvokeClient {
+	return provider.NewInvokeClient(op.WrapCallTransport(http.DefaultTransport), nil)
 }
 
 func getEnv(key, fallback string) string {
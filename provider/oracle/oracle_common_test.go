@@ -1,8 +1,15 @@
 package oracle
 
 import (
+	"context"
+	"errors"
 	"net/http"
+	"strings"
 	"testing"
+
+	"github.com/fnproject/fn_go/provider"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/functions"
 )
 
 func TestInsecureRoundTripper(t *testing.T) {
@@ -24,3 +31,88 @@ func TestInsecureRoundTripper(t *testing.T) {
 		}
 	}
 }
+
+func TestOCIRetryPolicyIsIdempotencyAware(t *testing.T) {
+	policy := ociRetryPolicy(provider.DefaultRetryPolicy())
+
+	unavailable := func(method string) common.OCIOperationResponse {
+		resp := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}, Request: &http.Request{Method: method}}
+		return common.OCIOperationResponse{
+			Response:      functions.GetApplicationResponse{RawResponse: resp},
+			Error:         errors.New("service unavailable"),
+			AttemptNumber: 1,
+		}
+	}
+
+	if !policy.ShouldRetryOperation(unavailable(http.MethodGet)) {
+		t.Error("expected GET to be retried on 503")
+	}
+	if policy.ShouldRetryOperation(unavailable(http.MethodPost)) {
+		t.Error("expected POST not to be retried on 503")
+	}
+	if policy.MaximumNumberAttempts != 4 {
+		t.Errorf("unexpected number of attempts %d", policy.MaximumNumberAttempts)
+	}
+}
+
+func TestTriggersAreReadOnly(t *testing.T) {
+	p := &OracleProvider{}
+	if unavailable := p.UnavailableResources(); len(unavailable) != 1 || unavailable[0] != provider.TriggerResourceType {
+		t.Errorf("expected triggers to stay unavailable for consumers that don't check capabilities, got %v", unavailable)
+	}
+	capabilities := provider.Capabilities(p)
+	if capabilities[provider.TriggerResourceType] != provider.ResourceSupportReadOnly {
+		t.Errorf("expected read-only triggers, got %s", capabilities[provider.TriggerResourceType])
+	}
+	if capabilities[provider.FunctionResourceType] != provider.ResourceSupportFull {
+		t.Errorf("expected full function support, got %s", capabilities[provider.FunctionResourceType])
+	}
+}
+
+func TestSigningRoundTripperCompartmentOverride(t *testing.T) {
+	rt := ociSigningRoundTripper{compartmentID: "ocid1.compartment.oc1..default"}
+
+	request, _ := http.NewRequest(http.MethodGet, "https://functions.example.com", nil)
+	if err := rt.intercept(request); err != nil {
+		t.Fatal(err)
+	}
+	if got := request.Header.Get(requestHeaderOpcCompId); got != "ocid1.compartment.oc1..default" {
+		t.Errorf("expected the default compartment, got %q", got)
+	}
+
+	request = request.WithContext(provider.WithCompartmentID(context.Background(), "ocid1.compartment.oc1..override"))
+	if err := rt.intercept(request); err != nil {
+		t.Fatal(err)
+	}
+	if got := request.Header.Get(requestHeaderOpcCompId); got != "ocid1.compartment.oc1..override" {
+		t.Errorf("expected the compartment from the context, got %q", got)
+	}
+}
+
+func TestLoadOracleConfigPrecedence(t *testing.T) {
+	t.Setenv(OCI_CLI_CONFIG_FILE_ENV_VAR, t.TempDir()+"/missing")
+	t.Setenv(OCI_CLI_PROFILE_ENV_VAR, "")
+	t.Setenv(OCI_CLI_TENANCY_ENV_VAR, "ocid1.tenancy.oc1..env")
+	t.Setenv(OCI_CLI_USER_ENV_VAR, "")
+	t.Setenv(OCI_CLI_FINGERPRINT_ENV_VAR, "")
+	t.Setenv(OCI_CLI_KEY_FILE_ENV_VAR, "")
+
+	config := provider.NewConfigSourceFromMap(map[string]string{
+		CfgTenancyID: "ocid1.tenancy.oc1..config",
+		CfgUserID:    "ocid1.user.oc1..config",
+	})
+
+	settings := oracleSettings(config)
+	tenancy := settings.Lookup(CfgTenancyID)
+	if tenancy.Value != "ocid1.tenancy.oc1..env" || tenancy.Origin != "environment variable "+OCI_CLI_TENANCY_ENV_VAR {
+		t.Errorf("expected %s to override the Fn config, got %+v", OCI_CLI_TENANCY_ENV_VAR, tenancy)
+	}
+	if user := settings.Lookup(CfgUserID); user.Origin != "Fn config" {
+		t.Errorf("expected the user to come from the Fn config, got %+v", user)
+	}
+
+	_, err := loadOracleConfig(config, &provider.NopPassPhraseSource{})
+	if err == nil || !strings.Contains(err.Error(), OCI_CLI_FINGERPRINT_ENV_VAR) {
+		t.Errorf("expected an error explaining how to set the missing fingerprint, got %v", err)
+	}
+}
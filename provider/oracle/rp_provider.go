@@ -0,0 +1,97 @@
+package oracle
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/oracle/oci-go-sdk/v65/functions"
+
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+
+	"github.com/fnproject/fn_go/provider"
+)
+
+const (
+	userAgentPrefixRp = "fn_go-oracle-rp"
+)
+
+// NewRPProvider creates an "oracle-rp" provider, which authenticates as the resource principal of the OCI function (or
+// other resource) that it runs in. The principal is read from the OCI_RESOURCE_PRINCIPAL_* environment variables that
+// OCI sets; oracle.compartment-id defaults to the compartment of the resource.
+func NewRPProvider(configSource provider.ConfigSource, passphraseSource provider.PassPhraseSource) (provider.Provider, error) {
+	configProvider, err := auth.ResourcePrincipalConfigurationProvider()
+	if err != nil {
+		return nil, err
+	}
+	return newRPProvider(configSource, configProvider, userAgentPrefixRp)
+}
+
+func newRPProvider(configSource provider.ConfigSource, configProvider auth.ConfigurationProviderWithClaimAccess, userAgentPrefix string) (provider.Provider, error) {
+	compartmentID := configSource.GetString(CfgCompartmentID)
+	if compartmentID == "" {
+		claim, err := configProvider.GetClaim(auth.CompartmentOCIDClaimKey)
+		if err != nil {
+			return nil, fmt.Errorf("no compartment in config key %s and unable to read it from the resource principal: %s", CfgCompartmentID, err)
+		}
+		var ok bool
+		if compartmentID, ok = claim.(string); !ok || compartmentID == "" {
+			return nil, fmt.Errorf("no compartment in config key %s or the resource principal", CfgCompartmentID)
+		}
+	}
+
+	ociClient, err := functions.NewFunctionsManagementClientWithConfigurationProvider(configProvider)
+	if err != nil {
+		return nil, err
+	}
+
+	ociClient.UserAgent = fmt.Sprintf("%s %s", userAgentPrefix, ociClient.UserAgent)
+
+	signer := common.DefaultRequestSigner(configProvider)
+	if s, ok := configProvider.(requestSignerProvider); ok {
+		// the configuration provider has its own signer when the key and key ID must be read together
+		signer = s.requestSigner()
+		ociClient.Signer = signer
+	}
+
+	retryPolicy, err := configureRetries(configSource, &ociClient)
+	if err != nil {
+		return nil, err
+	}
+
+	disableCerts := configSource.GetBool(CfgDisableCerts)
+	if disableCerts {
+		c := ociClient.HTTPClient.(*http.Client)
+		c.Transport = InsecureRoundTripper(c.Transport)
+	}
+
+	// If we have an explicit api-url configured then use that, otherwise let OCI client compute the url from the
+	// region of the resource principal.
+	cfgApiUrl := configSource.GetString(provider.CfgFnAPIURL)
+	var apiUrl *url.URL
+	if cfgApiUrl != "" {
+		apiUrl, err = provider.CanonicalFnAPIUrl(cfgApiUrl)
+		if err != nil {
+			return nil, err
+		}
+		ociClient.Host = apiUrl.String()
+	} else {
+		apiUrl, err = provider.CanonicalFnAPIUrl(ociClient.Host)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &OracleProvider{
+		FnApiUrl:              apiUrl,
+		Signer:                signer,
+		Interceptor:           nil,
+		DisableCerts:          disableCerts,
+		CompartmentID:         compartmentID,
+		ImageCompartmentID:    configSource.GetString(CfgImageCompartmentID),
+		ConfigurationProvider: configProvider,
+		RetryPolicy:           retryPolicy,
+		ociClient:             ociClient,
+	}, nil
+}
@@ -0,0 +1,150 @@
+package oracle
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider"
+	"github.com/fnproject/fn_go/provider/oracle/ocitest"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+)
+
+// recordingEndpoint serves an ocitest server through a proxy that records the Authorization header of each request
+func recordingEndpoint(t *testing.T) (string, func() []string) {
+	srv := ocitest.NewServer(nil)
+	t.Cleanup(srv.Close)
+	target, _ := url.Parse(srv.URL())
+	proxy := httputil.NewSingleHostReverseProxy(target)
+
+	var mu sync.Mutex
+	var authorizations []string
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		mu.Unlock()
+		proxy.ServeHTTP(w, r)
+	}))
+	t.Cleanup(endpoint.Close)
+
+	return endpoint.URL, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), authorizations...)
+	}
+}
+
+// unsignedJWT builds a token with the given claims, the SDK and the ocitest server never check its signature
+func unsignedJWT(t *testing.T, claims map[string]interface{}) string {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`)) + "." + enc.EncodeToString(payload) + "." + enc.EncodeToString([]byte("sig"))
+}
+
+// writeTestKey writes a throwaway unencrypted RSA private key
+func writeTestKey(t *testing.T, path string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeTestFile(t, path, string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})))
+}
+
+// writeRPST writes a resource principal session token and its private key in the layout that OCI Functions uses, the
+// token is an unsigned JWT as the SDK only decodes its claims
+func writeRPST(t *testing.T, claims map[string]interface{}) (tokenFile, keyFile, token string) {
+	dir := t.TempDir()
+	keyFile = filepath.Join(dir, "rp.pem")
+	writeTestKey(t, keyFile)
+
+	token = unsignedJWT(t, claims)
+	tokenFile = filepath.Join(dir, "rpst")
+	writeTestFile(t, tokenFile, token)
+	return tokenFile, keyFile, token
+}
+
+func TestRPProvider(t *testing.T) {
+	tokenFile, keyFile, token := writeRPST(t, map[string]interface{}{
+		"exp":                        time.Now().Add(time.Hour).Unix(),
+		auth.TenancyOCIDClaimKey:     "ocid1.tenancy.oc1..rp",
+		auth.CompartmentOCIDClaimKey: "ocid1.compartment.oc1..rp",
+	})
+	t.Setenv(auth.ResourcePrincipalVersionEnvVar, auth.ResourcePrincipalVersion2_2)
+	t.Setenv(auth.ResourcePrincipalRPSTEnvVar, tokenFile)
+	t.Setenv(auth.ResourcePrincipalPrivatePEMEnvVar, keyFile)
+	t.Setenv(auth.ResourcePrincipalRegionEnvVar, "us-ashburn-1")
+
+	endpoint, authorizations := recordingEndpoint(t)
+
+	p, err := NewRPProvider(provider.NewConfigSourceFromMap(map[string]string{
+		provider.CfgFnAPIURL: endpoint,
+	}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := p.(*OracleProvider)
+	if op.CompartmentID != "ocid1.compartment.oc1..rp" {
+		t.Errorf("expected the compartment to default to the resource principal's, got %s", op.CompartmentID)
+	}
+
+	app, err := p.APIClientv2().Apps.CreateApp(apps.NewCreateAppParams().WithBody(&modelsv2.App{
+		Name:        "orchestrated",
+		Annotations: map[string]interface{}{"oracle.com/oci/subnetIds": []interface{}{"subnet"}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := CompartmentID(app.Payload.Annotations); got != "ocid1.compartment.oc1..rp" {
+		t.Errorf("expected the app to be created in the resource principal's compartment, got %s", got)
+	}
+
+	if got := authorizations(); len(got) == 0 || !strings.Contains(got[0], `keyId="ST$`+token+`"`) {
+		t.Errorf("expected requests to be signed with the session token, got %v", got)
+	}
+}
+
+func TestRPProviderCompartmentOverride(t *testing.T) {
+	tokenFile, keyFile, _ := writeRPST(t, map[string]interface{}{
+		"exp":                    time.Now().Add(time.Hour).Unix(),
+		auth.TenancyOCIDClaimKey: "ocid1.tenancy.oc1..rp",
+	})
+	t.Setenv(auth.ResourcePrincipalVersionEnvVar, auth.ResourcePrincipalVersion2_2)
+	t.Setenv(auth.ResourcePrincipalRPSTEnvVar, tokenFile)
+	t.Setenv(auth.ResourcePrincipalPrivatePEMEnvVar, keyFile)
+	t.Setenv(auth.ResourcePrincipalRegionEnvVar, "us-ashburn-1")
+
+	_, err := NewRPProvider(provider.NewConfigSourceFromMap(map[string]string{}), nil)
+	if err == nil {
+		t.Errorf("expected an error when neither the config nor the token has a compartment")
+	}
+
+	p, err := NewRPProvider(provider.NewConfigSourceFromMap(map[string]string{
+		CfgCompartmentID: "ocid1.compartment.oc1..config",
+	}), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.(*OracleProvider).CompartmentID; got != "ocid1.compartment.oc1..config" {
+		t.Errorf("expected the configured compartment, got %s", got)
+	}
+	if got := p.APIURL().Hostname(); got != "functions.us-ashburn-1.oci.oraclecloud.com" {
+		t.Errorf("expected the API URL to come from the resource principal's region, got %s", got)
+	}
+}
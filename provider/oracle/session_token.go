@@ -0,0 +1,118 @@
+package oracle
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
+	oci "github.com/oracle/oci-go-sdk/v65/common"
+)
+
+const (
+	// CfgSecurityTokenFile is the session token written by `oci session authenticate`, it is normally read from the
+	// security_token_file of the OCI config profile
+	CfgSecurityTokenFile = "oracle.security-token-file"
+)
+
+// ErrSessionTokenExpired is returned (wrapped) when a request is signed with a session token that has expired
+var ErrSessionTokenExpired = errors.New("OCI session token has expired")
+
+// sessionTokenConfigurationProvider signs requests with a session token instead of a user's API key. The token file is
+// read for every request, so a token refreshed with `oci session refresh` is picked up without recreating the provider.
+type sessionTokenConfigurationProvider struct {
+	oci.ConfigurationProvider
+	profile   string
+	tokenFile string
+	now       func() time.Time
+}
+
+func newSessionTokenConfigurationProvider(base oci.ConfigurationProvider, profile, tokenFile string) (*sessionTokenConfigurationProvider, error) {
+	expanded, err := homedir.Expand(tokenFile)
+	if err != nil {
+		return nil, err
+	}
+	p := &sessionTokenConfigurationProvider{ConfigurationProvider: base, profile: profile, tokenFile: expanded, now: time.Now}
+	// fail early if the token can't be read, an expired token is only reported when it is used so that it can be refreshed
+	if _, _, err := p.loadToken(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// KeyID returns the session token in the form that OCI expects in place of a tenancy/user/fingerprint key ID
+func (p *sessionTokenConfigurationProvider) KeyID() (string, error) {
+	token, expiry, err := p.loadToken()
+	if err != nil {
+		return "", err
+	}
+	if !expiry.IsZero() && !p.now().Before(expiry) {
+		return "", fmt.Errorf("%w: the session token for profile %s expired at %s, re-authenticate with `oci session authenticate --profile-name %s`",
+			ErrSessionTokenExpired, p.profile, expiry.Format(time.RFC3339), p.profile)
+	}
+	return "ST$" + token, nil
+}
+
+// UserOCID is empty, session tokens identify the user themselves
+func (p *sessionTokenConfigurationProvider) UserOCID() (string, error) {
+	return "", nil
+}
+
+// loadToken reads the token file along with the expiry of the token
+func (p *sessionTokenConfigurationProvider) loadToken() (string, time.Time, error) {
+	data, err := ioutil.ReadFile(p.tokenFile)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("unable to read the session token for profile %s: %s", p.profile, err)
+	}
+	token := strings.TrimSpace(string(data))
+	expiry, err := sessionTokenExpiry(token)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid session token in %s: %s", p.tokenFile, err)
+	}
+	return token, expiry, nil
+}
+
+// sessionTokenExpiry reads the exp claim of a session token (a JWT), a token without one has a zero expiry
+func sessionTokenExpiry(token string) (time.Time, error) {
+	claims, err := jwtClaims(token)
+	if err != nil {
+		return time.Time{}, err
+	}
+	claim, ok := claims["exp"]
+	if !ok {
+		return time.Time{}, nil
+	}
+	exp, ok := claim.(json.Number)
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid exp claim: %v", claim)
+	}
+	seconds, err := exp.Int64()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid exp claim: %s", err)
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+// jwtClaims decodes the claims of a JWT without checking its signature, numbers are returned as json.Number
+func jwtClaims(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	var claims map[string]interface{}
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
@@ -0,0 +1,105 @@
+package oracle
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/provider"
+)
+
+func TestSessionTokenProfile(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "session_key.pem")
+	writeTestKey(t, keyFile)
+	tokenFile := filepath.Join(dir, "token")
+	writeTestFile(t, tokenFile, unsignedJWT(t, map[string]interface{}{"exp": time.Now().Add(time.Hour).Unix(), "sess": 1}))
+
+	configFile := filepath.Join(dir, "config")
+	writeTestFile(t, configFile, fmt.Sprintf(`[DEFAULT]
+user=ocid1.user.oc1..default
+
+# written by oci session authenticate
+[SESSION]
+fingerprint=00:11:22:33
+key_file=%s
+tenancy=ocid1.tenancy.oc1..session
+region=us-ashburn-1
+security_token_file=%s
+`, keyFile, tokenFile))
+
+	for _, env := range []string{OCI_CLI_TENANCY_ENV_VAR, OCI_CLI_USER_ENV_VAR, OCI_CLI_FINGERPRINT_ENV_VAR, OCI_CLI_KEY_FILE_ENV_VAR} {
+		t.Setenv(env, "")
+	}
+	t.Setenv(OCI_CLI_CONFIG_FILE_ENV_VAR, configFile)
+	t.Setenv(OCI_CLI_PROFILE_ENV_VAR, "SESSION")
+
+	endpoint, authorizations := recordingEndpoint(t)
+	p, err := NewFromConfig(provider.NewConfigSourceFromMap(map[string]string{
+		provider.CfgFnAPIURL: endpoint,
+		CfgCompartmentID:     "ocid1.compartment.oc1..session",
+	}), &provider.NopPassPhraseSource{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := p.APIClientv2()
+	listApps := func() error {
+		_, err := client.Apps.ListApps(apps.NewListAppsParams())
+		return err
+	}
+
+	if err := listApps(); err != nil {
+		t.Fatal(err)
+	}
+
+	// `oci session refresh` rewrites the token file in place
+	refreshed := unsignedJWT(t, map[string]interface{}{"exp": time.Now().Add(2 * time.Hour).Unix(), "sess": 2})
+	writeTestFile(t, tokenFile, refreshed)
+	if err := listApps(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := authorizations()
+	if len(got) != 2 || !strings.Contains(got[0], `keyId="ST$`) || !strings.Contains(got[1], `keyId="ST$`+refreshed+`"`) {
+		t.Errorf("expected requests to be signed with the current session token, got %v", got)
+	}
+
+	writeTestFile(t, tokenFile, unsignedJWT(t, map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()}))
+	err = listApps()
+	if !errors.Is(err, ErrSessionTokenExpired) || !strings.Contains(err.Error(), "oci session authenticate --profile-name SESSION") {
+		t.Errorf("expected a re-authenticate error for an expired token, got %v", err)
+	}
+	if len(authorizations()) != 2 {
+		t.Errorf("requests with an expired token should not be sent")
+	}
+}
+
+func TestSessionTokenExpiry(t *testing.T) {
+	expired := time.Unix(1700000000, 0)
+	p := &sessionTokenConfigurationProvider{
+		profile:   "SESSION",
+		tokenFile: filepath.Join(t.TempDir(), "token"),
+		now:       func() time.Time { return expired },
+	}
+	writeTestFile(t, p.tokenFile, unsignedJWT(t, map[string]interface{}{"exp": expired.Unix()})+"\n")
+
+	_, err := p.KeyID()
+	if !errors.Is(err, ErrSessionTokenExpired) {
+		t.Errorf("expected ErrSessionTokenExpired, got %v", err)
+	}
+
+	p.now = func() time.Time { return expired.Add(-time.Second) }
+	keyID, err := p.KeyID()
+	if err != nil || !strings.HasPrefix(keyID, "ST$") || strings.HasSuffix(keyID, "\n") {
+		t.Errorf("expected a trimmed session token key ID, got %q, %v", keyID, err)
+	}
+
+	writeTestFile(t, p.tokenFile, "not a token")
+	if _, err := p.KeyID(); err == nil || errors.Is(err, ErrSessionTokenExpired) {
+		t.Errorf("expected an invalid token error, got %v", err)
+	}
+}
@@ -0,0 +1,189 @@
+package shim
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/oracle/oci-go-sdk/v65/functions"
+)
+
+// Annotations that carry OCI-specific application and function settings. Values use the same JSON shapes as the OCI
+// API, e.g. {"isEnabled": true, "domainId": "ocid1.apmdomain..."} for trace config. Setting an annotation to null or
+// "" in an update clears the setting.
+const (
+	// AnnotationNetworkSecurityGroupIds is a list of NSG OCIDs for an application
+	AnnotationNetworkSecurityGroupIds = "oracle.com/oci/networkSecurityGroupIds"
+	// AnnotationTraceConfig is the tracing configuration of an application or function
+	AnnotationTraceConfig = "oracle.com/oci/traceConfig"
+	// AnnotationFreeformTags is a map of free-form tags on an application or function
+	AnnotationFreeformTags = "oracle.com/oci/freeformTags"
+	// AnnotationDefinedTags is a map of tag namespaces to defined tags on an application or function
+	AnnotationDefinedTags = "oracle.com/oci/definedTags"
+	// AnnotationImagePolicyConfig is the image signature verification policy of an application
+	AnnotationImagePolicyConfig = "oracle.com/oci/imagePolicyConfig"
+)
+
+// ociSettings are the OCI fields that are round-tripped through annotations
+type ociSettings struct {
+	networkSecurityGroupIds []string
+	appTraceConfig          *functions.ApplicationTraceConfig
+	fnTraceConfig           *functions.FunctionTraceConfig
+	freeformTags            map[string]string
+	definedTags             map[string]map[string]interface{}
+	imagePolicyConfig       *functions.ImagePolicyConfig
+	provisionedConcurrency  functions.FunctionProvisionedConcurrencyConfig
+}
+
+// cleared reports whether an annotation is present but empty, which clears the setting
+func cleared(value interface{}) bool {
+	return value == nil || value == ""
+}
+
+// decodeAnnotation converts an annotation value (either a decoded JSON value or a Go value) into target by way of
+// its JSON representation. It returns false if the annotation is absent.
+func decodeAnnotation(annotations map[string]interface{}, key string, target interface{}) (bool, error) {
+	value, ok := annotations[key]
+	if !ok {
+		return false, nil
+	}
+	if cleared(value) {
+		return true, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s annotation: %s", key, err)
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return false, fmt.Errorf("invalid %s annotation: %s", key, err)
+	}
+	return true, nil
+}
+
+// encodeAnnotation stores value in annotations in the form that it would have after a round trip through JSON
+func encodeAnnotation(annotations map[string]interface{}, key string, value interface{}) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return
+	}
+	annotations[key] = decoded
+}
+
+// parseAppSettings reads the OCI application settings from annotations, settings whose annotation is absent are left
+// nil so that they are not sent to OCI
+func parseAppSettings(annotations map[string]interface{}) (*ociSettings, error) {
+	settings := &ociSettings{}
+
+	if ok, err := decodeAnnotation(annotations, AnnotationNetworkSecurityGroupIds, &settings.networkSecurityGroupIds); err != nil {
+		return nil, err
+	} else if ok && settings.networkSecurityGroupIds == nil {
+		settings.networkSecurityGroupIds = []string{}
+	}
+
+	traceConfig := &functions.ApplicationTraceConfig{}
+	if ok, err := decodeAnnotation(annotations, AnnotationTraceConfig, traceConfig); err != nil {
+		return nil, err
+	} else if ok {
+		if traceConfig.IsEnabled == nil {
+			disabled := false
+			traceConfig.IsEnabled = &disabled
+		}
+		settings.appTraceConfig = traceConfig
+	}
+
+	imagePolicyConfig := &functions.ImagePolicyConfig{}
+	if ok, err := decodeAnnotation(annotations, AnnotationImagePolicyConfig, imagePolicyConfig); err != nil {
+		return nil, err
+	} else if ok {
+		if imagePolicyConfig.IsPolicyEnabled == nil {
+			disabled := false
+			imagePolicyConfig.IsPolicyEnabled = &disabled
+		}
+		settings.imagePolicyConfig = imagePolicyConfig
+	}
+
+	if err := parseTags(annotations, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// parseFnSettings reads the OCI function settings from annotations
+func parseFnSettings(annotations map[string]interface{}) (*ociSettings, error) {
+	settings := &ociSettings{}
+
+	traceConfig := &functions.FunctionTraceConfig{}
+	if ok, err := decodeAnnotation(annotations, AnnotationTraceConfig, traceConfig); err != nil {
+		return nil, err
+	} else if ok {
+		if traceConfig.IsEnabled == nil {
+			disabled := false
+			traceConfig.IsEnabled = &disabled
+		}
+		settings.fnTraceConfig = traceConfig
+	}
+
+	provisionedConcurrency, err := ociProvisionedConcurrency(annotations)
+	if err != nil {
+		return nil, err
+	}
+	settings.provisionedConcurrency = provisionedConcurrency
+
+	if err := parseTags(annotations, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func parseTags(annotations map[string]interface{}, settings *ociSettings) error {
+	if ok, err := decodeAnnotation(annotations, AnnotationFreeformTags, &settings.freeformTags); err != nil {
+		return err
+	} else if ok && settings.freeformTags == nil {
+		settings.freeformTags = map[string]string{}
+	}
+
+	if ok, err := decodeAnnotation(annotations, AnnotationDefinedTags, &settings.definedTags); err != nil {
+		return err
+	} else if ok && settings.definedTags == nil {
+		settings.definedTags = map[string]map[string]interface{}{}
+	}
+	return nil
+}
+
+// setAppSettingAnnotations records the OCI settings of an application (or application summary) in annotations
+func setAppSettingAnnotations(annotations map[string]interface{}, nsgIds []string, traceConfig *functions.ApplicationTraceConfig,
+	imagePolicyConfig *functions.ImagePolicyConfig, freeformTags map[string]string, definedTags map[string]map[string]interface{}) {
+	if len(nsgIds) > 0 {
+		annotations[AnnotationNetworkSecurityGroupIds] = ociSubnetsToAnnotationValue(nsgIds)
+	}
+	if traceConfig != nil {
+		encodeAnnotation(annotations, AnnotationTraceConfig, traceConfig)
+	}
+	if imagePolicyConfig != nil {
+		encodeAnnotation(annotations, AnnotationImagePolicyConfig, imagePolicyConfig)
+	}
+	setTagAnnotations(annotations, freeformTags, definedTags)
+}
+
+// setFnSettingAnnotations records the OCI settings of a function (or function summary) in annotations
+func setFnSettingAnnotations(annotations map[string]interface{}, traceConfig *functions.FunctionTraceConfig,
+	provisionedConcurrency functions.FunctionProvisionedConcurrencyConfig, freeformTags map[string]string,
+	definedTags map[string]map[string]interface{}) {
+	if traceConfig != nil {
+		encodeAnnotation(annotations, AnnotationTraceConfig, traceConfig)
+	}
+	setProvisionedConcurrencyAnnotation(annotations, provisionedConcurrency)
+	setTagAnnotations(annotations, freeformTags, definedTags)
+}
+
+func setTagAnnotations(annotations map[string]interface{}, freeformTags map[string]string, definedTags map[string]map[string]interface{}) {
+	if len(freeformTags) > 0 {
+		encodeAnnotation(annotations, AnnotationFreeformTags, freeformTags)
+	}
+	if len(definedTags) > 0 {
+		encodeAnnotation(annotations, AnnotationDefinedTags, definedTags)
+	}
+}
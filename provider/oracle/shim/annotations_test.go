@@ -0,0 +1,95 @@
+package shim
+
+import (
+	"testing"
+
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider/oracle/ocitest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppSettingsRoundTrip(t *testing.T) {
+	shim := NewAppsShim(ocitest.NewClient(), "AppSettingsCompartment")
+
+	created, err := shim.CreateApp(&apps.CreateAppParams{Body: &modelsv2.App{
+		Name: "AppSettingsName",
+		Annotations: map[string]interface{}{
+			annotationSubnet:                  []interface{}{"AppSettingsSubnet"},
+			AnnotationNetworkSecurityGroupIds: []string{"AppSettingsNsg"},
+			AnnotationTraceConfig:             map[string]interface{}{"isEnabled": true, "domainId": "AppSettingsApm"},
+			AnnotationFreeformTags:            map[string]string{"cost-center": "42"},
+			AnnotationDefinedTags:             map[string]interface{}{"Operations": map[string]interface{}{"Owner": "team"}},
+			AnnotationImagePolicyConfig: map[string]interface{}{
+				"isPolicyEnabled": true,
+				"keyDetails":      []interface{}{map[string]interface{}{"kmsKeyId": "AppSettingsKey"}},
+			},
+		},
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	got, err := shim.GetApp(&apps.GetAppParams{AppID: created.Payload.ID})
+	if !assert.NoError(t, err) {
+		return
+	}
+	annotations := got.Payload.Annotations
+	assert.Equal(t, []interface{}{"AppSettingsNsg"}, annotations[AnnotationNetworkSecurityGroupIds])
+	assert.Equal(t, map[string]interface{}{"isEnabled": true, "domainId": "AppSettingsApm"}, annotations[AnnotationTraceConfig])
+	assert.Equal(t, map[string]interface{}{"cost-center": "42"}, annotations[AnnotationFreeformTags])
+	assert.Equal(t, map[string]interface{}{"Operations": map[string]interface{}{"Owner": "team"}}, annotations[AnnotationDefinedTags])
+	assert.Equal(t, map[string]interface{}{
+		"isPolicyEnabled": true,
+		"keyDetails":      []interface{}{map[string]interface{}{"kmsKeyId": "AppSettingsKey"}},
+	}, annotations[AnnotationImagePolicyConfig])
+
+	updated, err := shim.UpdateApp(&apps.UpdateAppParams{AppID: created.Payload.ID, Body: &modelsv2.App{
+		Annotations: map[string]interface{}{
+			AnnotationFreeformTags:            map[string]interface{}{"cost-center": "43"},
+			AnnotationNetworkSecurityGroupIds: nil,
+		},
+	}})
+	if assert.NoError(t, err) {
+		assert.Equal(t, map[string]interface{}{"cost-center": "43"}, updated.Payload.Annotations[AnnotationFreeformTags])
+		assert.NotContains(t, updated.Payload.Annotations, AnnotationNetworkSecurityGroupIds)
+		assert.Contains(t, updated.Payload.Annotations, AnnotationTraceConfig, "settings without annotations should be left alone")
+	}
+}
+
+func TestFnSettingsRoundTrip(t *testing.T) {
+	c := ocitest.NewClient()
+	app, err := NewAppsShim(c, "FnSettingsCompartment").CreateApp(&apps.CreateAppParams{Body: &modelsv2.App{
+		Name:        "FnSettingsApp",
+		Annotations: map[string]interface{}{annotationSubnet: []interface{}{"FnSettingsSubnet"}},
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	shim := NewFnsShim(c)
+	created, err := shim.CreateFn(&fns.CreateFnParams{Body: &modelsv2.Fn{
+		AppID: app.Payload.ID,
+		Name:  "FnSettingsName",
+		Image: "FnSettingsImage",
+		Annotations: map[string]interface{}{
+			AnnotationTraceConfig:  map[string]interface{}{"isEnabled": true},
+			AnnotationFreeformTags: map[string]interface{}{"team": "fn"},
+		},
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, map[string]interface{}{"isEnabled": true}, created.Payload.Annotations[AnnotationTraceConfig])
+
+	list, err := shim.ListFns(&fns.ListFnsParams{AppID: &app.Payload.ID})
+	if assert.NoError(t, err) && assert.Len(t, list.Payload.Items, 1) {
+		assert.Equal(t, map[string]interface{}{"team": "fn"}, list.Payload.Items[0].Annotations[AnnotationFreeformTags])
+	}
+
+	_, err = shim.UpdateFn(&fns.UpdateFnParams{FnID: created.Payload.ID, Body: &modelsv2.Fn{
+		Annotations: map[string]interface{}{AnnotationTraceConfig: "not a trace config"},
+	}})
+	assert.Error(t, err)
+}
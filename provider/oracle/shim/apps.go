@@ -1,10 +1,12 @@
 package shim
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/fnproject/fn_go/clientv2/apps"
 	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider"
 	"github.com/fnproject/fn_go/provider/oracle/shim/client"
 	"github.com/go-openapi/runtime"
 	"github.com/go-openapi/strfmt"
@@ -26,6 +28,15 @@ func NewAppsShim(ociClient client.FunctionsManagementClient, compartmentId strin
 	return &appsShim{ociClient: ociClient, compartmentId: compartmentId}
 }
 
+// compartment returns the compartment to use for a call, a compartment set with provider.WithCompartmentID takes
+// precedence over the shim's own
+func (s *appsShim) compartment(ctx context.Context) *string {
+	if compartmentID := provider.GetCompartmentID(ctx); compartmentID != "" {
+		return &compartmentID
+	}
+	return &s.compartmentId
+}
+
 func (s *appsShim) CreateApp(params *apps.CreateAppParams) (*apps.CreateAppOK, error) {
 	subnetIds, err := parseSubnetIds(params.Body.Annotations)
 	if err != nil {
@@ -37,13 +48,23 @@ func (s *appsShim) CreateApp(params *apps.CreateAppParams) (*apps.CreateAppOK, e
 		return nil, err
 	}
 
+	settings, err := parseAppSettings(params.Body.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
 	details := functions.CreateApplicationDetails{
-		CompartmentId: &s.compartmentId,
-		DisplayName:   &params.Body.Name,
-		SubnetIds:     subnetIds,
-		Config:        params.Body.Config,
-		SyslogUrl:     params.Body.SyslogURL,
-		Shape:         shape,
+		CompartmentId:           s.compartment(params.Context),
+		DisplayName:             &params.Body.Name,
+		SubnetIds:               subnetIds,
+		Config:                  params.Body.Config,
+		SyslogUrl:               params.Body.SyslogURL,
+		Shape:                   shape,
+		NetworkSecurityGroupIds: settings.networkSecurityGroupIds,
+		TraceConfig:             settings.appTraceConfig,
+		FreeformTags:            settings.freeformTags,
+		DefinedTags:             settings.definedTags,
+		ImagePolicyConfig:       settings.imagePolicyConfig,
 	}
 
 	req := functions.CreateApplicationRequest{CreateApplicationDetails: details}
@@ -90,27 +111,23 @@ func (s *appsShim) ListApps(params *apps.ListAppsParams) (*apps.ListAppsOK, erro
 	}
 
 	req := functions.ListApplicationsRequest{
-		CompartmentId: &s.compartmentId,
+		CompartmentId: s.compartment(params.Context),
 		Limit:         limit,
 		Page:          params.Cursor,
 		DisplayName:   params.Name,
 	}
 
-	var applicationSummaries []functions.ApplicationSummary
-
-	for {
-		res, err := s.ociClient.ListApplications(ctxOrBackground(params.Context), req)
-		if err != nil {
-			return nil, err
-		}
+	// Only a single page is fetched per call, callers follow NextCursor (which maps onto OpcNextPage) for more
+	res, err := s.ociClient.ListApplications(ctxOrBackground(params.Context), req)
+	if err != nil {
+		return nil, err
+	}
 
-		applicationSummaries = append(applicationSummaries, res.Items...)
+	applicationSummaries := res.Items
 
-		if res.OpcNextPage != nil {
-			req.Page = res.OpcNextPage
-		} else {
-			break
-		}
+	var nextCursor string
+	if res.OpcNextPage != nil {
+		nextCursor = *res.OpcNextPage
 	}
 
 	var items []*modelsv2.App
@@ -136,7 +153,8 @@ func (s *appsShim) ListApps(params *apps.ListAppsParams) (*apps.ListAppsOK, erro
 
 	return &apps.ListAppsOK{
 		Payload: &modelsv2.AppList{
-			Items: items,
+			Items:      items,
+			NextCursor: nextCursor,
 		},
 	}, nil
 }
@@ -159,9 +177,19 @@ func (s *appsShim) UpdateApp(params *apps.UpdateAppParams) (*apps.UpdateAppOK, e
 		etag = res.Etag
 	}
 
+	settings, err := parseAppSettings(params.Body.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
 	details := functions.UpdateApplicationDetails{
-		Config:        params.Body.Config,
-		SyslogUrl:     params.Body.SyslogURL,
+		Config:                  params.Body.Config,
+		SyslogUrl:               params.Body.SyslogURL,
+		NetworkSecurityGroupIds: settings.networkSecurityGroupIds,
+		TraceConfig:             settings.appTraceConfig,
+		FreeformTags:            settings.freeformTags,
+		DefinedTags:             settings.definedTags,
+		ImagePolicyConfig:       settings.imagePolicyConfig,
 	}
 
 	req := functions.UpdateApplicationRequest{
@@ -214,8 +242,11 @@ func parseSubnetIds(annotations map[string]interface{}) ([]string, error) {
 
 func ociAppToV2(ociApp functions.Application) *modelsv2.App {
 	annotations := make(map[string]interface{})
-	annotations[annotationCompartmentId] = *ociApp.CompartmentId
+	annotations[AnnotationCompartmentId] = *ociApp.CompartmentId
 	annotations[annotationSubnet] = ociSubnetsToAnnotationValue(ociApp.SubnetIds)
+	setLifecycleState(annotations, string(ociApp.LifecycleState))
+	setAppSettingAnnotations(annotations, ociApp.NetworkSecurityGroupIds, ociApp.TraceConfig, ociApp.ImagePolicyConfig,
+		ociApp.FreeformTags, ociApp.DefinedTags)
 
 	return &modelsv2.App{
 		Annotations:   annotations,
@@ -231,8 +262,11 @@ func ociAppToV2(ociApp functions.Application) *modelsv2.App {
 
 func ociAppSummaryToV2(ociAppSummary functions.ApplicationSummary) *modelsv2.App {
 	annotations := make(map[string]interface{})
-	annotations[annotationCompartmentId] = *ociAppSummary.CompartmentId
+	annotations[AnnotationCompartmentId] = *ociAppSummary.CompartmentId
 	annotations[annotationSubnet] = ociSubnetsToAnnotationValue(ociAppSummary.SubnetIds)
+	setLifecycleState(annotations, string(ociAppSummary.LifecycleState))
+	setAppSettingAnnotations(annotations, ociAppSummary.NetworkSecurityGroupIds, ociAppSummary.TraceConfig,
+		ociAppSummary.ImagePolicyConfig, ociAppSummary.FreeformTags, ociAppSummary.DefinedTags)
 
 	return &modelsv2.App{
 		Annotations:   annotations,
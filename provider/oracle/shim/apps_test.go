@@ -35,7 +35,8 @@ func TestCreateApp(t *testing.T) {
 	assert.NoError(t, err)
 
 	expectedAnnotations := app.Annotations
-	expectedAnnotations[annotationCompartmentId] = compartmentId
+	expectedAnnotations[AnnotationCompartmentId] = compartmentId
+	expectedAnnotations[AnnotationLifecycleState] = "ACTIVE"
 
 	result := createAppOK.GetPayload()
 	assert.Equal(t, app.Name, result.Name)
@@ -80,7 +81,7 @@ func TestGetApp(t *testing.T) {
 	assert.NotEmpty(t, result.Name)
 	assert.NotEmpty(t, result.SyslogURL)
 	assert.NotEmpty(t, result.Annotations[annotationSubnet])
-	assert.NotEmpty(t, result.Annotations[annotationCompartmentId])
+	assert.NotEmpty(t, result.Annotations[AnnotationCompartmentId])
 	assert.NotEmpty(t, result.Config)
 	assert.NotEmpty(t, result.CreatedAt)
 	assert.NotEmpty(t, result.UpdatedAt)
@@ -111,7 +112,7 @@ func TestListAppsMultiplePages(t *testing.T) {
 	}
 	assert.Len(t, results, 9)
 	app := results[0]
-	assert.Equal(t, compartmentId, app.Annotations[annotationCompartmentId])
+	assert.Equal(t, compartmentId, app.Annotations[AnnotationCompartmentId])
 	assert.NotEmpty(t, app.ID)
 	assert.NotEmpty(t, app.Name)
 	assert.NotEmpty(t, app.Annotations[annotationSubnet])
@@ -7,14 +7,20 @@ import (
 
 // Interface extracted from Go SDK FunctionsManagementClient for mockability
 type FunctionsManagementClient interface {
+	ChangeApplicationCompartment(ctx context.Context, request functions.ChangeApplicationCompartmentRequest) (response functions.ChangeApplicationCompartmentResponse, err error)
 	CreateApplication(ctx context.Context, request functions.CreateApplicationRequest) (response functions.CreateApplicationResponse, err error)
 	CreateFunction(ctx context.Context, request functions.CreateFunctionRequest) (response functions.CreateFunctionResponse, err error)
 	DeleteApplication(ctx context.Context, request functions.DeleteApplicationRequest) (response functions.DeleteApplicationResponse, err error)
 	DeleteFunction(ctx context.Context, request functions.DeleteFunctionRequest) (response functions.DeleteFunctionResponse, err error)
 	GetApplication(ctx context.Context, request functions.GetApplicationRequest) (response functions.GetApplicationResponse, err error)
 	GetFunction(ctx context.Context, request functions.GetFunctionRequest) (response functions.GetFunctionResponse, err error)
+	GetPbfListing(ctx context.Context, request functions.GetPbfListingRequest) (response functions.GetPbfListingResponse, err error)
+	GetPbfListingVersion(ctx context.Context, request functions.GetPbfListingVersionRequest) (response functions.GetPbfListingVersionResponse, err error)
 	ListApplications(ctx context.Context, request functions.ListApplicationsRequest) (response functions.ListApplicationsResponse, err error)
 	ListFunctions(ctx context.Context, request functions.ListFunctionsRequest) (response functions.ListFunctionsResponse, err error)
+	ListPbfListingVersions(ctx context.Context, request functions.ListPbfListingVersionsRequest) (response functions.ListPbfListingVersionsResponse, err error)
+	ListPbfListings(ctx context.Context, request functions.ListPbfListingsRequest) (response functions.ListPbfListingsResponse, err error)
+	ListTriggers(ctx context.Context, request functions.ListTriggersRequest) (response functions.ListTriggersResponse, err error)
 	UpdateApplication(ctx context.Context, request functions.UpdateApplicationRequest) (response functions.UpdateApplicationResponse, err error)
 	UpdateFunction(ctx context.Context, request functions.UpdateFunctionRequest) (response functions.UpdateFunctionResponse, err error)
 }
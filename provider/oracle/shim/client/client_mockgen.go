@@ -34,6 +34,21 @@ func (m *MockFunctionsManagementClient) EXPECT() *MockFunctionsManagementClientM
 	return m.recorder
 }
 
+// ChangeApplicationCompartment mocks base method
+func (m *MockFunctionsManagementClient) ChangeApplicationCompartment(ctx context.Context, request functions.ChangeApplicationCompartmentRequest) (functions.ChangeApplicationCompartmentResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeApplicationCompartment", ctx, request)
+	ret0, _ := ret[0].(functions.ChangeApplicationCompartmentResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeApplicationCompartment indicates an expected call of ChangeApplicationCompartment
+func (mr *MockFunctionsManagementClientMockRecorder) ChangeApplicationCompartment(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeApplicationCompartment", reflect.TypeOf((*MockFunctionsManagementClient)(nil).ChangeApplicationCompartment), ctx, request)
+}
+
 // CreateApplication mocks base method
 func (m *MockFunctionsManagementClient) CreateApplication(ctx context.Context, request functions.CreateApplicationRequest) (functions.CreateApplicationResponse, error) {
 	m.ctrl.T.Helper()
@@ -124,6 +139,36 @@ func (mr *MockFunctionsManagementClientMockRecorder) GetFunction(ctx, request in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFunction", reflect.TypeOf((*MockFunctionsManagementClient)(nil).GetFunction), ctx, request)
 }
 
+// GetPbfListing mocks base method
+func (m *MockFunctionsManagementClient) GetPbfListing(ctx context.Context, request functions.GetPbfListingRequest) (functions.GetPbfListingResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPbfListing", ctx, request)
+	ret0, _ := ret[0].(functions.GetPbfListingResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPbfListing indicates an expected call of GetPbfListing
+func (mr *MockFunctionsManagementClientMockRecorder) GetPbfListing(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPbfListing", reflect.TypeOf((*MockFunctionsManagementClient)(nil).GetPbfListing), ctx, request)
+}
+
+// GetPbfListingVersion mocks base method
+func (m *MockFunctionsManagementClient) GetPbfListingVersion(ctx context.Context, request functions.GetPbfListingVersionRequest) (functions.GetPbfListingVersionResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPbfListingVersion", ctx, request)
+	ret0, _ := ret[0].(functions.GetPbfListingVersionResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPbfListingVersion indicates an expected call of GetPbfListingVersion
+func (mr *MockFunctionsManagementClientMockRecorder) GetPbfListingVersion(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPbfListingVersion", reflect.TypeOf((*MockFunctionsManagementClient)(nil).GetPbfListingVersion), ctx, request)
+}
+
 // ListApplications mocks base method
 func (m *MockFunctionsManagementClient) ListApplications(ctx context.Context, request functions.ListApplicationsRequest) (functions.ListApplicationsResponse, error) {
 	m.ctrl.T.Helper()
@@ -154,6 +199,51 @@ func (mr *MockFunctionsManagementClientMockRecorder) ListFunctions(ctx, request
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListFunctions", reflect.TypeOf((*MockFunctionsManagementClient)(nil).ListFunctions), ctx, request)
 }
 
+// ListPbfListingVersions mocks base method
+func (m *MockFunctionsManagementClient) ListPbfListingVersions(ctx context.Context, request functions.ListPbfListingVersionsRequest) (functions.ListPbfListingVersionsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPbfListingVersions", ctx, request)
+	ret0, _ := ret[0].(functions.ListPbfListingVersionsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPbfListingVersions indicates an expected call of ListPbfListingVersions
+func (mr *MockFunctionsManagementClientMockRecorder) ListPbfListingVersions(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPbfListingVersions", reflect.TypeOf((*MockFunctionsManagementClient)(nil).ListPbfListingVersions), ctx, request)
+}
+
+// ListPbfListings mocks base method
+func (m *MockFunctionsManagementClient) ListPbfListings(ctx context.Context, request functions.ListPbfListingsRequest) (functions.ListPbfListingsResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListPbfListings", ctx, request)
+	ret0, _ := ret[0].(functions.ListPbfListingsResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListPbfListings indicates an expected call of ListPbfListings
+func (mr *MockFunctionsManagementClientMockRecorder) ListPbfListings(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListPbfListings", reflect.TypeOf((*MockFunctionsManagementClient)(nil).ListPbfListings), ctx, request)
+}
+
+// ListTriggers mocks base method
+func (m *MockFunctionsManagementClient) ListTriggers(ctx context.Context, request functions.ListTriggersRequest) (functions.ListTriggersResponse, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTriggers", ctx, request)
+	ret0, _ := ret[0].(functions.ListTriggersResponse)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListTriggers indicates an expected call of ListTriggers
+func (mr *MockFunctionsManagementClientMockRecorder) ListTriggers(ctx, request interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTriggers", reflect.TypeOf((*MockFunctionsManagementClient)(nil).ListTriggers), ctx, request)
+}
+
 // UpdateApplication mocks base method
 func (m *MockFunctionsManagementClient) UpdateApplication(ctx context.Context, request functions.UpdateApplicationRequest) (functions.UpdateApplicationResponse, error) {
 	m.ctrl.T.Helper()
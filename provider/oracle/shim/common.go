@@ -2,7 +2,13 @@ package shim
 
 import "context"
 
-const annotationCompartmentId = "oracle.com/oci/compartmentId"
+const (
+	// AnnotationCompartmentId is the OCID of the compartment that an app or function lives in
+	AnnotationCompartmentId = "oracle.com/oci/compartmentId"
+
+	// AnnotationLifecycleState carries the OCI lifecycle state (e.g. CREATING, ACTIVE) of apps and functions
+	AnnotationLifecycleState = "oracle.com/oci/lifecycleState"
+)
 
 // OCI update config is wholesale replacement of the map. Here we do the FnV2 server-side merge on the client instead.
 // Based on https://github.com/fnproject/fn/blob/d55e01ab7d565e9796748f2f40662e94394aff07/api/models/fn.go#L274-L285
@@ -29,3 +35,10 @@ func ctxOrBackground(ctx context.Context) context.Context {
 	}
 	return ctx
 }
+
+// setLifecycleState records an OCI lifecycle state in annotations, unknown states are left out
+func setLifecycleState(annotations map[string]interface{}, state string) {
+	if state != "" {
+		annotations[AnnotationLifecycleState] = state
+	}
+}
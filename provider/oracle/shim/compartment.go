@@ -0,0 +1,23 @@
+package shim
+
+import (
+	"context"
+
+	"github.com/fnproject/fn_go/provider/oracle/shim/client"
+	"github.com/oracle/oci-go-sdk/v65/functions"
+)
+
+// ChangeAppCompartment asks OCI to move an application, along with its functions, into another compartment. The move
+// is asynchronous: the application is UPDATING until it has completed.
+func ChangeAppCompartment(ctx context.Context, ociClient client.FunctionsManagementClient, appID, compartmentID string) error {
+	req := functions.ChangeApplicationCompartmentRequest{
+		ApplicationId:                       &appID,
+		ChangeApplicationCompartmentDetails: functions.ChangeApplicationCompartmentDetails{CompartmentId: &compartmentID},
+	}
+
+	_, err := ociClient.ChangeApplicationCompartment(ctxOrBackground(ctx), req)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package shim
+
+import (
+	"fmt"
+
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/oracle/oci-go-sdk/v65/functions"
+)
+
+const (
+	// AnnotationProvisionedConcurrency holds the provisioned concurrency of a function, e.g.
+	// {"strategy": "CONSTANT", "count": 20}. Use GetProvisionedConcurrency and SetProvisionedConcurrency to access it.
+	AnnotationProvisionedConcurrency = "oracle.com/oci/provisionedConcurrencyConfig"
+
+	// ProvisionedConcurrencyNone disables provisioned concurrency
+	ProvisionedConcurrencyNone = string(functions.FunctionProvisionedConcurrencyConfigStrategyNone)
+	// ProvisionedConcurrencyConstant keeps a constant number of provisioned concurrency units warm
+	ProvisionedConcurrencyConstant = string(functions.FunctionProvisionedConcurrencyConfigStrategyConstant)
+)
+
+// ProvisionedConcurrency is the provisioned concurrency setting of an Oracle function
+type ProvisionedConcurrency struct {
+	// Strategy is ProvisionedConcurrencyNone or ProvisionedConcurrencyConstant
+	Strategy string `json:"strategy"`
+	// Count is the number of provisioned concurrency units for the CONSTANT strategy
+	Count int `json:"count,omitempty"`
+}
+
+// Validate checks that the setting can be sent to OCI
+func (pc *ProvisionedConcurrency) Validate() error {
+	switch pc.Strategy {
+	case ProvisionedConcurrencyNone:
+		if pc.Count != 0 {
+			return fmt.Errorf("provisioned concurrency count must not be set for strategy %s", ProvisionedConcurrencyNone)
+		}
+	case ProvisionedConcurrencyConstant:
+		if pc.Count <= 0 {
+			return fmt.Errorf("provisioned concurrency count must be positive for strategy %s", ProvisionedConcurrencyConstant)
+		}
+	default:
+		return fmt.Errorf("unknown provisioned concurrency strategy %q, expected %s or %s", pc.Strategy,
+			ProvisionedConcurrencyNone, ProvisionedConcurrencyConstant)
+	}
+	return nil
+}
+
+// GetProvisionedConcurrency returns the provisioned concurrency of fn, or nil if it is not known (e.g. because fn did
+// not come from the Oracle provider)
+func GetProvisionedConcurrency(fn *modelsv2.Fn) (*ProvisionedConcurrency, error) {
+	if fn == nil {
+		return nil, nil
+	}
+	return parseProvisionedConcurrency(fn.Annotations)
+}
+
+// SetProvisionedConcurrency validates pc and records it in fn's annotations so that it is applied when fn is created
+// or updated. A nil pc disables provisioned concurrency.
+func SetProvisionedConcurrency(fn *modelsv2.Fn, pc *ProvisionedConcurrency) error {
+	if pc == nil {
+		pc = &ProvisionedConcurrency{Strategy: ProvisionedConcurrencyNone}
+	}
+	if err := pc.Validate(); err != nil {
+		return err
+	}
+	if fn.Annotations == nil {
+		fn.Annotations = map[string]interface{}{}
+	}
+	encodeAnnotation(fn.Annotations, AnnotationProvisionedConcurrency, pc)
+	return nil
+}
+
+func parseProvisionedConcurrency(annotations map[string]interface{}) (*ProvisionedConcurrency, error) {
+	pc := &ProvisionedConcurrency{}
+	ok, err := decodeAnnotation(annotations, AnnotationProvisionedConcurrency, pc)
+	if err != nil || !ok {
+		return nil, err
+	}
+	if cleared(annotations[AnnotationProvisionedConcurrency]) {
+		pc.Strategy = ProvisionedConcurrencyNone
+	}
+	if err := pc.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %s", AnnotationProvisionedConcurrency, err)
+	}
+	return pc, nil
+}
+
+// ociProvisionedConcurrency converts the provisioned concurrency annotation into its OCI form, returning nil if it is
+// not set
+func ociProvisionedConcurrency(annotations map[string]interface{}) (functions.FunctionProvisionedConcurrencyConfig, error) {
+	pc, err := parseProvisionedConcurrency(annotations)
+	if err != nil || pc == nil {
+		return nil, err
+	}
+	if pc.Strategy == ProvisionedConcurrencyConstant {
+		count := pc.Count
+		return functions.ConstantProvisionedConcurrencyConfig{Count: &count}, nil
+	}
+	return functions.NoneProvisionedConcurrencyConfig{}, nil
+}
+
+// setProvisionedConcurrencyAnnotation records an OCI provisioned concurrency config in annotations
+func setProvisionedConcurrencyAnnotation(annotations map[string]interface{}, config functions.FunctionProvisionedConcurrencyConfig) {
+	var pc *ProvisionedConcurrency
+	switch c := config.(type) {
+	case functions.ConstantProvisionedConcurrencyConfig:
+		pc = &ProvisionedConcurrency{Strategy: ProvisionedConcurrencyConstant}
+		if c.Count != nil {
+			pc.Count = *c.Count
+		}
+	case *functions.ConstantProvisionedConcurrencyConfig:
+		pc = &ProvisionedConcurrency{Strategy: ProvisionedConcurrencyConstant}
+		if c != nil && c.Count != nil {
+			pc.Count = *c.Count
+		}
+	case functions.NoneProvisionedConcurrencyConfig, *functions.NoneProvisionedConcurrencyConfig:
+		pc = &ProvisionedConcurrency{Strategy: ProvisionedConcurrencyNone}
+	default:
+		return
+	}
+	encodeAnnotation(annotations, AnnotationProvisionedConcurrency, pc)
+}
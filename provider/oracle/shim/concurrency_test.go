@@ -0,0 +1,61 @@
+package shim
+
+import (
+	"testing"
+
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider/oracle/ocitest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvisionedConcurrencyValidation(t *testing.T) {
+	fn := &modelsv2.Fn{}
+	assert.Error(t, SetProvisionedConcurrency(fn, &ProvisionedConcurrency{Strategy: ProvisionedConcurrencyConstant}))
+	assert.Error(t, SetProvisionedConcurrency(fn, &ProvisionedConcurrency{Strategy: ProvisionedConcurrencyNone, Count: 10}))
+	assert.Error(t, SetProvisionedConcurrency(fn, &ProvisionedConcurrency{Strategy: "SOMETIMES"}))
+	assert.Empty(t, fn.Annotations)
+
+	fn.Annotations = map[string]interface{}{AnnotationProvisionedConcurrency: map[string]interface{}{"strategy": "CONSTANT", "count": -1}}
+	_, err := GetProvisionedConcurrency(fn)
+	assert.Error(t, err)
+
+	_, err = NewFnsShim(ocitest.NewClient()).CreateFn(&fns.CreateFnParams{Body: &modelsv2.Fn{Name: "InvalidPC", Annotations: fn.Annotations}})
+	assert.Error(t, err, "invalid settings should be rejected before calling OCI")
+}
+
+func TestProvisionedConcurrencyRoundTrip(t *testing.T) {
+	c := ocitest.NewClient()
+	app, err := NewAppsShim(c, "PCCompartment").CreateApp(&apps.CreateAppParams{Body: &modelsv2.App{
+		Name:        "PCApp",
+		Annotations: map[string]interface{}{annotationSubnet: []interface{}{"PCSubnet"}},
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	shim := NewFnsShim(c)
+	fn := &modelsv2.Fn{AppID: app.Payload.ID, Name: "PCFn", Image: "PCImage"}
+	assert.NoError(t, SetProvisionedConcurrency(fn, &ProvisionedConcurrency{Strategy: ProvisionedConcurrencyConstant, Count: 20}))
+
+	created, err := shim.CreateFn(&fns.CreateFnParams{Body: fn})
+	if !assert.NoError(t, err) {
+		return
+	}
+	pc, err := GetProvisionedConcurrency(created.Payload)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &ProvisionedConcurrency{Strategy: ProvisionedConcurrencyConstant, Count: 20}, pc)
+	}
+
+	update := &modelsv2.Fn{}
+	assert.NoError(t, SetProvisionedConcurrency(update, nil))
+	updated, err := shim.UpdateFn(&fns.UpdateFnParams{FnID: created.Payload.ID, Body: update})
+	if !assert.NoError(t, err) {
+		return
+	}
+	pc, err = GetProvisionedConcurrency(updated.Payload)
+	if assert.NoError(t, err) {
+		assert.Equal(t, &ProvisionedConcurrency{Strategy: ProvisionedConcurrencyNone}, pc)
+	}
+}
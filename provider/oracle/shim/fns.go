@@ -40,14 +40,38 @@ func (s *fnsShim) CreateFn(params *fns.CreateFnParams) (*fns.CreateFnOK, error)
 		return nil, err
 	}
 
+	settings, err := parseFnSettings(params.Body.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := parseSourceDetails(params.Body.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pre-built functions are deployed from their listing and have no image
+	image := &params.Body.Image
+	if source != nil {
+		if params.Body.Image != "" {
+			return nil, fmt.Errorf("function %s cannot have both an image and a %s annotation", params.Body.Name, AnnotationPbfListingId)
+		}
+		image = nil
+	}
+
 	details := functions.CreateFunctionDetails{
-		DisplayName:      &params.Body.Name,
-		ApplicationId:    &params.Body.AppID,
-		Image:            &params.Body.Image,
-		MemoryInMBs:      &memory,
-		ImageDigest:      digest,
-		Config:           params.Body.Config,
-		TimeoutInSeconds: parseTimeout(params.Body.Timeout),
+		DisplayName:                  &params.Body.Name,
+		ApplicationId:                &params.Body.AppID,
+		Image:                        image,
+		SourceDetails:                source,
+		MemoryInMBs:                  &memory,
+		ImageDigest:                  digest,
+		Config:                       params.Body.Config,
+		TimeoutInSeconds:             parseTimeout(params.Body.Timeout),
+		TraceConfig:                  settings.fnTraceConfig,
+		FreeformTags:                 settings.freeformTags,
+		DefinedTags:                  settings.definedTags,
+		ProvisionedConcurrencyConfig: settings.provisionedConcurrency,
 	}
 
 	req := functions.CreateFunctionRequest{CreateFunctionDetails: details}
@@ -100,21 +124,17 @@ func (s *fnsShim) ListFns(params *fns.ListFnsParams) (*fns.ListFnsOK, error) {
 		DisplayName:   params.Name,
 	}
 
-	var functionSummaries []functions.FunctionSummary
-
-	for {
-		res, err := s.ociClient.ListFunctions(ctxOrBackground(params.Context), req)
-		if err != nil {
-			return nil, err
-		}
+	// Only a single page is fetched per call, callers follow NextCursor (which maps onto OpcNextPage) for more
+	res, err := s.ociClient.ListFunctions(ctxOrBackground(params.Context), req)
+	if err != nil {
+		return nil, err
+	}
 
-		functionSummaries = append(functionSummaries, res.Items...)
+	functionSummaries := res.Items
 
-		if res.OpcNextPage != nil {
-			req.Page = res.OpcNextPage
-		} else {
-			break
-		}
+	var nextCursor string
+	if res.OpcNextPage != nil {
+		nextCursor = *res.OpcNextPage
 	}
 
 	var items []*modelsv2.Fn
@@ -140,7 +160,8 @@ func (s *fnsShim) ListFns(params *fns.ListFnsParams) (*fns.ListFnsOK, error) {
 
 	return &fns.ListFnsOK{
 		Payload: &modelsv2.FnList{
-			Items: items,
+			Items:      items,
+			NextCursor: nextCursor,
 		},
 	}, nil
 }
@@ -179,12 +200,21 @@ func (s *fnsShim) UpdateFn(params *fns.UpdateFnParams) (*fns.UpdateFnOK, error)
 		return nil, err
 	}
 
+	settings, err := parseFnSettings(params.Body.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
 	details := functions.UpdateFunctionDetails{
-		Image:            imagePtr,
-		ImageDigest:      digest,
-		MemoryInMBs:      memoryPtr,
-		Config:           params.Body.Config,
-		TimeoutInSeconds: parseTimeout(params.Body.Timeout),
+		Image:                        imagePtr,
+		ImageDigest:                  digest,
+		MemoryInMBs:                  memoryPtr,
+		Config:                       params.Body.Config,
+		TimeoutInSeconds:             parseTimeout(params.Body.Timeout),
+		TraceConfig:                  settings.fnTraceConfig,
+		FreeformTags:                 settings.freeformTags,
+		DefinedTags:                  settings.definedTags,
+		ProvisionedConcurrencyConfig: settings.provisionedConcurrency,
 	}
 
 	req := functions.UpdateFunctionRequest{
@@ -240,9 +270,9 @@ func parseDigestAnnotation(annotations map[string]interface{}) (*string, error)
 func ociFnToV2(ociFn functions.Function) *modelsv2.Fn {
 	annotations := make(map[string]interface{})
 	invokeEndpoint := fmt.Sprintf(invokeEndpointFmtString, *ociFn.InvokeEndpoint, *ociFn.Id)
-	annotations[annotationCompartmentId] = *ociFn.CompartmentId
+	annotations[AnnotationCompartmentId] = *ociFn.CompartmentId
 
-	// For pbf functions image and its digest will be always empty
+	// For pbf functions image and its digest will be always empty, the listing they come from is annotated instead
 	imageDigest := ""
 	if ociFn.ImageDigest != nil {
 		imageDigest = *ociFn.ImageDigest
@@ -255,6 +285,9 @@ func ociFnToV2(ociFn functions.Function) *modelsv2.Fn {
 
 	annotations[annotationImageDigest] = imageDigest
 	annotations[annotationInvokeEndpoint] = invokeEndpoint
+	setLifecycleState(annotations, string(ociFn.LifecycleState))
+	setFnSettingAnnotations(annotations, ociFn.TraceConfig, ociFn.ProvisionedConcurrencyConfig, ociFn.FreeformTags, ociFn.DefinedTags)
+	setSourceAnnotations(annotations, ociFn.SourceDetails)
 
 	var timeoutPtr *int32
 	if ociFn.TimeoutInSeconds != nil {
@@ -272,7 +305,7 @@ func ociFnToV2(ociFn functions.Function) *modelsv2.Fn {
 		Memory:      uint64(*ociFn.MemoryInMBs),
 		Name:        *ociFn.DisplayName,
 		Timeout:     timeoutPtr,
-		Shape: 		 string(ociFn.Shape),
+		Shape:       string(ociFn.Shape),
 		UpdatedAt:   strfmt.DateTime(ociFn.TimeUpdated.Time),
 	}
 }
@@ -280,9 +313,9 @@ func ociFnToV2(ociFn functions.Function) *modelsv2.Fn {
 func ociFnSummaryToV2(ociFnSummary functions.FunctionSummary) *modelsv2.Fn {
 	annotations := make(map[string]interface{})
 	invokeEndpoint := fmt.Sprintf(invokeEndpointFmtString, *ociFnSummary.InvokeEndpoint, *ociFnSummary.Id)
-	annotations[annotationCompartmentId] = *ociFnSummary.CompartmentId
+	annotations[AnnotationCompartmentId] = *ociFnSummary.CompartmentId
 
-	// For pbf functions image and its digest will be always empty
+	// For pbf functions image and its digest will be always empty, the listing they come from is annotated instead
 	imageDigest := ""
 	if ociFnSummary.ImageDigest != nil {
 		imageDigest = *ociFnSummary.ImageDigest
@@ -295,6 +328,10 @@ func ociFnSummaryToV2(ociFnSummary functions.FunctionSummary) *modelsv2.Fn {
 
 	annotations[annotationImageDigest] = imageDigest
 	annotations[annotationInvokeEndpoint] = invokeEndpoint
+	setLifecycleState(annotations, string(ociFnSummary.LifecycleState))
+	setFnSettingAnnotations(annotations, ociFnSummary.TraceConfig, ociFnSummary.ProvisionedConcurrencyConfig,
+		ociFnSummary.FreeformTags, ociFnSummary.DefinedTags)
+	setSourceAnnotations(annotations, ociFnSummary.SourceDetails)
 
 	var timeoutPtr *int32
 	if ociFnSummary.TimeoutInSeconds != nil {
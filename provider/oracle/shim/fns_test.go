@@ -40,7 +40,8 @@ func TestCreateFn(t *testing.T) {
 	result := createFnOK.GetPayload()
 
 	expectedAnnotations := fn.Annotations
-	expectedAnnotations[annotationCompartmentId] = "CreateFunctionCompartment"
+	expectedAnnotations[AnnotationCompartmentId] = "CreateFunctionCompartment"
+	expectedAnnotations[AnnotationLifecycleState] = "ACTIVE"
 	expectedAnnotations[annotationInvokeEndpoint] = fmt.Sprintf("CreateFunctionInvokeEndpoint/20181201/functions/%s/actions/invoke", result.ID)
 
 	assert.Equal(t, fn.Name, result.Name)
@@ -90,7 +91,7 @@ func TestGetFn(t *testing.T) {
 	assert.NotEmpty(t, result.Image)
 	assert.NotEmpty(t, result.Annotations[annotationImageDigest])
 	assert.NotEmpty(t, result.Annotations[annotationInvokeEndpoint])
-	assert.NotEmpty(t, result.Annotations[annotationCompartmentId])
+	assert.NotEmpty(t, result.Annotations[AnnotationCompartmentId])
 	assert.NotEmpty(t, result.Config)
 	assert.NotEmpty(t, result.CreatedAt)
 	assert.NotEmpty(t, result.UpdatedAt)
@@ -129,7 +130,7 @@ func TestListFnsMultiplePages(t *testing.T) {
 	assert.NotEmpty(t, fn.Image)
 	assert.NotEmpty(t, fn.Annotations[annotationImageDigest])
 	assert.NotEmpty(t, fn.Annotations[annotationInvokeEndpoint])
-	assert.NotEmpty(t, fn.Annotations[annotationCompartmentId])
+	assert.NotEmpty(t, fn.Annotations[AnnotationCompartmentId])
 	assert.NotEmpty(t, fn.CreatedAt)
 	assert.NotEmpty(t, fn.UpdatedAt)
 }
@@ -0,0 +1,364 @@
+package shim
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider/oracle/shim/client"
+	"github.com/oracle/oci-go-sdk/v65/functions"
+)
+
+// AnnotationPbfListingId is the OCID of the pre-built function (PBF) listing that a function is deployed from. Setting
+// it when creating a function deploys the current version of the listing instead of an image; it is reported on reads
+// of such functions and cannot be changed once the function exists.
+const AnnotationPbfListingId = "oracle.com/oci/pbfListingId"
+
+// PbfListing is an entry in the catalog of pre-built functions
+type PbfListing struct {
+	ID             string
+	Name           string
+	Description    string
+	Publisher      string
+	Triggers       []string
+	LifecycleState string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// PbfListingVersion is a single published version of a pre-built function
+type PbfListingVersion struct {
+	ID             string
+	ListingID      string
+	Name           string
+	ChangeSummary  string
+	Requirements   PbfRequirements
+	Config         []PbfConfigKey
+	Triggers       []string
+	LifecycleState string
+	CreatedAt      time.Time
+}
+
+// PbfRequirements describes what a pre-built function needs in order to run
+type PbfRequirements struct {
+	// MinMemoryMB is the minimum memory that functions deployed from the version must have
+	MinMemoryMB int64
+	// Policies are the IAM policies that must be in place for the function to work
+	Policies []PbfPolicy
+}
+
+// PbfPolicy is an IAM policy statement required by a pre-built function
+type PbfPolicy struct {
+	Policy      string
+	Description string
+}
+
+// PbfConfigKey is a configuration key read by a pre-built function
+type PbfConfigKey struct {
+	Key         string
+	Description string
+	Optional    bool
+}
+
+// PbfListingFilter narrows down a catalog search, empty fields match everything
+type PbfListingFilter struct {
+	// Name matches listings with exactly this name
+	Name string
+	// NameContains matches listings whose name contains this string, ignoring case
+	NameContains string
+	// NameStartsWith matches listings whose name starts with this string, ignoring case
+	NameStartsWith string
+	// Triggers matches listings that support all of these triggers (e.g. "HTTP")
+	Triggers []string
+	// LifecycleState matches listings in this state (e.g. ACTIVE)
+	LifecycleState string
+}
+
+// PbfCatalog browses the catalog of pre-built functions available in a region
+type PbfCatalog struct {
+	ociClient client.FunctionsManagementClient
+}
+
+func NewPbfCatalog(ociClient client.FunctionsManagementClient) *PbfCatalog {
+	return &PbfCatalog{ociClient: ociClient}
+}
+
+// ListListings returns all listings that match filter (which may be nil) ordered by name
+func (c *PbfCatalog) ListListings(ctx context.Context, filter *PbfListingFilter) ([]*PbfListing, error) {
+	if filter == nil {
+		filter = &PbfListingFilter{}
+	}
+	req := functions.ListPbfListingsRequest{
+		Name:           optionalString(filter.Name),
+		NameContains:   optionalString(filter.NameContains),
+		NameStartsWith: optionalString(filter.NameStartsWith),
+		Trigger:        filter.Triggers,
+		LifecycleState: functions.PbfListingLifecycleStateEnum(filter.LifecycleState),
+		SortBy:         functions.ListPbfListingsSortByName,
+		SortOrder:      functions.ListPbfListingsSortOrderAsc,
+	}
+
+	var result []*PbfListing
+	for {
+		res, err := c.ociClient.ListPbfListings(ctxOrBackground(ctx), req)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range res.Items {
+			result = append(result, ociPbfListingSummary(item))
+		}
+		if res.OpcNextPage == nil {
+			return result, nil
+		}
+		req.Page = res.OpcNextPage
+	}
+}
+
+// ListTriggers returns the names of the triggers (e.g. "HTTP") that listings can be searched by
+func (c *PbfCatalog) ListTriggers(ctx context.Context) ([]string, error) {
+	req := functions.ListTriggersRequest{SortOrder: functions.ListTriggersSortOrderAsc}
+
+	var result []string
+	for {
+		res, err := c.ociClient.ListTriggers(ctxOrBackground(ctx), req)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range res.Items {
+			if item.Name != nil {
+				result = append(result, *item.Name)
+			}
+		}
+		if res.OpcNextPage == nil {
+			return result, nil
+		}
+		req.Page = res.OpcNextPage
+	}
+}
+
+// GetListing returns the listing with the given ID
+func (c *PbfCatalog) GetListing(ctx context.Context, listingID string) (*PbfListing, error) {
+	res, err := c.ociClient.GetPbfListing(ctxOrBackground(ctx), functions.GetPbfListingRequest{PbfListingId: &listingID})
+	if err != nil {
+		return nil, err
+	}
+	return ociPbfListing(res.PbfListing), nil
+}
+
+// ListVersions returns all versions of a listing, newest first
+func (c *PbfCatalog) ListVersions(ctx context.Context, listingID string) ([]*PbfListingVersion, error) {
+	return c.listVersions(ctx, functions.ListPbfListingVersionsRequest{PbfListingId: &listingID})
+}
+
+// CurrentVersion returns the version of a listing that is deployed when a function is created from it
+func (c *PbfCatalog) CurrentVersion(ctx context.Context, listingID string) (*PbfListingVersion, error) {
+	current := true
+	versions, err := c.listVersions(ctx, functions.ListPbfListingVersionsRequest{PbfListingId: &listingID, IsCurrentVersion: &current})
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("pre-built function listing %s has no current version", listingID)
+	}
+	return versions[0], nil
+}
+
+func (c *PbfCatalog) listVersions(ctx context.Context, req functions.ListPbfListingVersionsRequest) ([]*PbfListingVersion, error) {
+	req.SortBy = functions.ListPbfListingVersionsSortByTimecreated
+	req.SortOrder = functions.ListPbfListingVersionsSortOrderDesc
+
+	var result []*PbfListingVersion
+	for {
+		res, err := c.ociClient.ListPbfListingVersions(ctxOrBackground(ctx), req)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range res.Items {
+			result = append(result, ociPbfListingVersionSummary(item))
+		}
+		if res.OpcNextPage == nil {
+			return result, nil
+		}
+		req.Page = res.OpcNextPage
+	}
+}
+
+// GetVersion returns the listing version with the given ID
+func (c *PbfCatalog) GetVersion(ctx context.Context, versionID string) (*PbfListingVersion, error) {
+	res, err := c.ociClient.GetPbfListingVersion(ctxOrBackground(ctx), functions.GetPbfListingVersionRequest{PbfListingVersionId: &versionID})
+	if err != nil {
+		return nil, err
+	}
+	return ociPbfListingVersion(res.PbfListingVersion), nil
+}
+
+// PrepareFn sets fn up to be created from a listing version with Fns.CreateFn. OCI always deploys the current version
+// of a listing, so versionID must be the current version. The function's memory defaults to the version's minimum and
+// every non-optional config key of the version must be set in fn.Config.
+func (c *PbfCatalog) PrepareFn(ctx context.Context, versionID string, fn *modelsv2.Fn) error {
+	version, err := c.GetVersion(ctx, versionID)
+	if err != nil {
+		return err
+	}
+	current, err := c.CurrentVersion(ctx, version.ListingID)
+	if err != nil {
+		return err
+	}
+	if current.ID != version.ID {
+		return fmt.Errorf("pre-built function version %s is not the current version (%s) of listing %s and cannot be deployed",
+			version.Name, current.Name, version.ListingID)
+	}
+
+	if fn.Image != "" {
+		return fmt.Errorf("function %s cannot have both an image and a pre-built function source", fn.Name)
+	}
+	if fn.Memory == 0 && version.Requirements.MinMemoryMB > 0 {
+		fn.Memory = uint64(version.Requirements.MinMemoryMB)
+	}
+	if int64(fn.Memory) < version.Requirements.MinMemoryMB {
+		return fmt.Errorf("pre-built function %s requires at least %d MB of memory", version.Name, version.Requirements.MinMemoryMB)
+	}
+	for _, key := range version.Config {
+		if !key.Optional && fn.Config[key.Key] == "" {
+			return fmt.Errorf("pre-built function %s requires config key %s (%s)", version.Name, key.Key, key.Description)
+		}
+	}
+
+	if fn.Annotations == nil {
+		fn.Annotations = map[string]interface{}{}
+	}
+	fn.Annotations[AnnotationPbfListingId] = version.ListingID
+	return nil
+}
+
+// parseSourceDetails reads the function source from annotations, a nil result means the function is built from an image
+func parseSourceDetails(annotations map[string]interface{}) (functions.FunctionSourceDetails, error) {
+	value, ok := annotations[AnnotationPbfListingId]
+	if !ok || cleared(value) {
+		return nil, nil
+	}
+	listingID, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid %s annotation: expected a string", AnnotationPbfListingId)
+	}
+	return functions.PreBuiltFunctionSourceDetails{PbfListingId: &listingID}, nil
+}
+
+// setSourceAnnotations records where a function that is not built from an image comes from
+func setSourceAnnotations(annotations map[string]interface{}, source functions.FunctionSourceDetails) {
+	switch s := source.(type) {
+	case functions.PreBuiltFunctionSourceDetails:
+		if s.PbfListingId != nil {
+			annotations[AnnotationPbfListingId] = *s.PbfListingId
+		}
+	case *functions.PreBuiltFunctionSourceDetails:
+		if s != nil && s.PbfListingId != nil {
+			annotations[AnnotationPbfListingId] = *s.PbfListingId
+		}
+	}
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func ociTriggerNames(triggers []functions.Trigger) []string {
+	var names []string
+	for _, t := range triggers {
+		if t.Name != nil {
+			names = append(names, *t.Name)
+		}
+	}
+	return names
+}
+
+func ociPbfListing(l functions.PbfListing) *PbfListing {
+	return ociPbfListingSummary(functions.PbfListingSummary{
+		Id:               l.Id,
+		Name:             l.Name,
+		Description:      l.Description,
+		PublisherDetails: l.PublisherDetails,
+		TimeCreated:      l.TimeCreated,
+		TimeUpdated:      l.TimeUpdated,
+		LifecycleState:   l.LifecycleState,
+		Triggers:         l.Triggers,
+	})
+}
+
+func ociPbfListingSummary(l functions.PbfListingSummary) *PbfListing {
+	listing := &PbfListing{
+		ID:             stringValue(l.Id),
+		Name:           stringValue(l.Name),
+		Description:    stringValue(l.Description),
+		Triggers:       ociTriggerNames(l.Triggers),
+		LifecycleState: string(l.LifecycleState),
+	}
+	if l.PublisherDetails != nil {
+		listing.Publisher = stringValue(l.PublisherDetails.Name)
+	}
+	if l.TimeCreated != nil {
+		listing.CreatedAt = l.TimeCreated.Time
+	}
+	if l.TimeUpdated != nil {
+		listing.UpdatedAt = l.TimeUpdated.Time
+	}
+	return listing
+}
+
+func ociPbfListingVersion(v functions.PbfListingVersion) *PbfListingVersion {
+	return ociPbfListingVersionSummary(functions.PbfListingVersionSummary{
+		Id:             v.Id,
+		PbfListingId:   v.PbfListingId,
+		Name:           v.Name,
+		Requirements:   v.Requirements,
+		ChangeSummary:  v.ChangeSummary,
+		Triggers:       v.Triggers,
+		TimeCreated:    v.TimeCreated,
+		LifecycleState: v.LifecycleState,
+		Config:         v.Config,
+	})
+}
+
+func ociPbfListingVersionSummary(v functions.PbfListingVersionSummary) *PbfListingVersion {
+	version := &PbfListingVersion{
+		ID:             stringValue(v.Id),
+		ListingID:      stringValue(v.PbfListingId),
+		Name:           stringValue(v.Name),
+		ChangeSummary:  stringValue(v.ChangeSummary),
+		Triggers:       ociTriggerNames(v.Triggers),
+		LifecycleState: string(v.LifecycleState),
+	}
+	if v.Requirements != nil {
+		if v.Requirements.MinMemoryRequiredInMBs != nil {
+			version.Requirements.MinMemoryMB = *v.Requirements.MinMemoryRequiredInMBs
+		}
+		for _, p := range v.Requirements.Policies {
+			version.Requirements.Policies = append(version.Requirements.Policies, PbfPolicy{
+				Policy:      stringValue(p.Policy),
+				Description: stringValue(p.Description),
+			})
+		}
+	}
+	for _, c := range v.Config {
+		version.Config = append(version.Config, PbfConfigKey{
+			Key:         stringValue(c.Key),
+			Description: stringValue(c.Description),
+			Optional:    c.IsOptional != nil && *c.IsOptional,
+		})
+	}
+	if v.TimeCreated != nil {
+		version.CreatedAt = v.TimeCreated.Time
+	}
+	return version
+}
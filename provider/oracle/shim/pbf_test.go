@@ -0,0 +1,131 @@
+package shim
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider/oracle/ocitest"
+	"github.com/oracle/oci-go-sdk/v65/functions"
+	"github.com/stretchr/testify/assert"
+)
+
+func addPbfListing(c *ocitest.Client, name string, triggers ...string) string {
+	listing := functions.PbfListing{Name: &name}
+	for i := range triggers {
+		listing.Triggers = append(listing.Triggers, functions.Trigger{Name: &triggers[i]})
+	}
+	return c.AddPbfListing(listing)
+}
+
+func addPbfVersion(c *ocitest.Client, listingID, name string, memory int64, config ...functions.ConfigDetails) string {
+	return c.AddPbfListingVersion(functions.PbfListingVersion{
+		PbfListingId: &listingID,
+		Name:         &name,
+		Requirements: &functions.RequirementDetails{MinMemoryRequiredInMBs: &memory},
+		Config:       config,
+	})
+}
+
+func TestPbfCatalog(t *testing.T) {
+	c := ocitest.NewClient()
+	ctx := context.Background()
+	resize := addPbfListing(c, "Image Resize", "HTTP")
+	addPbfListing(c, "Document Translation", "HTTP", "Object Storage")
+	addPbfVersion(c, resize, "1.0.0", 256)
+	newest := addPbfVersion(c, resize, "1.1.0", 512)
+
+	catalog := NewPbfCatalog(c)
+
+	all, err := catalog.ListListings(ctx, nil)
+	if assert.NoError(t, err) && assert.Len(t, all, 2) {
+		assert.Equal(t, "Document Translation", all[0].Name, "listings should be ordered by name")
+		assert.Equal(t, []string{"HTTP", "Object Storage"}, all[0].Triggers)
+	}
+
+	found, err := catalog.ListListings(ctx, &PbfListingFilter{NameStartsWith: "image"})
+	if assert.NoError(t, err) && assert.Len(t, found, 1) {
+		assert.Equal(t, resize, found[0].ID)
+	}
+	found, err = catalog.ListListings(ctx, &PbfListingFilter{Triggers: []string{"Object Storage"}})
+	if assert.NoError(t, err) && assert.Len(t, found, 1) {
+		assert.Equal(t, "Document Translation", found[0].Name)
+	}
+
+	listing, err := catalog.GetListing(ctx, resize)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Image Resize", listing.Name)
+		assert.Equal(t, "ACTIVE", listing.LifecycleState)
+	}
+
+	versions, err := catalog.ListVersions(ctx, resize)
+	if assert.NoError(t, err) && assert.Len(t, versions, 2) {
+		assert.Equal(t, int64(512), versions[0].Requirements.MinMemoryMB)
+	}
+
+	current, err := catalog.CurrentVersion(ctx, resize)
+	if assert.NoError(t, err) {
+		assert.Equal(t, newest, current.ID)
+	}
+
+	_, err = catalog.GetListing(ctx, "ocid1.fnpbflisting.oc1.ocitest.missing")
+	assert.Error(t, err)
+}
+
+func TestCreateFnFromPbf(t *testing.T) {
+	c := ocitest.NewClient()
+	ctx := context.Background()
+	listingID := addPbfListing(c, "Translate")
+	old := addPbfVersion(c, listingID, "1.0.0", 256)
+	optional := true
+	current := addPbfVersion(c, listingID, "2.0.0", 512,
+		functions.ConfigDetails{Key: &[]string{"TARGET_LANGUAGE"}[0], Description: &[]string{"language to translate to"}[0]},
+		functions.ConfigDetails{Key: &[]string{"LOG_LEVEL"}[0], Description: &[]string{"log level"}[0], IsOptional: &optional},
+	)
+
+	app, err := NewAppsShim(c, "PbfCompartment").CreateApp(&apps.CreateAppParams{Body: &modelsv2.App{
+		Name:        "PbfApp",
+		Annotations: map[string]interface{}{annotationSubnet: []interface{}{"PbfSubnet"}},
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	catalog := NewPbfCatalog(c)
+	fn := &modelsv2.Fn{AppID: app.Payload.ID, Name: "Translate"}
+	assert.Error(t, catalog.PrepareFn(ctx, old, fn), "only the current version can be deployed")
+	assert.Error(t, catalog.PrepareFn(ctx, current, fn), "required config must be set")
+
+	fn.Config = map[string]string{"TARGET_LANGUAGE": "fr"}
+	fn.Memory = 128
+	assert.Error(t, catalog.PrepareFn(ctx, current, fn), "memory below the minimum should be rejected")
+
+	fn.Memory = 0
+	if !assert.NoError(t, catalog.PrepareFn(ctx, current, fn)) {
+		return
+	}
+	assert.Equal(t, uint64(512), fn.Memory)
+
+	shim := NewFnsShim(c)
+	created, err := shim.CreateFn(&fns.CreateFnParams{Body: fn})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Empty(t, created.Payload.Image)
+	assert.Equal(t, listingID, created.Payload.Annotations[AnnotationPbfListingId])
+
+	listed, err := shim.ListFns(&fns.ListFnsParams{AppID: &app.Payload.ID})
+	if assert.NoError(t, err) && assert.Len(t, listed.Payload.Items, 1) {
+		assert.Equal(t, listingID, listed.Payload.Items[0].Annotations[AnnotationPbfListingId])
+	}
+
+	_, err = shim.CreateFn(&fns.CreateFnParams{Body: &modelsv2.Fn{
+		AppID:       app.Payload.ID,
+		Name:        "Both",
+		Image:       "fnproject/hello",
+		Annotations: map[string]interface{}{AnnotationPbfListingId: listingID},
+	}})
+	assert.Error(t, err, "a function can't have both an image and a PBF source")
+}
@@ -1,21 +1,52 @@
 package shim
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/fnproject/fn_go/clientv2/triggers"
+	"github.com/fnproject/fn_go/fnerrors"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider/oracle/shim/client"
 	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/functions"
 )
 
-type triggersShim struct{}
+const (
+	// AnnotationTriggerName is the name of the OCI trigger (e.g. "Object Storage") that a trigger was mapped from
+	AnnotationTriggerName = "oracle.com/oci/triggerName"
+
+	// triggerIDSeparator joins the function ID and the trigger name that make up a trigger ID
+	triggerIDSeparator = ".trigger."
+)
+
+// OCI has no trigger resources of its own, triggers are the event sources that a pre-built function listing can be
+// wired up to. They are mapped onto read-only Fn triggers of the functions deployed from those listings; functions built
+// from an image have no triggers.
+type triggersShim struct {
+	ociClient client.FunctionsManagementClient
+}
 
 var _ triggers.ClientService = &triggersShim{}
 
-var triggersUnsupportedErr = fmt.Errorf("HTTP Triggers are not supported on Oracle Functions")
+var triggersUnsupportedErr = fnerrors.NewUnsupported("Triggers are read-only on Oracle Functions")
 
+// NewTriggersShim returns a triggers client that has no OCI client to read triggers with, every call fails as unsupported.
+// Use NewTriggersShimWithClient to map the triggers of pre-built functions.
 func NewTriggersShim() triggers.ClientService {
 	return &triggersShim{}
 }
 
+// NewTriggersShimWithClient returns a read-only triggers client that maps the triggers of functions deployed from
+// pre-built function listings
+func NewTriggersShimWithClient(ociClient client.FunctionsManagementClient) triggers.ClientService {
+	return &triggersShim{ociClient: ociClient}
+}
+
 func (*triggersShim) CreateTrigger(*triggers.CreateTriggerParams) (*triggers.CreateTriggerOK, error) {
 	return nil, triggersUnsupportedErr
 }
@@ -24,12 +55,90 @@ func (*triggersShim) DeleteTrigger(*triggers.DeleteTriggerParams) (*triggers.Del
 	return nil, triggersUnsupportedErr
 }
 
-func (*triggersShim) GetTrigger(*triggers.GetTriggerParams) (*triggers.GetTriggerOK, error) {
-	return nil, triggersUnsupportedErr
+func (s *triggersShim) GetTrigger(params *triggers.GetTriggerParams) (*triggers.GetTriggerOK, error) {
+	if s.ociClient == nil {
+		return nil, triggersUnsupportedErr
+	}
+	notFound := &fnerrors.Error{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("Trigger %s not found", params.TriggerID)}
+
+	sep := strings.LastIndex(params.TriggerID, triggerIDSeparator)
+	if sep < 0 {
+		return nil, notFound
+	}
+	fnID := params.TriggerID[:sep]
+
+	ctx := ctxOrBackground(params.Context)
+	res, err := s.ociClient.GetFunction(ctx, functions.GetFunctionRequest{FunctionId: &fnID})
+	if err != nil {
+		return nil, err
+	}
+
+	fnTriggers, err := s.fnTriggers(ctx, ociFnTriggerSource(res.Function), map[string][]functions.Trigger{})
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range fnTriggers {
+		if t.ID == params.TriggerID {
+			return &triggers.GetTriggerOK{Payload: t}, nil
+		}
+	}
+	return nil, notFound
 }
 
-func (*triggersShim) ListTriggers(*triggers.ListTriggersParams) (*triggers.ListTriggersOK, error) {
-	return nil, triggersUnsupportedErr
+// ListTriggers lists the triggers of a function, or of every function in an app. All matching triggers are returned in
+// a single page.
+func (s *triggersShim) ListTriggers(params *triggers.ListTriggersParams) (*triggers.ListTriggersOK, error) {
+	if s.ociClient == nil {
+		return nil, triggersUnsupportedErr
+	}
+	ctx := ctxOrBackground(params.Context)
+
+	var sources []triggerSource
+	switch {
+	case params.FnID != nil:
+		res, err := s.ociClient.GetFunction(ctx, functions.GetFunctionRequest{FunctionId: params.FnID})
+		if err != nil {
+			return nil, err
+		}
+		if params.AppID == nil || *params.AppID == *res.ApplicationId {
+			sources = append(sources, ociFnTriggerSource(res.Function))
+		}
+	case params.AppID != nil:
+		req := functions.ListFunctionsRequest{ApplicationId: params.AppID}
+		for {
+			res, err := s.ociClient.ListFunctions(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range res.Items {
+				sources = append(sources, ociFnSummaryTriggerSource(f))
+			}
+			if res.OpcNextPage == nil {
+				break
+			}
+			req.Page = res.OpcNextPage
+		}
+	default:
+		return nil, fmt.Errorf("an app or function ID is required to list triggers")
+	}
+
+	listingTriggers := map[string][]functions.Trigger{}
+	var items []*modelsv2.Trigger
+	for _, source := range sources {
+		fnTriggers, err := s.fnTriggers(ctx, source, listingTriggers)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range fnTriggers {
+			if params.Name == nil || *params.Name == t.Name {
+				items = append(items, t)
+			}
+		}
+	}
+
+	return &triggers.ListTriggersOK{
+		Payload: &modelsv2.TriggerList{Items: items},
+	}, nil
 }
 
 func (*triggersShim) UpdateTrigger(*triggers.UpdateTriggerParams) (*triggers.UpdateTriggerOK, error) {
@@ -37,3 +146,86 @@ func (*triggersShim) UpdateTrigger(*triggers.UpdateTriggerParams) (*triggers.Upd
 }
 
 func (*triggersShim) SetTransport(runtime.ClientTransport) {}
+
+// triggerSource holds the fields of a function (or function summary) that its triggers are built from
+type triggerSource struct {
+	fnID          string
+	appID         string
+	sourceDetails functions.FunctionSourceDetails
+	timeCreated   *common.SDKTime
+	timeUpdated   *common.SDKTime
+}
+
+func ociFnTriggerSource(f functions.Function) triggerSource {
+	return triggerSource{fnID: *f.Id, appID: *f.ApplicationId, sourceDetails: f.SourceDetails, timeCreated: f.TimeCreated, timeUpdated: f.TimeUpdated}
+}
+
+func ociFnSummaryTriggerSource(f functions.FunctionSummary) triggerSource {
+	return triggerSource{fnID: *f.Id, appID: *f.ApplicationId, sourceDetails: f.SourceDetails, timeCreated: f.TimeCreated, timeUpdated: f.TimeUpdated}
+}
+
+// fnTriggers maps the triggers of the listing that a function was deployed from onto Fn triggers, listingTriggers
+// caches the triggers of listings that have already been fetched
+func (s *triggersShim) fnTriggers(ctx context.Context, source triggerSource, listingTriggers map[string][]functions.Trigger) ([]*modelsv2.Trigger, error) {
+	annotations := map[string]interface{}{}
+	setSourceAnnotations(annotations, source.sourceDetails)
+	listingID, ok := annotations[AnnotationPbfListingId].(string)
+	if !ok {
+		return nil, nil
+	}
+
+	ociTriggers, ok := listingTriggers[listingID]
+	if !ok {
+		res, err := s.ociClient.GetPbfListing(ctx, functions.GetPbfListingRequest{PbfListingId: &listingID})
+		if err != nil {
+			return nil, err
+		}
+		ociTriggers = res.Triggers
+		listingTriggers[listingID] = ociTriggers
+	}
+
+	var result []*modelsv2.Trigger
+	for _, t := range ociTriggers {
+		if t.Name == nil {
+			continue
+		}
+		name := triggerName(*t.Name)
+		trigger := &modelsv2.Trigger{
+			ID:    source.fnID + triggerIDSeparator + name,
+			AppID: source.appID,
+			FnID:  source.fnID,
+			Name:  name,
+			Type:  name,
+			Annotations: map[string]interface{}{
+				AnnotationTriggerName:  *t.Name,
+				AnnotationPbfListingId: listingID,
+			},
+		}
+		if source.timeCreated != nil {
+			trigger.CreatedAt = strfmt.DateTime(source.timeCreated.Time)
+		}
+		if source.timeUpdated != nil {
+			trigger.UpdatedAt = strfmt.DateTime(source.timeUpdated.Time)
+		}
+		result = append(result, trigger)
+	}
+	return result, nil
+}
+
+// triggerName converts an OCI trigger name such as "Object Storage" into an Fn-style name such as "object-storage"
+func triggerName(ociName string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(ociName) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package shim
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/clientv2/triggers"
+	"github.com/fnproject/fn_go/fnerrors"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider/oracle/ocitest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadOnlyTriggers(t *testing.T) {
+	c := ocitest.NewClient()
+	listingID := addPbfListing(c, "Object Upload", "HTTP", "Object Storage")
+	addPbfVersion(c, listingID, "1.0.0", 128)
+
+	app, err := NewAppsShim(c, "TriggerCompartment").CreateApp(&apps.CreateAppParams{Body: &modelsv2.App{
+		Name:        "TriggerApp",
+		Annotations: map[string]interface{}{annotationSubnet: []interface{}{"TriggerSubnet"}},
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	fnsShim := NewFnsShim(c)
+	pbf, err := fnsShim.CreateFn(&fns.CreateFnParams{Body: &modelsv2.Fn{
+		AppID:       app.Payload.ID,
+		Name:        "Upload",
+		Annotations: map[string]interface{}{AnnotationPbfListingId: listingID},
+	}})
+	if !assert.NoError(t, err) {
+		return
+	}
+	image, err := fnsShim.CreateFn(&fns.CreateFnParams{Body: &modelsv2.Fn{AppID: app.Payload.ID, Name: "Image", Image: "fnproject/hello"}})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	shim := NewTriggersShimWithClient(c)
+
+	all, err := shim.ListTriggers(&triggers.ListTriggersParams{AppID: &app.Payload.ID})
+	if assert.NoError(t, err) && assert.Len(t, all.Payload.Items, 2) {
+		trigger := all.Payload.Items[1]
+		assert.Equal(t, "object-storage", trigger.Name)
+		assert.Equal(t, pbf.Payload.ID, trigger.FnID)
+		assert.Equal(t, app.Payload.ID, trigger.AppID)
+		assert.Equal(t, "Object Storage", trigger.Annotations[AnnotationTriggerName])
+
+		got, err := shim.GetTrigger(&triggers.GetTriggerParams{TriggerID: trigger.ID})
+		if assert.NoError(t, err) {
+			assert.Equal(t, trigger, got.Payload)
+		}
+	}
+
+	name := "http"
+	byName, err := shim.ListTriggers(&triggers.ListTriggersParams{AppID: &app.Payload.ID, FnID: &pbf.Payload.ID, Name: &name})
+	if assert.NoError(t, err) && assert.Len(t, byName.Payload.Items, 1) {
+		assert.Equal(t, "http", byName.Payload.Items[0].Type)
+	}
+
+	none, err := shim.ListTriggers(&triggers.ListTriggersParams{FnID: &image.Payload.ID})
+	if assert.NoError(t, err) {
+		assert.Empty(t, none.Payload.Items, "functions built from an image have no triggers")
+	}
+
+	_, err = shim.GetTrigger(&triggers.GetTriggerParams{TriggerID: image.Payload.ID + triggerIDSeparator + "http"})
+	assert.True(t, fnerrors.IsNotFound(err))
+
+	_, err = shim.CreateTrigger(&triggers.CreateTriggerParams{Body: &modelsv2.Trigger{Name: "new"}})
+	assert.True(t, fnerrors.IsUnsupported(err))
+	_, err = shim.DeleteTrigger(&triggers.DeleteTriggerParams{TriggerID: "id"})
+	assert.True(t, fnerrors.IsUnsupported(err))
+
+	names, err := NewPbfCatalog(c).ListTriggers(context.Background())
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"HTTP", "Object Storage"}, names)
+	}
+}
+
+func TestTriggersShimWithoutClient(t *testing.T) {
+	shim := NewTriggersShim()
+	appID := "app"
+
+	_, err := shim.ListTriggers(&triggers.ListTriggersParams{AppID: &appID})
+	assert.True(t, fnerrors.IsUnsupported(err))
+	_, err = shim.GetTrigger(&triggers.GetTriggerParams{TriggerID: "fn" + triggerIDSeparator + "http"})
+	assert.True(t, fnerrors.IsUnsupported(err))
+}
+
+func TestTriggerName(t *testing.T) {
+	assert.Equal(t, "http", triggerName("HTTP"))
+	assert.Equal(t, "object-storage", triggerName("Object Storage"))
+	assert.Equal(t, "oci-events-service", triggerName(" OCI Events  (Service) "))
+}
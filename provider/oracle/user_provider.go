@@ -1,11 +1,8 @@
 package oracle
 
 import (
-	"crypto/x509"
-	"encoding/pem"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
@@ -41,6 +38,11 @@ func NewFromConfig(configSource provider.ConfigSource, passphraseSource provider
 		return nil, err
 	}
 
+	retryPolicy, err := configureRetries(configSource, &ociClient)
+	if err != nil {
+		return nil, err
+	}
+
 	disableCerts := configSource.GetBool(CfgDisableCerts)
 	if disableCerts {
 		c := ociClient.HTTPClient.(*http.Client)
@@ -75,16 +77,35 @@ func NewFromConfig(configSource provider.ConfigSource, passphraseSource provider
 		CompartmentID:         compartmentID,
 		ImageCompartmentID:    configSource.GetString(CfgImageCompartmentID),
 		ConfigurationProvider: configProvider,
+		RetryPolicy:           retryPolicy,
 		ociClient:             ociClient,
 	}, nil
 }
 
+// oracleEnvVars are the OCI CLI environment variables that take precedence over the equivalent Fn config keys
+var oracleEnvVars = map[string]string{
+	CfgProfile:     OCI_CLI_PROFILE_ENV_VAR,
+	CfgTenancyID:   OCI_CLI_TENANCY_ENV_VAR,
+	CfgUserID:      OCI_CLI_USER_ENV_VAR,
+	CfgFingerprint: OCI_CLI_FINGERPRINT_ENV_VAR,
+	CfgKeyFile:     OCI_CLI_KEY_FILE_ENV_VAR,
+}
+
+// oracleSettings layers the OCI CLI environment variables over the Fn config, anything that neither of them sets is
+// read from the profile in the OCI config file
+func oracleSettings(config provider.ConfigSource) *provider.LayeredConfigSource {
+	return provider.NewLayeredConfigSource(
+		provider.NewConfigSourceLayer("Fn config", config),
+		provider.NewEnvVarsConfigLayer(oracleEnvVars),
+	)
+}
+
 func loadOracleConfig(config provider.ConfigSource, passphraseSource provider.PassPhraseSource) (oci.ConfigurationProvider, error) {
-	var oracleProfile string
 	var err error
 	var cf oci.ConfigurationProvider
 
-	oracleProfile = getEnv(OCI_CLI_PROFILE_ENV_VAR, config.GetString(CfgProfile))
+	settings := oracleSettings(config)
+	oracleProfile := settings.GetString(CfgProfile)
 
 	if oracleProfile == "" {
 		oracleProfile = "DEFAULT"
@@ -104,53 +125,62 @@ func loadOracleConfig(config provider.ConfigSource, passphraseSource provider.Pa
 		}
 	}
 
-	var tenancyID string
-	if tenancyID = getEnv(OCI_CLI_TENANCY_ENV_VAR, config.GetString(CfgTenancyID)); tenancyID == "" {
-		if cf == nil {
-			return nil, errors.New("unable to find tenancyID in environment or configuration.")
+	// setting returns a value from the environment or the Fn config, falling back to the OCI config file
+	setting := func(key string, fromFile func() (string, error)) (string, error) {
+		if value := settings.GetString(key); value != "" {
+			return value, nil
 		}
-		tenancyID, err = cf.TenancyOCID()
-		if err != nil {
-			return nil, err
+		if cf == nil {
+			return "", fmt.Errorf("unable to find %s in environment or configuration: set %s or %s, or create profile %s in %s", key, oracleEnvVars[key], key, oracleProfile, path)
 		}
-
+		return fromFile()
 	}
 
-	var userID string
-	if userID = getEnv(OCI_CLI_USER_ENV_VAR, config.GetString(CfgUserID)); userID == "" {
-		if cf == nil {
-			return nil, errors.New("unable to find userID in environment or configuration.")
-		}
-		userID, err = cf.UserOCID()
-		if err != nil {
-			return nil, err
+	// profiles created by `oci session authenticate` sign with a session token rather than as a user
+	securityTokenFile := settings.GetString(CfgSecurityTokenFile)
+	if securityTokenFile == "" && cf != nil {
+		if configFile, err := LoadOCIConfigFile(path); err == nil {
+			if profile, ok := configFile.Profile(oracleProfile); ok {
+				securityTokenFile = profile[ProfileSecurityTokenFile]
+			}
 		}
 	}
 
-	var fingerprint string
-	if fingerprint = getEnv(OCI_CLI_FINGERPRINT_ENV_VAR, config.GetString(CfgFingerprint)); fingerprint == "" {
-		if cf == nil {
-			return nil, errors.New("unable to find fingerprint in environment or configuration.")
-		}
-		fingerprint, err = cf.KeyFingerprint()
+	tenancyID, err := setting(CfgTenancyID, func() (string, error) { return cf.TenancyOCID() })
+	if err != nil {
+		return nil, err
+	}
+	var userID string
+	if securityTokenFile == "" {
+		userID, err = setting(CfgUserID, func() (string, error) { return cf.UserOCID() })
 		if err != nil {
 			return nil, err
 		}
 	}
+	fingerprint, err := setting(CfgFingerprint, func() (string, error) { return cf.KeyFingerprint() })
+	if err != nil {
+		return nil, err
+	}
 
-	var keyFile string
+	var privateKey string
 	var passphrase *string
-	if keyFile = getEnv(OCI_CLI_KEY_FILE_ENV_VAR, config.GetString(CfgKeyFile)); keyFile != "" {
-		isEncrypted, err := isPrivateKeyEncrypted(keyFile)
+	keyFile := settings.Lookup(CfgKeyFile)
+	if keyFile.Value != "" {
+		keyBytes, format, err := readPrivateKey(keyFile.Value)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%s (%s)", err, keyFile)
 		}
+		privateKey = string(keyBytes)
 
-		if isEncrypted {
-			passphrase, err = getPrivateKeyPassphrase(config, passphraseSource, keyFile)
+		if format.Encrypted() {
+			passphrase, err = getPrivateKeyPassphrase(config, passphraseSource, keyFile.Value)
 			if err != nil {
 				return nil, err
 			}
+			// report a wrong passphrase now rather than on the first request
+			if _, err := oci.PrivateKeyFromBytes(keyBytes, passphrase); err != nil {
+				return nil, fmt.Errorf("unable to decrypt private key: %s (%s)", err, keyFile)
+			}
 		}
 	}
 
@@ -173,27 +203,22 @@ func loadOracleConfig(config provider.ConfigSource, passphraseSource provider.Pa
 		region = ""
 	}
 
-	overrideConfigProvider := oci.NewRawConfigurationProvider(tenancyID, userID, region, fingerprint, keyFile, passphrase)
+	overrideConfigProvider := oci.NewRawConfigurationProvider(tenancyID, userID, region, fingerprint, privateKey, passphrase)
 
 	// We use a composing configuration provider, so that values set by env vars or Fn context take precedence over OCI config file
-	return oci.ComposingConfigurationProvider([]oci.ConfigurationProvider{
-		overrideConfigProvider,
-		cf,
-	})
-}
-
-func isPrivateKeyEncrypted(pkeyFilePath string) (bool, error) {
-	keyBytes, err := ioutil.ReadFile(pkeyFilePath)
+	providers := []oci.ConfigurationProvider{overrideConfigProvider}
+	if cf != nil {
+		providers = append(providers, cf)
+	}
+	composed, err := oci.ComposingConfigurationProvider(providers)
 	if err != nil {
-		return false, fmt.Errorf("Unable to read private key from file due to error: %s\n", err)
+		return nil, err
 	}
 
-	pemBlock, _ := pem.Decode(keyBytes)
-	if pemBlock != nil {
-		return false, fmt.Errorf("unable to decode private key file as PEM")
+	if securityTokenFile != "" {
+		return newSessionTokenConfigurationProvider(composed, oracleProfile, securityTokenFile)
 	}
-
-	return x509.IsEncryptedPEMBlock(pemBlock), nil
+	return composed, nil
 }
 
 func getPrivateKeyPassphrase(config provider.ConfigSource, passphraseSource provider.PassPhraseSource, pkeyFilePath string) (*string, error) {
@@ -202,6 +227,7 @@ func getPrivateKeyPassphrase(config provider.ConfigSource, passphraseSource prov
 		return &passphrase, nil
 	}
 
+	// the prompt names the key file, so passphrases cached per challenge are not reused for other keys
 	passphrase, err := passphraseSource.ChallengeForPassPhrase("oracle.privateKey", fmt.Sprintf("Enter passphrase for private key %s", pkeyFilePath))
 	return &passphrase, err
 }
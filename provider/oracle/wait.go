@@ -0,0 +1,187 @@
+package oracle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/fnerrors"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider"
+	"github.com/fnproject/fn_go/provider/oracle/shim"
+)
+
+// OCI lifecycle states of applications and functions, as reported by LifecycleState
+const (
+	LifecycleStateCreating = "CREATING"
+	LifecycleStateActive   = "ACTIVE"
+	LifecycleStateInactive = "INACTIVE"
+	LifecycleStateUpdating = "UPDATING"
+	LifecycleStateDeleting = "DELETING"
+	LifecycleStateDeleted  = "DELETED"
+	LifecycleStateFailed   = "FAILED"
+
+	// AnnotationLifecycleState is the app and function annotation that holds the OCI lifecycle state
+	AnnotationLifecycleState = shim.AnnotationLifecycleState
+
+	defaultWaitInitialInterval = time.Second
+	defaultWaitMaxInterval     = 10 * time.Second
+)
+
+// LifecycleState returns the OCI lifecycle state recorded in a resource's annotations, or "" if there is none (as is
+// the case for resources that do not come from OCI)
+func LifecycleState(annotations map[string]interface{}) string {
+	state, _ := annotations[AnnotationLifecycleState].(string)
+	return state
+}
+
+// AppLifecycleState returns the OCI lifecycle state of app
+func AppLifecycleState(app *modelsv2.App) string {
+	if app == nil {
+		return ""
+	}
+	return LifecycleState(app.Annotations)
+}
+
+// FnLifecycleState returns the OCI lifecycle state of fn
+func FnLifecycleState(fn *modelsv2.Fn) string {
+	if fn == nil {
+		return ""
+	}
+	return LifecycleState(fn.Annotations)
+}
+
+// WaitOptions controls how often the wait helpers poll, the total time spent waiting is bounded by the context
+type WaitOptions struct {
+	// InitialInterval is the delay before the first re-check, it doubles after every poll (default 1s)
+	InitialInterval time.Duration
+	// MaxInterval caps the delay between polls (default 10s)
+	MaxInterval time.Duration
+}
+
+func (o *WaitOptions) intervals() (time.Duration, time.Duration) {
+	initial, max := defaultWaitInitialInterval, defaultWaitMaxInterval
+	if o != nil && o.InitialInterval > 0 {
+		initial = o.InitialInterval
+	}
+	if o != nil && o.MaxInterval > 0 {
+		max = o.MaxInterval
+	}
+	if max < initial {
+		max = initial
+	}
+	return initial, max
+}
+
+// poll calls check until it reports done, returns an error or ctx expires
+func poll(ctx context.Context, opts *WaitOptions, check func() (bool, error)) error {
+	interval, max := opts.intervals()
+	for {
+		done, err := check()
+		if err != nil || done {
+			return err
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		interval *= 2
+		if interval > max {
+			interval = max
+		}
+	}
+}
+
+// checkActive decides whether a resource in the given state is ready. Resources without a state are treated as
+// ready as they come from providers that have no provisioning step.
+func checkActive(kind, id, state string) (bool, error) {
+	switch state {
+	case "", LifecycleStateActive:
+		return true, nil
+	case LifecycleStateFailed, LifecycleStateDeleting, LifecycleStateDeleted, LifecycleStateInactive:
+		return false, fmt.Errorf("%s %s is %s and will not become %s", kind, id, state, LifecycleStateActive)
+	}
+	return false, nil
+}
+
+// WaitForAppActive polls an application until it is ACTIVE and returns it. It fails straight away if the application
+// ends up FAILED, INACTIVE or being deleted, and otherwise waits until ctx is done.
+func WaitForAppActive(ctx context.Context, client *clientv2.Fn, appID string, opts *WaitOptions) (*modelsv2.App, error) {
+	var app *modelsv2.App
+	err := poll(ctx, opts, func() (bool, error) {
+		res, err := client.Apps.GetApp(apps.NewGetAppParams().WithContext(ctx).WithAppID(appID))
+		if err != nil {
+			return false, err
+		}
+		app = res.Payload
+		return checkActive("app", appID, AppLifecycleState(app))
+	})
+	if err != nil {
+		return nil, waitError(ctx, err, "app", appID, LifecycleStateActive, AppLifecycleState(app))
+	}
+	return app, nil
+}
+
+// WaitForFnActive polls a function until it is ACTIVE and returns it. It fails straight away if the function ends up
+// FAILED, INACTIVE or being deleted, and otherwise waits until ctx is done.
+func WaitForFnActive(ctx context.Context, client *clientv2.Fn, fnID string, opts *WaitOptions) (*modelsv2.Fn, error) {
+	var fn *modelsv2.Fn
+	err := poll(ctx, opts, func() (bool, error) {
+		res, err := client.Fns.GetFn(fns.NewGetFnParams().WithContext(ctx).WithFnID(fnID))
+		if err != nil {
+			return false, err
+		}
+		fn = res.Payload
+		return checkActive("function", fnID, FnLifecycleState(fn))
+	})
+	if err != nil {
+		return nil, waitError(ctx, err, "function", fnID, LifecycleStateActive, FnLifecycleState(fn))
+	}
+	return fn, nil
+}
+
+// WaitForDeleted polls an application or function until it no longer exists or is DELETED
+func WaitForDeleted(ctx context.Context, client *clientv2.Fn, resourceType provider.FnResourceType, id string, opts *WaitOptions) error {
+	var state string
+	err := poll(ctx, opts, func() (bool, error) {
+		var err error
+		switch resourceType {
+		case provider.ApplicationResourceType:
+			var res *apps.GetAppOK
+			if res, err = client.Apps.GetApp(apps.NewGetAppParams().WithContext(ctx).WithAppID(id)); err == nil {
+				state = AppLifecycleState(res.Payload)
+			}
+		case provider.FunctionResourceType:
+			var res *fns.GetFnOK
+			if res, err = client.Fns.GetFn(fns.NewGetFnParams().WithContext(ctx).WithFnID(id)); err == nil {
+				state = FnLifecycleState(res.Payload)
+			}
+		default:
+			return false, fmt.Errorf("waiting for %s resources is not supported", resourceType)
+		}
+		if fnerrors.IsNotFound(err) {
+			return true, nil
+		}
+		return state == LifecycleStateDeleted, err
+	})
+	if err != nil {
+		return waitError(ctx, err, resourceType.String(), id, LifecycleStateDeleted, state)
+	}
+	return nil
+}
+
+func waitError(ctx context.Context, err error, kind, id, target, lastState string) error {
+	if ctxErr := ctx.Err(); ctxErr != nil && errors.Is(err, ctxErr) {
+		return fmt.Errorf("gave up waiting for %s %s to be %s (last state %q): %w", kind, id, target, lastState, err)
+	}
+	return err
+}
@@ -0,0 +1,84 @@
+package oracle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider"
+	"github.com/fnproject/fn_go/provider/oracle/ocitest"
+	"github.com/fnproject/fn_go/provider/oracle/shim"
+)
+
+func newSimulatedClient(delay time.Duration) *clientv2.Fn {
+	c := ocitest.NewClient()
+	c.TransitionDelay = delay
+	return &clientv2.Fn{
+		Apps:     shim.NewAppsShim(c, "ocid1.compartment.oc1..waittest"),
+		Fns:      shim.NewFnsShim(c),
+		Triggers: shim.NewTriggersShimWithClient(c),
+	}
+}
+
+func TestWaitForActiveAndDeleted(t *testing.T) {
+	client := newSimulatedClient(30 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	opts := &WaitOptions{InitialInterval: 5 * time.Millisecond, MaxInterval: 20 * time.Millisecond}
+
+	created, err := client.Apps.CreateApp(apps.NewCreateAppParams().WithBody(&modelsv2.App{
+		Name:        "myapp",
+		Annotations: map[string]interface{}{"oracle.com/oci/subnetIds": []interface{}{"subnet"}},
+	}))
+	if err != nil {
+		t.Fatalf("create app failed: %s", err)
+	}
+	if state := AppLifecycleState(created.Payload); state != LifecycleStateCreating {
+		t.Errorf("expected a new app to be %s, got %q", LifecycleStateCreating, state)
+	}
+
+	app, err := WaitForAppActive(ctx, client, created.Payload.ID, opts)
+	if err != nil {
+		t.Fatalf("waiting for app failed: %s", err)
+	}
+	if state := AppLifecycleState(app); state != LifecycleStateActive {
+		t.Errorf("expected app to be %s, got %q", LifecycleStateActive, state)
+	}
+
+	fn, err := client.Fns.CreateFn(fns.NewCreateFnParams().WithBody(&modelsv2.Fn{AppID: app.ID, Name: "hello", Image: "fnproject/hello"}))
+	if err != nil {
+		t.Fatalf("create fn failed: %s", err)
+	}
+	if _, err := WaitForFnActive(ctx, client, fn.Payload.ID, opts); err != nil {
+		t.Fatalf("waiting for fn failed: %s", err)
+	}
+
+	if _, err := client.Fns.DeleteFn(fns.NewDeleteFnParams().WithFnID(fn.Payload.ID)); err != nil {
+		t.Fatalf("delete fn failed: %s", err)
+	}
+	if err := WaitForDeleted(ctx, client, provider.FunctionResourceType, fn.Payload.ID, opts); err != nil {
+		t.Fatalf("waiting for fn deletion failed: %s", err)
+	}
+}
+
+func TestWaitRespectsDeadline(t *testing.T) {
+	client := newSimulatedClient(time.Hour)
+	created, err := client.Apps.CreateApp(apps.NewCreateAppParams().WithBody(&modelsv2.App{
+		Name:        "myapp",
+		Annotations: map[string]interface{}{"oracle.com/oci/subnetIds": []interface{}{"subnet"}},
+	}))
+	if err != nil {
+		t.Fatalf("create app failed: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = WaitForAppActive(ctx, client, created.Payload.ID, &WaitOptions{InitialInterval: 10 * time.Millisecond})
+	if err == nil || ctx.Err() == nil {
+		t.Fatalf("expected the wait to time out, got %v", err)
+	}
+}
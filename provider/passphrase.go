@@ -24,6 +24,9 @@ func (*NopPassPhraseSource) ChallengeForPassPhrase(id, msg string) (string, erro
 }
 
 func (*TerminalPassPhraseSource) ChallengeForPassPhrase(id, msg string) (string, error) {
+	if !terminal.IsTerminal(int(syscall.Stdin)) {
+		return "", errors.New("unable to prompt for a pass phrase, stdin is not a terminal")
+	}
 	fmt.Print(msg)
 	bytePassword, err := terminal.ReadPassword(int(syscall.Stdin))
 	if err != nil {
@@ -0,0 +1,32 @@
+//go:build !windows
+// +build !windows
+
+package provider
+
+import (
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestFDPassPhraseSource(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("from fd"))
+	w.Close()
+	// the source closes the descriptor it reads, so it is given a duplicate that nothing else will close again
+	dup, err := syscall.Dup(int(r.Fd()))
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fd := &FDPassPhraseSource{FD: uintptr(dup)}
+	for i := 0; i < 2; i++ {
+		if got, err := fd.ChallengeForPassPhrase("id", "prompt"); err != nil || got != "from fd" {
+			t.Errorf("expected the passphrase from the pipe on challenge %d, got %q %v", i, got, err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+const (
+	// EnvPassPhraseID and EnvPassPhrasePrompt are set in the environment of a passphrase command, so that one command
+	// can answer for several keys
+	EnvPassPhraseID     = "FN_PASSPHRASE_ID"
+	EnvPassPhrasePrompt = "FN_PASSPHRASE_PROMPT"
+)
+
+// EnvPassPhraseSource reads the passphrase from an environment variable
+type EnvPassPhraseSource struct {
+	Var string
+}
+
+func (s *EnvPassPhraseSource) ChallengeForPassPhrase(id, prompt string) (string, error) {
+	passphrase, ok := os.LookupEnv(s.Var)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", s.Var)
+	}
+	return passphrase, nil
+}
+
+// FilePassPhraseSource reads the passphrase from the first line of a file, such as a mounted secret
+type FilePassPhraseSource struct {
+	Path string
+}
+
+func (s *FilePassPhraseSource) ChallengeForPassPhrase(id, prompt string) (string, error) {
+	path, err := homedir.Expand(s.Path)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read passphrase: %s", err)
+	}
+	defer f.Close()
+	return readPassPhraseLine(f.Name(), bufio.NewReader(f))
+}
+
+// FDPassPhraseSource reads the passphrase from the first line of an open file descriptor, such as a pipe set up by
+// the parent process. The descriptor can only be read once, so the passphrase is kept for later challenges.
+type FDPassPhraseSource struct {
+	FD uintptr
+
+	once       sync.Once
+	passphrase string
+	err        error
+}
+
+func (s *FDPassPhraseSource) ChallengeForPassPhrase(id, prompt string) (string, error) {
+	s.once.Do(func() {
+		f := os.NewFile(s.FD, fmt.Sprintf("fd %d", s.FD))
+		if f == nil {
+			s.err = fmt.Errorf("invalid file descriptor %d", s.FD)
+			return
+		}
+		defer f.Close()
+		s.passphrase, s.err = readPassPhraseLine(f.Name(), bufio.NewReader(f))
+	})
+	return s.passphrase, s.err
+}
+
+// CommandPassPhraseSource runs a command and reads the passphrase from the first line of its stdout. The id and prompt
+// of the challenge are passed to the command in FN_PASSPHRASE_ID and FN_PASSPHRASE_PROMPT. The command's stdin is the
+// null device, so a command that asks the user must do so itself (for instance through pinentry), and it is killed if
+// it runs for longer than Timeout.
+type CommandPassPhraseSource struct {
+	Command []string
+	// Timeout limits how long the command may run for, DefaultHelperCommandTimeout is used if it is zero
+	Timeout time.Duration
+}
+
+func (s *CommandPassPhraseSource) ChallengeForPassPhrase(id, prompt string) (string, error) {
+	if len(s.Command) == 0 {
+		return "", errors.New("no passphrase command")
+	}
+	command := &HelperCommand{
+		Args:    s.Command,
+		Env:     []string{EnvPassPhraseID + "=" + id, EnvPassPhrasePrompt + "=" + prompt},
+		Timeout: s.Timeout,
+	}
+	out, err := command.Output()
+	if err != nil {
+		return "", fmt.Errorf("passphrase command %s failed: %s", command.Name(), err)
+	}
+	return readPassPhraseLine("passphrase command "+command.Name(), bufio.NewReader(bytes.NewReader(out)))
+}
+
+// ChainPassPhraseSource tries each source in order, returning the first passphrase that is found
+type ChainPassPhraseSource []PassPhraseSource
+
+func (c ChainPassPhraseSource) ChallengeForPassPhrase(id, prompt string) (string, error) {
+	var errs []string
+	for _, source := range c {
+		passphrase, err := source.ChallengeForPassPhrase(id, prompt)
+		if err == nil {
+			return passphrase, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	if len(errs) == 0 {
+		return "", errors.New("no pass phrase available")
+	}
+	return "", fmt.Errorf("no pass phrase available: %s", strings.Join(errs, "; "))
+}
+
+// CachingPassPhraseSource remembers the passphrase returned by a source for each challenge (its id and prompt), for the
+// life of the process, so that the user is only asked once. The prompt is part of the key as one id can be used for
+// several secrets, such as "oracle.privateKey" whose prompt names the key file. Failed challenges are not cached.
+type CachingPassPhraseSource struct {
+	Source PassPhraseSource
+
+	mu          sync.Mutex
+	passphrases map[passPhraseChallenge]string
+}
+
+type passPhraseChallenge struct {
+	id, prompt string
+}
+
+// NewCachingPassPhraseSource returns a CachingPassPhraseSource for source
+func NewCachingPassPhraseSource(source PassPhraseSource) *CachingPassPhraseSource {
+	return &CachingPassPhraseSource{Source: source}
+}
+
+func (c *CachingPassPhraseSource) ChallengeForPassPhrase(id, prompt string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	challenge := passPhraseChallenge{id: id, prompt: prompt}
+	if passphrase, ok := c.passphrases[challenge]; ok {
+		return passphrase, nil
+	}
+	passphrase, err := c.Source.ChallengeForPassPhrase(id, prompt)
+	if err != nil {
+		return "", err
+	}
+	if c.passphrases == nil {
+		c.passphrases = map[passPhraseChallenge]string{}
+	}
+	c.passphrases[challenge] = passphrase
+	return passphrase, nil
+}
+
+// readPassPhraseLine reads up to the first line break, passphrases may contain other whitespace so nothing else is
+// trimmed
+func readPassPhraseLine(name string, r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("unable to read passphrase from %s: %s", name, err)
+	}
+	if line == "" {
+		return "", fmt.Errorf("no passphrase in %s", name)
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
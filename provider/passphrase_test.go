@@ -0,0 +1,95 @@
+package provider
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPassPhraseSources(t *testing.T) {
+	t.Setenv("FN_TEST_PASSPHRASE", " with spaces ")
+	if got, err := (&EnvPassPhraseSource{Var: "FN_TEST_PASSPHRASE"}).ChallengeForPassPhrase("id", "prompt"); err != nil || got != " with spaces " {
+		t.Errorf("expected the environment variable untrimmed, got %q %v", got, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "passphrase")
+	if err := ioutil.WriteFile(path, []byte("from file\r\nignored\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := (&FilePassPhraseSource{Path: path}).ChallengeForPassPhrase("id", "prompt"); err != nil || got != "from file" {
+		t.Errorf("expected the first line of the file, got %q %v", got, err)
+	}
+
+	command := &CommandPassPhraseSource{Command: []string{"sh", "-c", `echo "$FN_PASSPHRASE_ID:$FN_PASSPHRASE_PROMPT"`}}
+	if got, err := command.ChallengeForPassPhrase("oracle.privateKey", "Enter passphrase"); err != nil || got != "oracle.privateKey:Enter passphrase" {
+		t.Errorf("expected the command's output, got %q %v", got, err)
+	}
+	command = &CommandPassPhraseSource{Command: []string{"sh", "-c", "echo vault is sealed >&2; exit 3"}}
+	if _, err := command.ChallengeForPassPhrase("id", "prompt"); err == nil || !strings.Contains(err.Error(), "vault is sealed") {
+		t.Errorf("expected the command's stderr in the error, got %v", err)
+	}
+	// the command can't read from stdin, and is killed if it doesn't answer in time
+	command = &CommandPassPhraseSource{Command: []string{"sh", "-c", "cat; echo from command"}}
+	if got, err := command.ChallengeForPassPhrase("id", "prompt"); err != nil || got != "from command" {
+		t.Errorf("expected the command not to wait for stdin, got %q %v", got, err)
+	}
+	command = &CommandPassPhraseSource{Command: []string{"sh", "-c", "sleep 10"}, Timeout: 100 * time.Millisecond}
+	if _, err := command.ChallengeForPassPhrase("id", "prompt"); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected a hung command to time out, got %v", err)
+	}
+}
+
+// countingPassPhraseSource returns the id as the passphrase, failing the first challenge for "flaky"
+type countingPassPhraseSource struct {
+	challenges map[string]int
+}
+
+func (s *countingPassPhraseSource) ChallengeForPassPhrase(id, prompt string) (string, error) {
+	s.challenges[id]++
+	if id == "flaky" && s.challenges[id] == 1 {
+		return "", errors.New("try again")
+	}
+	return id, nil
+}
+
+func TestChainAndCachingPassPhraseSources(t *testing.T) {
+	chain := ChainPassPhraseSource{
+		&EnvPassPhraseSource{Var: "FN_TEST_UNSET_PASSPHRASE"},
+		&FilePassPhraseSource{Path: filepath.Join(t.TempDir(), "missing")},
+		&NopPassPhraseSource{},
+	}
+	_, err := chain.ChallengeForPassPhrase("id", "prompt")
+	if err == nil || !strings.Contains(err.Error(), "FN_TEST_UNSET_PASSPHRASE is not set") || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("expected the error of each source, got %v", err)
+	}
+
+	t.Setenv("FN_TEST_PASSPHRASE", "from env")
+	chain = append(ChainPassPhraseSource{&EnvPassPhraseSource{Var: "FN_TEST_PASSPHRASE"}}, chain...)
+	if got, err := chain.ChallengeForPassPhrase("id", "prompt"); err != nil || got != "from env" {
+		t.Errorf("expected the first source that answers, got %q %v", got, err)
+	}
+
+	counting := &countingPassPhraseSource{challenges: map[string]int{}}
+	cache := NewCachingPassPhraseSource(counting)
+	for _, id := range []string{"a", "b", "a", "b"} {
+		if got, err := cache.ChallengeForPassPhrase(id, "prompt"); err != nil || got != id {
+			t.Errorf("expected the passphrase for %s, got %q %v", id, got, err)
+		}
+	}
+	if _, err := cache.ChallengeForPassPhrase("flaky", "prompt"); err == nil {
+		t.Errorf("expected the first flaky challenge to fail")
+	}
+	if got, err := cache.ChallengeForPassPhrase("flaky", "prompt"); err != nil || got != "flaky" {
+		t.Errorf("expected failures not to be cached, got %q %v", got, err)
+	}
+	// the same id with another prompt is another secret, such as a second key file
+	if got, err := cache.ChallengeForPassPhrase("a", "other prompt"); err != nil || got != "a" {
+		t.Errorf("expected the passphrase for a, got %q %v", got, err)
+	}
+	if counting.challenges["a"] != 2 || counting.challenges["b"] != 1 || counting.challenges["flaky"] != 2 {
+		t.Errorf("expected one challenge per id and prompt, got %v", counting.challenges)
+	}
+}
@@ -21,6 +21,18 @@ const (
 	TriggerResourceType     FnResourceType = "trigger"
 )
 
+// ResourceSupport describes how much of the API for a resource type a provider implements
+type ResourceSupport string
+
+const (
+	// ResourceSupportFull means that resources can be created, read, updated and deleted
+	ResourceSupportFull ResourceSupport = "full"
+	// ResourceSupportReadOnly means that resources can be listed and read, mutations fail with an unsupported error
+	ResourceSupportReadOnly ResourceSupport = "read-only"
+	// ResourceSupportUnavailable means that the resource type is not available at all
+	ResourceSupportUnavailable ResourceSupport = "unavailable"
+)
+
 //Providers describes a set of providers
 type Providers struct {
 	Providers map[string]ProviderFunc
@@ -46,10 +58,39 @@ type Provider interface {
 	// Returns a list of resource types that are not supported by this provider
 	UnavailableResources() []FnResourceType
 	VersionClient() *version.Client
+	// InvokeClient returns a client for invoking functions through this provider
+	InvokeClient() InvokeClient
 	// WrapCallTransport adds any request signing or auth to an existing round tripper for calls
 	WrapCallTransport(http.RoundTripper) http.RoundTripper
 }
 
+// ReadOnlyResourcesProvider is implemented by providers that can read but not modify some resource types. Read-only
+// types should still be reported by UnavailableResources, as consumers that don't know about read-only support use it
+// to decide which resources they can modify.
+type ReadOnlyResourcesProvider interface {
+	// ReadOnlyResources returns the resource types that can only be listed and read
+	ReadOnlyResources() []FnResourceType
+}
+
+// Capabilities returns the level of support that p has for each resource type, resources that are read-only are
+// reported as such even though they are also unavailable
+func Capabilities(p Provider) map[FnResourceType]ResourceSupport {
+	result := map[FnResourceType]ResourceSupport{
+		ApplicationResourceType: ResourceSupportFull,
+		FunctionResourceType:    ResourceSupportFull,
+		TriggerResourceType:     ResourceSupportFull,
+	}
+	for _, t := range p.UnavailableResources() {
+		result[t] = ResourceSupportUnavailable
+	}
+	if ro, ok := p.(ReadOnlyResourcesProvider); ok {
+		for _, t := range ro.ReadOnlyResources() {
+			result[t] = ResourceSupportReadOnly
+		}
+	}
+	return result
+}
+
 // CanonicalFnAPIUrl canonicalises an *FN_API_URL  to a default value
 func CanonicalFnAPIUrl(urlStr string) (*url.URL, error) {
 	if !strings.Contains(urlStr, "://") {
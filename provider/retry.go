@@ -0,0 +1,301 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	//CfgRetryMaxAttempts is the total number of attempts made for a request (including the first one), values below 2 disable retries
+	CfgRetryMaxAttempts = "retry.max-attempts"
+	//CfgRetryInitialBackoff is the base backoff duration (e.g. "500ms") that is doubled on each attempt
+	CfgRetryInitialBackoff = "retry.initial-backoff"
+	//CfgRetryMaxBackoff caps the backoff between two attempts, including any Retry-After delay requested by the server
+	CfgRetryMaxBackoff = "retry.max-backoff"
+
+	defaultRetryMaxAttempts    = 4
+	defaultRetryInitialBackoff = 500 * time.Millisecond
+	defaultRetryMaxBackoff     = 30 * time.Second
+
+	// maxBufferedRetryBody caps the size of request bodies that are buffered in memory so that they can be replayed
+	maxBufferedRetryBody = 1 << 20
+)
+
+// RetryPolicy describes how failed API requests are retried
+//
+// Requests are retried with exponential backoff and full jitter. Idempotent requests are retried on any of
+// RetryableStatusCodes and on connection errors; non-idempotent requests (POST and PATCH) are only retried when the server
+// refused them with 429 Too Many Requests or when the connection could not be established, so that creates are never
+// replayed after the server may have acted on them.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts int
+	// InitialBackoff is the backoff before the first retry, it doubles with every subsequent attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the backoff between attempts
+	MaxBackoff time.Duration
+	// RetryableStatusCodes are the response codes on which idempotent requests are retried
+	RetryableStatusCodes []int
+}
+
+// DefaultRetryPolicy returns a policy that makes up to four attempts, backing off from 500ms up to 30s
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    defaultRetryMaxAttempts,
+		InitialBackoff: defaultRetryInitialBackoff,
+		MaxBackoff:     defaultRetryMaxBackoff,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+	}
+}
+
+// RetryPolicyFromConfig returns the retry policy configured in source, starting from DefaultRetryPolicy. If none of the
+// retry keys are set then retries are disabled and nil is returned.
+func RetryPolicyFromConfig(source ConfigSource) (*RetryPolicy, error) {
+	if !source.IsSet(CfgRetryMaxAttempts) && !source.IsSet(CfgRetryInitialBackoff) && !source.IsSet(CfgRetryMaxBackoff) {
+		return nil, nil
+	}
+
+	policy := DefaultRetryPolicy()
+
+	if source.IsSet(CfgRetryMaxAttempts) {
+		attempts, err := strconv.Atoi(source.GetString(CfgRetryMaxAttempts))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s", CfgRetryMaxAttempts, err)
+		}
+		policy.MaxAttempts = attempts
+	}
+
+	var err error
+	if policy.InitialBackoff, err = durationFromConfig(source, CfgRetryInitialBackoff, policy.InitialBackoff); err != nil {
+		return nil, err
+	}
+	if policy.MaxBackoff, err = durationFromConfig(source, CfgRetryMaxBackoff, policy.MaxBackoff); err != nil {
+		return nil, err
+	}
+
+	if policy.MaxAttempts < 2 {
+		return nil, nil
+	}
+	return policy, nil
+}
+
+func durationFromConfig(source ConfigSource, key string, fallback time.Duration) (time.Duration, error) {
+	if !source.IsSet(key) {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(source.GetString(key))
+	if err != nil {
+		return 0, fmt.Errorf("invalid value for %s: %s", key, err)
+	}
+	return d, nil
+}
+
+// Backoff returns the delay before the given retry (1 for the first retry). If the server asked for a specific delay
+// with Retry-After then that is used instead of the computed backoff, in both cases the delay is capped at MaxBackoff.
+func (p *RetryPolicy) Backoff(retry int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		if p.MaxBackoff > 0 && retryAfter > p.MaxBackoff {
+			return p.MaxBackoff
+		}
+		return retryAfter
+	}
+
+	ceiling := p.InitialBackoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || ceiling < p.MaxBackoff); i++ {
+		ceiling *= 2
+	}
+	if p.MaxBackoff > 0 && ceiling > p.MaxBackoff {
+		ceiling = p.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// ShouldRetry reports whether a request with the given method that produced resp or err may be retried
+func (p *RetryPolicy) ShouldRetry(method string, resp *http.Response, err error) bool {
+	idempotent := isIdempotent(method)
+
+	if err != nil {
+		if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+			return false
+		}
+		if isDialError(err) {
+			return true
+		}
+		return idempotent && isConnectionError(err)
+	}
+
+	if resp == nil {
+		return false
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if !idempotent {
+		return false
+	}
+	for _, code := range p.RetryableStatusCodes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+func isIdempotent(method string) bool {
+	switch strings.ToUpper(method) {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isDialError is true when the connection could not be established, so the request was never sent
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// isConnectionError is true when the connection was reset, refused or closed by the server. Timeouts are not included,
+// the server may still be acting on a request that was sent before the client gave up on it.
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	return strings.Contains(err.Error(), "connection reset") || strings.Contains(err.Error(), "transport connection broken")
+}
+
+// RetryAfter parses the Retry-After header of resp, returning 0 if there is none
+func RetryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+type retryRoundTripper struct {
+	policy    *RetryPolicy
+	transport http.RoundTripper
+}
+
+// RetryRoundTripper retries requests sent through transport according to policy, a nil policy returns transport as-is
+//
+// Wrap this around any request signing so that each attempt is signed afresh. Bodies of idempotent requests that cannot
+// be re-read through GetBody are buffered in memory so that they can be replayed, up to 1MiB. Other requests with
+// bodies that cannot be re-read, such as larger uploads or streamed function invocations, are sent once as-is.
+func RetryRoundTripper(policy *RetryPolicy, transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	if policy == nil || policy.MaxAttempts < 2 {
+		return transport
+	}
+	return &retryRoundTripper{policy: policy, transport: transport}
+}
+
+func (t *retryRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+		if !isIdempotent(request.Method) || request.ContentLength > maxBufferedRetryBody {
+			return t.transport.RoundTrip(request)
+		}
+		replayable, ok, err := bufferBody(request)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return t.transport.RoundTrip(replayable)
+		}
+		request = replayable
+	}
+
+	ctx := request.Context()
+	for attempt := 1; ; attempt++ {
+		attemptRequest := request.Clone(ctx)
+		if attempt > 1 && request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptRequest.Body = body
+		}
+
+		resp, err := t.transport.RoundTrip(attemptRequest)
+		if attempt >= t.policy.MaxAttempts || !t.policy.ShouldRetry(request.Method, resp, err) {
+			return resp, err
+		}
+
+		delay := t.policy.Backoff(attempt, RetryAfter(resp))
+		if resp != nil {
+			io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxErrorBodyBytes))
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// bufferBody returns a copy of request whose body can be replayed through GetBody. If the body is larger than
+// maxBufferedRetryBody then false is returned along with a copy of request that still has the whole body to send.
+func bufferBody(request *http.Request) (*http.Request, bool, error) {
+	original := request.Body
+	body, err := ioutil.ReadAll(io.LimitReader(original, maxBufferedRetryBody+1))
+	if err != nil {
+		original.Close()
+		return nil, false, err
+	}
+
+	request = request.Clone(request.Context())
+	if len(body) > maxBufferedRetryBody {
+		request.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), original), original}
+		return request, false, nil
+	}
+
+	original.Close()
+	request.GetBody = func() (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(body)), nil
+	}
+	request.Body, _ = request.GetBody()
+	return request, true, nil
+}
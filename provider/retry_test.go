@@ -0,0 +1,183 @@
+package provider
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryRoundTripper(t *testing.T) {
+	var tests = []struct {
+		method   string
+		statuses []int
+		attempts int
+		final    int
+	}{
+		{http.MethodGet, []int{503, 502, 200}, 3, 200},
+		{http.MethodGet, []int{504, 504, 504, 504, 504}, 4, 504},
+		{http.MethodGet, []int{404}, 1, 404},
+		{http.MethodPut, []int{503, 200}, 2, 200},
+		{http.MethodPost, []int{503}, 1, 503},
+		{http.MethodPost, []int{429, 201}, 2, 201},
+		{http.MethodPatch, []int{502}, 1, 502},
+		{http.MethodPatch, []int{504}, 1, 504},
+	}
+
+	for _, test := range tests {
+		var attempts int
+		var bodies []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			w.WriteHeader(test.statuses[attempts])
+			attempts++
+		}))
+
+		policy := &RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond, RetryableStatusCodes: DefaultRetryPolicy().RetryableStatusCodes}
+		client := &http.Client{Transport: RetryRoundTripper(policy, http.DefaultTransport)}
+
+		req, _ := http.NewRequest(test.method, srv.URL, strings.NewReader("payload"))
+		resp, err := client.Do(req)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("%s %v: unexpected error %s", test.method, test.statuses, err)
+		}
+		if resp.StatusCode != test.final || attempts != test.attempts {
+			t.Errorf("%s %v: got status %d after %d attempts, expected %d after %d", test.method, test.statuses, resp.StatusCode, attempts, test.final, test.attempts)
+		}
+		for _, body := range bodies {
+			if body != "payload" {
+				t.Errorf("%s %v: body not replayed, got %q", test.method, test.statuses, body)
+			}
+		}
+	}
+}
+
+func TestRetryRoundTripperNonReplayableBodies(t *testing.T) {
+	large := strings.Repeat("x", maxBufferedRetryBody+1)
+	var tests = []struct {
+		name     string
+		method   string
+		body     string
+		attempts int
+	}{
+		{"small idempotent body is buffered", http.MethodPut, "payload", 2},
+		{"large idempotent body is sent once", http.MethodPut, large, 1},
+		{"non-idempotent body is sent once", http.MethodPost, "payload", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var bodies []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+				if len(bodies) == 1 {
+					w.WriteHeader(http.StatusTooManyRequests)
+				}
+			}))
+			defer srv.Close()
+
+			policy := &RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond, MaxBackoff: 5 * time.Millisecond}
+			client := &http.Client{Transport: RetryRoundTripper(policy, http.DefaultTransport)}
+
+			// a body that isn't a bytes or strings reader has no GetBody, like a streamed function invocation
+			req, _ := http.NewRequest(test.method, srv.URL, ioutil.NopCloser(strings.NewReader(test.body)))
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if len(bodies) != test.attempts {
+				t.Errorf("expected %d attempts, got %d", test.attempts, len(bodies))
+			}
+			for _, body := range bodies {
+				if body != test.body {
+					t.Errorf("expected the whole body to be sent, got %d bytes", len(body))
+				}
+			}
+		})
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryConnectionErrors(t *testing.T) {
+	reset := &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+	var tests = []struct {
+		method string
+		err    error
+		retry  bool
+	}{
+		{http.MethodGet, reset, true},
+		{http.MethodGet, io.EOF, true},
+		{http.MethodPut, &net.OpError{Op: "write", Net: "tcp", Err: syscall.EPIPE}, true},
+		{http.MethodGet, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{http.MethodPost, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		{http.MethodPost, reset, false},
+		{http.MethodPatch, reset, false},
+		{http.MethodPatch, io.EOF, false},
+		{http.MethodGet, &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}, false},
+		{http.MethodGet, timeoutError{}, false},
+	}
+
+	policy := DefaultRetryPolicy()
+	for _, test := range tests {
+		if retry := policy.ShouldRetry(test.method, nil, test.err); retry != test.retry {
+			t.Errorf("%s %v: expected retry %v, got %v", test.method, test.err, test.retry, retry)
+		}
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for retry := 1; retry < 10; retry++ {
+		if d := policy.Backoff(retry, 0); d < 0 || d > time.Second {
+			t.Errorf("backoff %s for retry %d is out of range", d, retry)
+		}
+	}
+	if d := policy.Backoff(1, 500*time.Millisecond); d != 500*time.Millisecond {
+		t.Errorf("Retry-After not honoured, got %s", d)
+	}
+	if d := policy.Backoff(1, time.Minute); d != time.Second {
+		t.Errorf("Retry-After not capped, got %s", d)
+	}
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"3"}}}
+	if d := RetryAfter(resp); d != 3*time.Second {
+		t.Errorf("unexpected Retry-After %s", d)
+	}
+}
+
+func TestRetryPolicyFromConfig(t *testing.T) {
+	policy, err := RetryPolicyFromConfig(NewConfigSourceFromMap(map[string]string{}))
+	if err != nil || policy != nil {
+		t.Errorf("expected retries to be disabled by default, got %v %v", policy, err)
+	}
+
+	policy, err = RetryPolicyFromConfig(NewConfigSourceFromMap(map[string]string{
+		CfgRetryMaxAttempts:    "6",
+		CfgRetryInitialBackoff: "250ms",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if policy.MaxAttempts != 6 || policy.InitialBackoff != 250*time.Millisecond || policy.MaxBackoff != defaultRetryMaxBackoff {
+		t.Errorf("unexpected policy %+v", policy)
+	}
+
+	if _, err = RetryPolicyFromConfig(NewConfigSourceFromMap(map[string]string{CfgRetryMaxBackoff: "forever"})); err == nil {
+		t.Error("expected an error for an invalid duration")
+	}
+}
@@ -0,0 +1,46 @@
+package provider
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// TokenFile is a TokenSource that reads the token from a file each time it is used, so that tokens which are rotated
+// on disk (such as Kubernetes projected service account tokens) are picked up without recreating the provider
+type TokenFile struct {
+	path string
+}
+
+// NewTokenFile returns a TokenFile for path, failing if the token can't be read now
+func NewTokenFile(path string) (*TokenFile, error) {
+	expanded, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+	f := &TokenFile{path: expanded}
+	if _, err := f.Token(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Path returns the file that the token is read from
+func (f *TokenFile) Path() string {
+	return f.path
+}
+
+// Token reads the current token from the file
+func (f *TokenFile) Token() (string, error) {
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read token: %s", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("token file %s is empty", f.path)
+	}
+	return token, nil
+}
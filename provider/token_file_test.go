@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestTokenFilePicksUpRotatedTokens(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization"))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "token")
+	write := func(token string) {
+		if err := ioutil.WriteFile(path, []byte(token), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := NewTokenFile(path); err == nil {
+		t.Errorf("expected an error for a missing token file")
+	}
+
+	write("first\n")
+	tokenFile, err := NewTokenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: TokenSourceRoundTripper(tokenFile, nil)}
+	get := func() error {
+		resp, err := client.Get(srv.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	if err := get(); err != nil {
+		t.Fatal(err)
+	}
+	write("second")
+	if err := get(); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "Bearer first" || got[1] != "Bearer second" {
+		t.Errorf("expected the rotated token to be sent, got %v", got)
+	}
+
+	write("")
+	if err := get(); err == nil {
+		t.Errorf("expected an error for an empty token file")
+	}
+	if len(got) != 2 {
+		t.Errorf("requests without a token should not be sent")
+	}
+}
@@ -0,0 +1,386 @@
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	//CfgFnTokenCommand is a command that prints a bearer token on its stdout, arguments that contain spaces can be quoted
+	CfgFnTokenCommand = "token-command"
+	//CfgFnTokenCommandTimeout limits how long CfgFnTokenCommand may run for, as a Go duration such as 10s
+	CfgFnTokenCommandTimeout = "token-command-timeout"
+	//CfgFnCredentialHosts lists hosts other than the API host that credentials are sent to, such as the host of
+	//annotated invoke endpoints, separated by spaces or commas
+	CfgFnCredentialHosts = "credential-hosts"
+	//CfgOAuth2TokenURL is the token endpoint used to get bearer tokens with the OAuth2 client credentials grant
+	CfgOAuth2TokenURL = "oauth2.token-url"
+	//CfgOAuth2ClientID is the client ID used with CfgOAuth2TokenURL
+	CfgOAuth2ClientID = "oauth2.client-id"
+	//CfgOAuth2ClientSecret is the client secret used with CfgOAuth2TokenURL
+	CfgOAuth2ClientSecret = "oauth2.client-secret"
+	//CfgOAuth2Scopes are the scopes requested from CfgOAuth2TokenURL, separated by spaces or commas
+	CfgOAuth2Scopes = "oauth2.scopes"
+
+	// tokens are refreshed this long before they expire, so that they don't expire in flight
+	tokenExpiryDelta = 30 * time.Second
+)
+
+// TokenSource supplies the bearer token that authenticates API requests, it is asked for a token on every request
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// RefreshableTokenSource is a TokenSource that caches its token. Invalidate is called with a token that the server
+// rejected, so that the next call to Token fetches a new one.
+type RefreshableTokenSource interface {
+	TokenSource
+	Invalidate(token string)
+}
+
+type staticTokenSource string
+
+// StaticTokenSource returns a TokenSource that always returns token
+func StaticTokenSource(token string) TokenSource {
+	return staticTokenSource(token)
+}
+
+func (s staticTokenSource) Token() (string, error) {
+	return string(s), nil
+}
+
+// TokenSourceFromConfig returns the token source configured in source with one of CfgFnToken, CfgFnTokenFile,
+// CfgFnTokenCommand or CfgOAuth2TokenURL. If none of them are set then calls are un-authenticated and nil is returned.
+func TokenSourceFromConfig(source ConfigSource) (TokenSource, error) {
+	var configured []string
+	for _, key := range []string{CfgFnToken, CfgFnTokenFile, CfgFnTokenCommand, CfgOAuth2TokenURL} {
+		if source.GetString(key) != "" {
+			configured = append(configured, key)
+		}
+	}
+	if len(configured) == 0 {
+		return nil, nil
+	}
+	if len(configured) > 1 {
+		return nil, fmt.Errorf("only one of %s may be configured", strings.Join(configured, ", "))
+	}
+
+	switch configured[0] {
+	case CfgFnTokenFile:
+		return NewTokenFile(source.GetString(CfgFnTokenFile))
+	case CfgFnTokenCommand:
+		args, err := ParseCommandLine(source.GetString(CfgFnTokenCommand))
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s: %s", CfgFnTokenCommand, err)
+		}
+		timeout, err := durationFromConfig(source, CfgFnTokenCommandTimeout, DefaultHelperCommandTimeout)
+		if err != nil {
+			return nil, err
+		}
+		return NewExecTokenSource(&HelperCommand{Args: args, Timeout: timeout}), nil
+	case CfgOAuth2TokenURL:
+		credentials := &OAuth2ClientCredentials{
+			TokenURL:     source.GetString(CfgOAuth2TokenURL),
+			ClientID:     source.GetString(CfgOAuth2ClientID),
+			ClientSecret: source.GetString(CfgOAuth2ClientSecret),
+			Scopes:       strings.Fields(strings.Replace(source.GetString(CfgOAuth2Scopes), ",", " ", -1)),
+		}
+		if credentials.ClientID == "" {
+			return nil, fmt.Errorf("%s must be set with %s", CfgOAuth2ClientID, CfgOAuth2TokenURL)
+		}
+		return credentials.TokenSource(), nil
+	default:
+		return StaticTokenSource(source.GetString(CfgFnToken)), nil
+	}
+}
+
+// HeaderTokenSource is a TokenSource whose tokens are sent along with other headers, such as a tenant or an API key.
+// TokenAndHeader returns a token together with the headers that belong to it, the token may be empty if the headers
+// carry the credentials.
+type HeaderTokenSource interface {
+	TokenSource
+	TokenAndHeader() (string, http.Header, error)
+}
+
+// NewCachingTokenSource returns a TokenSource that caches the token returned by fetch, along with any headers to send
+// with it, until shortly before it expires or until the server rejects it; a token with a zero expiry is used until it
+// is rejected. Only one fetch runs at a time and callers wait for it rather than starting their own, so fetch must be
+// bounded by a timeout.
+func NewCachingTokenSource(fetch func() (string, http.Header, time.Time, error)) RefreshableTokenSource {
+	return &cachingTokenSource{now: time.Now, fetch: fetch}
+}
+
+type cachingTokenSource struct {
+	fetch func() (string, http.Header, time.Time, error)
+	now   func() time.Time
+
+	mu     sync.Mutex
+	cached bool
+	token  string
+	header http.Header
+	expiry time.Time
+}
+
+func (s *cachingTokenSource) Token() (string, error) {
+	token, _, err := s.TokenAndHeader()
+	return token, err
+}
+
+func (s *cachingTokenSource) TokenAndHeader() (string, http.Header, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached && (s.expiry.IsZero() || s.now().Add(tokenExpiryDelta).Before(s.expiry)) {
+		return s.token, s.header, nil
+	}
+	token, header, expiry, err := s.fetch()
+	if err != nil {
+		return "", nil, err
+	}
+	s.cached, s.token, s.header, s.expiry = true, token, header, expiry
+	return token, header, nil
+}
+
+func (s *cachingTokenSource) Invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == token {
+		s.cached = false
+	}
+}
+
+// NewExecTokenSource returns a TokenSource that runs command and uses what it prints on stdout as the token. The
+// token is cached until the server rejects it.
+func NewExecTokenSource(command *HelperCommand) RefreshableTokenSource {
+	return NewCachingTokenSource(func() (string, http.Header, time.Time, error) {
+		out, err := command.Output()
+		if err != nil {
+			return "", nil, time.Time{}, fmt.Errorf("token command %s failed: %s", command.Name(), err)
+		}
+		token := strings.TrimSpace(string(out))
+		if token == "" {
+			return "", nil, time.Time{}, fmt.Errorf("token command %s printed no token", command.Name())
+		}
+		return token, nil, time.Time{}, nil
+	})
+}
+
+// OAuth2ClientCredentials gets bearer tokens from an OAuth2 token endpoint with the client credentials grant
+type OAuth2ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// HTTPClient is used to request tokens, http.DefaultClient is used if it is nil
+	HTTPClient *http.Client
+}
+
+// TokenSource returns a TokenSource that caches tokens until shortly before they expire
+func (c *OAuth2ClientCredentials) TokenSource() RefreshableTokenSource {
+	s := &cachingTokenSource{now: time.Now}
+	s.fetch = func() (string, http.Header, time.Time, error) {
+		token, expiry, err := c.fetchToken(s.now)
+		return token, nil, expiry, err
+	}
+	return s
+}
+
+func (c *OAuth2ClientCredentials) fetchToken(now func() time.Time) (string, time.Time, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(c.Scopes) > 0 {
+		form.Set("scope", strings.Join(c.Scopes, " "))
+	}
+	req, err := http.NewRequest(http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	issued := now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("unable to get an OAuth2 token: %s", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("unable to get an OAuth2 token: %s", err)
+	}
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		TokenType        string `json:"token_type"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	decodeErr := json.NewDecoder(bytes.NewReader(body)).Decode(&result)
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != "" {
+			return "", time.Time{}, fmt.Errorf("unable to get an OAuth2 token from %s: %s %s %s", c.TokenURL, resp.Status, result.Error, result.ErrorDescription)
+		}
+		return "", time.Time{}, fmt.Errorf("unable to get an OAuth2 token from %s: %s", c.TokenURL, resp.Status)
+	}
+	if decodeErr != nil {
+		return "", time.Time{}, fmt.Errorf("invalid OAuth2 token response from %s: %s", c.TokenURL, decodeErr)
+	}
+	if result.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("no access token in the OAuth2 token response from %s", c.TokenURL)
+	}
+	if result.TokenType != "" && !strings.EqualFold(result.TokenType, "bearer") {
+		return "", time.Time{}, fmt.Errorf("unsupported OAuth2 token type %s from %s", result.TokenType, c.TokenURL)
+	}
+
+	var expiry time.Time
+	if result.ExpiresIn > 0 {
+		expiry = issued.Add(time.Duration(result.ExpiresIn) * time.Second)
+	}
+	return result.AccessToken, expiry, nil
+}
+
+// CredentialHostsFromConfig returns the hosts listed in CfgFnCredentialHosts
+func CredentialHostsFromConfig(source ConfigSource) []string {
+	return strings.Fields(strings.Replace(source.GetString(CfgFnCredentialHosts), ",", " ", -1))
+}
+
+type hostRestrictedRoundTripper struct {
+	// hosts holds host:port pairs, along with hosts without a port that match the default port of any scheme
+	hosts        map[string]bool
+	credentialed http.RoundTripper
+	transport    http.RoundTripper
+}
+
+// HostRestrictedRoundTripper sends requests for one of hosts through credentialed and all other requests through
+// transport, so that credentials for the API aren't sent to other servers. A host without a port matches requests to
+// the default port of their scheme.
+func HostRestrictedRoundTripper(hosts []string, credentialed, transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	t := &hostRestrictedRoundTripper{hosts: map[string]bool{}, credentialed: credentialed, transport: transport}
+	for _, host := range hosts {
+		u := &url.URL{Host: host}
+		if u.Port() == "" {
+			t.hosts[strings.ToLower(u.Hostname())] = true
+		} else {
+			t.hosts[HostPort(u)] = true
+		}
+	}
+	return t
+}
+
+func (t *hostRestrictedRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	u := request.URL
+	if t.hosts[HostPort(u)] || (t.hosts[strings.ToLower(u.Hostname())] && (u.Port() == "" || u.Port() == defaultPort(u.Scheme))) {
+		return t.credentialed.RoundTrip(request)
+	}
+	return t.transport.RoundTrip(request)
+}
+
+// HostPort returns the lower case host and port of u, filling in the default port of its scheme
+func HostPort(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		port = defaultPort(u.Scheme)
+	}
+	return net.JoinHostPort(strings.ToLower(u.Hostname()), port)
+}
+
+func defaultPort(scheme string) string {
+	switch strings.ToLower(scheme) {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+	return ""
+}
+
+type tokenSourceRoundTripper struct {
+	source    TokenSource
+	transport http.RoundTripper
+}
+
+// TokenSourceRoundTripper adds a bearer token from source to every request sent through transport, along with its
+// headers if source is a HeaderTokenSource. When the server responds with 401 Unauthorized the token is refreshed and
+// the request is retried once with the new token, requests with bodies that cannot be re-read through GetBody are not
+// retried.
+func TokenSourceRoundTripper(source TokenSource, transport http.RoundTripper) http.RoundTripper {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &tokenSourceRoundTripper{source: source, transport: transport}
+}
+
+func (t *tokenSourceRoundTripper) token() (string, http.Header, error) {
+	if source, ok := t.source.(HeaderTokenSource); ok {
+		return source.TokenAndHeader()
+	}
+	token, err := t.source.Token()
+	return token, nil, err
+}
+
+func (t *tokenSourceRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	token, header, err := t.token()
+	if err != nil {
+		if request.Body != nil {
+			request.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := t.transport.RoundTrip(withBearerToken(request, token, header))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if request.Body != nil && request.Body != http.NoBody && request.GetBody == nil {
+		return resp, nil
+	}
+
+	if refreshable, ok := t.source.(RefreshableTokenSource); ok {
+		refreshable.Invalidate(token)
+	}
+	refreshed, refreshedHeader, err := t.token()
+	if err != nil || (refreshed == token && reflect.DeepEqual(refreshedHeader, header)) {
+		// there's nothing better to retry with, so the original rejection stands
+		return resp, nil
+	}
+
+	retry := withBearerToken(request, refreshed, refreshedHeader)
+	if request.GetBody != nil {
+		body, err := request.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		retry.Body = body
+	}
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, maxErrorBodyBytes))
+	resp.Body.Close()
+	return t.transport.RoundTrip(retry)
+}
+
+func withBearerToken(request *http.Request, token string, header http.Header) *http.Request {
+	request = request.Clone(request.Context())
+	for k, vs := range header {
+		request.Header[http.CanonicalHeaderKey(k)] = append([]string(nil), vs...)
+	}
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	return request
+}
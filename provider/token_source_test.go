@@ -0,0 +1,298 @@
+package provider
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// oauth2Server is a stand-in token endpoint that issues numbered tokens, along with an API that only accepts the
+// latest token and echoes request bodies
+type oauth2Server struct {
+	mu      sync.Mutex
+	issued  int
+	revoked bool
+	api     []string
+}
+
+func newOAuth2Server(t *testing.T) (*oauth2Server, *httptest.Server) {
+	s := &oauth2Server{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "fn-controller" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"invalid_client","error_description":"bad credentials"}`)
+			return
+		}
+		if r.PostFormValue("grant_type") != "client_credentials" || r.PostFormValue("scope") != "fn.read fn.write" {
+			t.Errorf("unexpected token request %v", r.PostForm)
+		}
+		s.mu.Lock()
+		s.issued++
+		s.revoked = false
+		token := fmt.Sprintf("token-%d", s.issued)
+		s.mu.Unlock()
+		fmt.Fprintf(w, `{"access_token":%q,"token_type":"Bearer","expires_in":3600}`, token)
+	})
+	mux.HandleFunc("/api", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.api = append(s.api, r.Header.Get("Authorization"))
+		valid := !s.revoked && r.Header.Get("Authorization") == fmt.Sprintf("Bearer token-%d", s.issued)
+		s.mu.Unlock()
+		if !valid {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write(body)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func (s *oauth2Server) revoke() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revoked = true
+}
+
+func (s *oauth2Server) calls() (int, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issued, append([]string(nil), s.api...)
+}
+
+func TestOAuth2ClientCredentialsTokenSource(t *testing.T) {
+	s, srv := newOAuth2Server(t)
+	ts, err := TokenSourceFromConfig(NewConfigSourceFromMap(map[string]string{
+		CfgOAuth2TokenURL:     srv.URL + "/token",
+		CfgOAuth2ClientID:     "fn-controller",
+		CfgOAuth2ClientSecret: "s3cret",
+		CfgOAuth2Scopes:       "fn.read,fn.write",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Transport: TokenSourceRoundTripper(ts, nil)}
+	post := func(body string) (int, string) {
+		resp, err := client.Post(srv.URL+"/api", "text/plain", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		out, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(out)
+	}
+
+	post("one")
+	post("two")
+	if issued, _ := s.calls(); issued != 1 {
+		t.Errorf("expected the token to be cached, %d were issued", issued)
+	}
+
+	// the server stops accepting the token before it expires, so it is refreshed and the request is replayed
+	s.revoke()
+	if status, body := post("three"); status != http.StatusOK || body != "three" {
+		t.Errorf("expected the request to be retried with a new token, got %d %q", status, body)
+	}
+	issued, api := s.calls()
+	if issued != 2 || len(api) != 4 || api[2] != "Bearer token-1" || api[3] != "Bearer token-2" {
+		t.Errorf("expected one retry with a refreshed token, got %d tokens and calls %v", issued, api)
+	}
+
+	// tokens are refreshed shortly before they expire
+	cts := ts.(*cachingTokenSource)
+	cts.now = func() time.Time { return time.Now().Add(time.Hour - tokenExpiryDelta) }
+	if token, err := ts.Token(); err != nil || token != "token-3" {
+		t.Errorf("expected a new token near expiry, got %s %v", token, err)
+	}
+
+	bad := &OAuth2ClientCredentials{TokenURL: srv.URL + "/token", ClientID: "fn-controller", ClientSecret: "wrong"}
+	if _, err := bad.TokenSource().Token(); err == nil || !strings.Contains(err.Error(), "invalid_client bad credentials") {
+		t.Errorf("expected the token endpoint error, got %v", err)
+	}
+}
+
+func TestTokenSourceRoundTripperRefreshesOnce(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		got = append(got, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	fetches := 0
+	ts := NewCachingTokenSource(func() (string, http.Header, time.Time, error) {
+		fetches++
+		return fmt.Sprintf("token-%d", fetches), nil, time.Time{}, nil
+	})
+	client := &http.Client{Transport: TokenSourceRoundTripper(ts, nil)}
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized || len(got) != 2 || got[1] != "Bearer token-2" {
+		t.Errorf("expected a single retry that is also rejected, got %d %v", resp.StatusCode, got)
+	}
+
+	// a streamed body can't be replayed, so the rejection is returned as-is
+	got = nil
+	resp, err = client.Post(srv.URL, "text/plain", ioutil.NopCloser(strings.NewReader("stream")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(got) != 1 {
+		t.Errorf("expected a request with an unreplayable body not to be retried, got %v", got)
+	}
+
+	// a static token can't be refreshed, so it isn't retried either
+	got = nil
+	client.Transport = TokenSourceRoundTripper(StaticTokenSource("static"), nil)
+	resp, err = client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if len(got) != 1 || got[0] != "Bearer static" {
+		t.Errorf("expected a single request with the static token, got %v", got)
+	}
+}
+
+func TestTokenSourceRoundTripperSendsHeaders(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("Authorization")+"|"+r.Header.Get("X-Api-Key"))
+		if r.Header.Get("X-Api-Key") != "key-2" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	// the credentials are carried by a header alone, and a new header is enough to retry with
+	fetches := 0
+	ts := NewCachingTokenSource(func() (string, http.Header, time.Time, error) {
+		fetches++
+		return "", http.Header{"x-api-key": {fmt.Sprintf("key-%d", fetches)}}, time.Time{}, nil
+	})
+	client := &http.Client{Transport: TokenSourceRoundTripper(ts, nil)}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("expected the request to succeed, got %d", resp.StatusCode)
+		}
+	}
+	if strings.Join(got, " ") != "|key-1 |key-2 |key-2" {
+		t.Errorf("expected the refreshed header to be cached and reused, got %v", got)
+	}
+}
+
+func TestExecTokenSource(t *testing.T) {
+	ts := NewExecTokenSource(&HelperCommand{Args: []string{"echo", "exec-token"}})
+	if token, err := ts.Token(); err != nil || token != "exec-token" {
+		t.Errorf("expected the command's output, got %q %v", token, err)
+	}
+
+	ts = NewExecTokenSource(&HelperCommand{Args: []string{"sh", "-c", "echo not logged in >&2; exit 1"}})
+	if _, err := ts.Token(); err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Errorf("expected the command's stderr in the error, got %v", err)
+	}
+}
+
+func TestTokenSourceFromConfig(t *testing.T) {
+	ts, err := TokenSourceFromConfig(NewConfigSourceFromMap(map[string]string{}))
+	if err != nil || ts != nil {
+		t.Errorf("expected no token source, got %v %v", ts, err)
+	}
+
+	ts, err = TokenSourceFromConfig(NewConfigSourceFromMap(map[string]string{CfgFnToken: "static"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, _ := ts.Token(); token != "static" {
+		t.Errorf("expected the static token, got %s", token)
+	}
+
+	_, err = TokenSourceFromConfig(NewConfigSourceFromMap(map[string]string{CfgFnToken: "static", CfgFnTokenCommand: "echo token"}))
+	if err == nil || !strings.Contains(err.Error(), "only one of token, token-command") {
+		t.Errorf("expected an error for conflicting token settings, got %v", err)
+	}
+
+	ts, err = TokenSourceFromConfig(NewConfigSourceFromMap(map[string]string{CfgFnTokenCommand: `sh -c 'echo "$0 token"' "quoted  argument"`}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, err := ts.Token(); err != nil || token != "quoted  argument token" {
+		t.Errorf("expected quoted arguments to be passed as-is, got %q %v", token, err)
+	}
+
+	_, err = TokenSourceFromConfig(NewConfigSourceFromMap(map[string]string{CfgFnTokenCommand: "echo token", CfgFnTokenCommandTimeout: "soon"}))
+	if err == nil || !strings.Contains(err.Error(), CfgFnTokenCommandTimeout) {
+		t.Errorf("expected an error for an invalid timeout, got %v", err)
+	}
+
+	_, err = TokenSourceFromConfig(NewConfigSourceFromMap(map[string]string{CfgOAuth2TokenURL: "https://auth.example.com/token"}))
+	if err == nil || !strings.Contains(err.Error(), CfgOAuth2ClientID) {
+		t.Errorf("expected an error without a client ID, got %v", err)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestHostRestrictedRoundTripper(t *testing.T) {
+	var got []string
+	transport := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		got = append(got, r.URL.Host+" "+r.Header.Get("Authorization"))
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	rt := HostRestrictedRoundTripper([]string{"api.example.com:443", "Invoke.example.com", "[::1]:8080"}, TokenSourceRoundTripper(StaticTokenSource("token"), transport), transport)
+
+	for _, u := range []string{
+		"https://api.example.com/v2/apps",
+		"https://API.example.com:443/v2/apps",
+		"http://api.example.com/v2/apps",
+		"https://invoke.example.com/invoke/fn",
+		"http://invoke.example.com/invoke/fn",
+		"https://invoke.example.com:8443/invoke/fn",
+		"http://[::1]:8080/invoke/fn",
+		"https://elsewhere.example.com/invoke/fn",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, u, nil)
+		if _, err := rt.RoundTrip(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := []string{
+		"api.example.com Bearer token",
+		"API.example.com:443 Bearer token",
+		"api.example.com ",
+		"invoke.example.com Bearer token",
+		"invoke.example.com Bearer token",
+		"invoke.example.com:8443 ",
+		"[::1]:8080 Bearer token",
+		"elsewhere.example.com ",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected the token to be sent only to the listed hosts, got %q", got)
+	}
+}
@@ -0,0 +1,134 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/clientv2/triggers"
+	"github.com/fnproject/fn_go/provider"
+)
+
+// Apply makes the changes in plan in order, stopping at the first change that fails. IDs of resources created by the
+// plan are resolved as it goes so that functions and triggers can be created in new apps.
+func (r *Reconciler) Apply(ctx context.Context, plan *Plan) error {
+	appIDs := map[string]string{}
+	fnIDs := map[string]string{}
+
+	for _, c := range plan.Changes {
+		if err := r.apply(ctx, c, appIDs, fnIDs); err != nil {
+			return fmt.Errorf("failed to %s %s %s: %w", c.Action, c.Resource, c.Path, err)
+		}
+	}
+	return nil
+}
+
+func (r *Reconciler) apply(ctx context.Context, c Change, appIDs, fnIDs map[string]string) error {
+	fnKey := c.appName + "/" + c.fnName
+
+	switch c.Resource {
+	case provider.ApplicationResourceType:
+		switch c.Action {
+		case ActionCreate:
+			res, err := r.client.Apps.CreateApp(apps.NewCreateAppParams().WithContext(ctx).WithBody(c.app))
+			if err != nil {
+				return err
+			}
+			appIDs[c.appName] = res.Payload.ID
+		case ActionUpdate:
+			if _, err := r.client.Apps.UpdateApp(apps.NewUpdateAppParams().WithContext(ctx).WithAppID(c.ID).WithBody(c.app)); err != nil {
+				return err
+			}
+			appIDs[c.appName] = c.ID
+		case ActionDelete:
+			_, err := r.client.Apps.DeleteApp(apps.NewDeleteAppParams().WithContext(ctx).WithAppID(c.ID))
+			return err
+		}
+
+	case provider.FunctionResourceType:
+		switch c.Action {
+		case ActionCreate:
+			appID, err := r.appID(ctx, c.appName, appIDs)
+			if err != nil {
+				return err
+			}
+			fn := *c.fn
+			fn.AppID = appID
+			res, err := r.client.Fns.CreateFn(fns.NewCreateFnParams().WithContext(ctx).WithBody(&fn))
+			if err != nil {
+				return err
+			}
+			fnIDs[fnKey] = res.Payload.ID
+		case ActionUpdate:
+			if _, err := r.client.Fns.UpdateFn(fns.NewUpdateFnParams().WithContext(ctx).WithFnID(c.ID).WithBody(c.fn)); err != nil {
+				return err
+			}
+			fnIDs[fnKey] = c.ID
+		case ActionDelete:
+			_, err := r.client.Fns.DeleteFn(fns.NewDeleteFnParams().WithContext(ctx).WithFnID(c.ID))
+			return err
+		}
+
+	case provider.TriggerResourceType:
+		switch c.Action {
+		case ActionCreate:
+			appID, err := r.appID(ctx, c.appName, appIDs)
+			if err != nil {
+				return err
+			}
+			fnID, err := r.fnID(ctx, appID, c.fnName, fnKey, fnIDs)
+			if err != nil {
+				return err
+			}
+			trigger := *c.trigger
+			trigger.AppID = appID
+			trigger.FnID = fnID
+			_, err = r.client.Triggers.CreateTrigger(triggers.NewCreateTriggerParams().WithContext(ctx).WithBody(&trigger))
+			return err
+		case ActionUpdate:
+			_, err := r.client.Triggers.UpdateTrigger(triggers.NewUpdateTriggerParams().WithContext(ctx).WithTriggerID(c.ID).WithBody(c.trigger))
+			return err
+		case ActionDelete:
+			_, err := r.client.Triggers.DeleteTrigger(triggers.NewDeleteTriggerParams().WithContext(ctx).WithTriggerID(c.ID))
+			return err
+		}
+	}
+	return nil
+}
+
+// appID returns the ID of the named app, looking it up if it was not touched earlier in the plan
+func (r *Reconciler) appID(ctx context.Context, name string, appIDs map[string]string) (string, error) {
+	if id, ok := appIDs[name]; ok {
+		return id, nil
+	}
+	res, err := r.client.Apps.ListApps(apps.NewListAppsParams().WithContext(ctx).WithName(&name))
+	if err != nil {
+		return "", err
+	}
+	for _, app := range res.Payload.Items {
+		if app.Name == name {
+			appIDs[name] = app.ID
+			return app.ID, nil
+		}
+	}
+	return "", fmt.Errorf("app %s not found", name)
+}
+
+// fnID returns the ID of the named function, looking it up if it was not touched earlier in the plan
+func (r *Reconciler) fnID(ctx context.Context, appID, name, key string, fnIDs map[string]string) (string, error) {
+	if id, ok := fnIDs[key]; ok {
+		return id, nil
+	}
+	res, err := r.client.Fns.ListFns(fns.NewListFnsParams().WithContext(ctx).WithAppID(&appID).WithName(&name))
+	if err != nil {
+		return "", err
+	}
+	for _, fn := range res.Payload.Items {
+		if fn.Name == name {
+			fnIDs[key] = fn.ID
+			return fn.ID, nil
+		}
+	}
+	return "", fmt.Errorf("function %s not found", key)
+}
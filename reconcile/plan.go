@@ -0,0 +1,631 @@
+package reconcile
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/clientv2/triggers"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/pager"
+	"github.com/fnproject/fn_go/provider"
+)
+
+// Action is the kind of change made to a resource
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+var actionSymbols = map[Action]string{
+	ActionCreate: "+",
+	ActionUpdate: "~",
+	ActionDelete: "-",
+}
+
+// Change is a single operation in a plan
+type Change struct {
+	Action   Action
+	Resource provider.FnResourceType
+	// Path names the resource as app, app/fn or app/fn/trigger
+	Path string
+	// ID is the ID of the existing resource for updates and deletes
+	ID string
+	// Fields lists the fields that differ, for updates
+	Fields []string
+
+	appName string
+	fnName  string
+	app     *modelsv2.App
+	fn      *modelsv2.Fn
+	trigger *modelsv2.Trigger
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("%s %s %s", actionSymbols[c.Action], c.Resource, c.Path)
+	if len(c.Fields) > 0 {
+		s += fmt.Sprintf(" (%s)", strings.Join(c.Fields, ", "))
+	}
+	return s
+}
+
+// Plan is an ordered list of changes that brings the live state in line with the desired state. Creates are ordered
+// parents first and deletes children first so that the plan can be applied in order; a trigger that has to be replaced
+// is deleted before it is recreated.
+type Plan struct {
+	Changes []Change
+	// Skipped lists desired resources that were ignored because the provider does not support them
+	Skipped []string
+}
+
+// Empty is true if no changes are needed
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// String renders the plan one change per line, suitable for dry-run output
+func (p *Plan) String() string {
+	if p.Empty() && len(p.Skipped) == 0 {
+		return "No changes\n"
+	}
+	var b strings.Builder
+	for _, c := range p.Changes {
+		b.WriteString(c.String())
+		b.WriteString("\n")
+	}
+	for _, s := range p.Skipped {
+		fmt.Fprintf(&b, "! skipped %s\n", s)
+	}
+	return b.String()
+}
+
+// Options controls how a Reconciler plans changes
+type Options struct {
+	// Prune deletes functions and triggers of desired apps that are not in the desired state
+	Prune bool
+	// PruneApps deletes apps (and everything in them) that are not in the desired state
+	PruneApps bool
+	// SkipResources lists resource types that are neither read nor changed
+	SkipResources []provider.FnResourceType
+}
+
+// Reconciler plans and applies changes through an Fn API client
+type Reconciler struct {
+	client *clientv2.Fn
+	opts   Options
+}
+
+// NewReconciler creates a reconciler that works through client
+func NewReconciler(client *clientv2.Fn, opts Options) *Reconciler {
+	return &Reconciler{client: client, opts: opts}
+}
+
+// NewReconcilerForProvider creates a reconciler for a provider, skipping any resources that the provider cannot modify
+func NewReconcilerForProvider(p provider.Provider, opts Options) *Reconciler {
+	opts.SkipResources = append([]provider.FnResourceType{}, opts.SkipResources...)
+	for t, support := range provider.Capabilities(p) {
+		if support != provider.ResourceSupportFull {
+			opts.SkipResources = append(opts.SkipResources, t)
+		}
+	}
+	return NewReconciler(p.APIClientv2(), opts)
+}
+
+func (r *Reconciler) skip(t provider.FnResourceType) bool {
+	for _, s := range r.opts.SkipResources {
+		if s == t {
+			return true
+		}
+	}
+	return false
+}
+
+// changeSet accumulates changes by stage so that they can be emitted in dependency order
+type changeSet struct {
+	creates [3][]Change
+	updates [3][]Change
+	deletes [3][]Change
+	// replaced are deletes of resources that are recreated under the same name, they must come before the creates
+	replaced [3][]Change
+}
+
+var stages = map[provider.FnResourceType]int{
+	provider.ApplicationResourceType: 0,
+	provider.FunctionResourceType:    1,
+	provider.TriggerResourceType:     2,
+}
+
+func (cs *changeSet) add(c Change) {
+	stage := stages[c.Resource]
+	switch c.Action {
+	case ActionCreate:
+		cs.creates[stage] = append(cs.creates[stage], c)
+	case ActionUpdate:
+		cs.updates[stage] = append(cs.updates[stage], c)
+	case ActionDelete:
+		cs.deletes[stage] = append(cs.deletes[stage], c)
+	}
+}
+
+// replace adds the delete of a resource that is being replaced, so that it is emitted ahead of the create that replaces it
+func (cs *changeSet) replace(c Change) {
+	stage := stages[c.Resource]
+	cs.replaced[stage] = append(cs.replaced[stage], c)
+}
+
+func (cs *changeSet) ordered() []Change {
+	var result []Change
+	for stage := 0; stage < 3; stage++ {
+		result = append(result, cs.replaced[stage]...)
+		result = append(result, cs.creates[stage]...)
+		result = append(result, cs.updates[stage]...)
+	}
+	for stage := 2; stage >= 0; stage-- {
+		result = append(result, cs.deletes[stage]...)
+	}
+	return result
+}
+
+// Plan compares desired with the live state and returns the changes needed to reconcile them
+func (r *Reconciler) Plan(ctx context.Context, desired *State) (*Plan, error) {
+	if err := desired.Validate(); err != nil {
+		return nil, err
+	}
+
+	liveApps, err := pager.Apps(ctx, r.client, nil, pager.Options{}).All()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list apps: %w", err)
+	}
+	liveAppsByName := map[string]*modelsv2.App{}
+	for _, app := range liveApps {
+		liveAppsByName[app.Name] = app
+	}
+
+	plan := &Plan{}
+	cs := &changeSet{}
+	desiredAppNames := map[string]bool{}
+
+	for i := range desired.Apps {
+		desiredApp := &desired.Apps[i]
+		desiredAppNames[desiredApp.Name] = true
+
+		liveApp, exists := liveAppsByName[desiredApp.Name]
+		if !exists {
+			r.planNewApp(desiredApp, cs, plan)
+			continue
+		}
+
+		// list results may be summaries, get the full app for comparison
+		got, err := r.client.Apps.GetApp(apps.NewGetAppParams().WithContext(ctx).WithAppID(liveApp.ID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get app %s: %w", desiredApp.Name, err)
+		}
+		if err := r.planExistingApp(ctx, desiredApp, got.Payload, cs, plan); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.opts.PruneApps {
+		for _, liveApp := range liveApps {
+			if desiredAppNames[liveApp.Name] {
+				continue
+			}
+			if err := r.planAppDelete(ctx, liveApp, cs); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	plan.Changes = cs.ordered()
+	return plan, nil
+}
+
+func (r *Reconciler) planNewApp(desiredApp *App, cs *changeSet, plan *Plan) {
+	cs.add(Change{
+		Action:   ActionCreate,
+		Resource: provider.ApplicationResourceType,
+		Path:     desiredApp.Name,
+		appName:  desiredApp.Name,
+		app:      appModel(desiredApp),
+	})
+
+	for i := range desiredApp.Fns {
+		desiredFn := &desiredApp.Fns[i]
+		cs.add(Change{
+			Action:   ActionCreate,
+			Resource: provider.FunctionResourceType,
+			Path:     desiredApp.Name + "/" + desiredFn.Name,
+			appName:  desiredApp.Name,
+			fnName:   desiredFn.Name,
+			fn:       fnModel(desiredFn),
+		})
+		r.planNewTriggers(desiredApp.Name, desiredFn, cs, plan)
+	}
+}
+
+func (r *Reconciler) planNewTriggers(appName string, desiredFn *Fn, cs *changeSet, plan *Plan) {
+	for i := range desiredFn.Triggers {
+		desiredTrigger := &desiredFn.Triggers[i]
+		path := appName + "/" + desiredFn.Name + "/" + desiredTrigger.Name
+		if r.skip(provider.TriggerResourceType) {
+			plan.Skipped = append(plan.Skipped, fmt.Sprintf("%s %s", provider.TriggerResourceType, path))
+			continue
+		}
+		cs.add(Change{
+			Action:   ActionCreate,
+			Resource: provider.TriggerResourceType,
+			Path:     path,
+			appName:  appName,
+			fnName:   desiredFn.Name,
+			trigger:  triggerModel(desiredTrigger),
+		})
+	}
+}
+
+func (r *Reconciler) planExistingApp(ctx context.Context, desiredApp *App, liveApp *modelsv2.App, cs *changeSet, plan *Plan) error {
+	// the shape of an app is fixed when it is created, so a different one can't be planned as an update
+	if desiredApp.Shape != "" && liveApp.Shape != "" && desiredApp.Shape != liveApp.Shape {
+		return fmt.Errorf("app %s has shape %s, it can't be changed to %s without recreating the app", desiredApp.Name, liveApp.Shape, desiredApp.Shape)
+	}
+
+	update := &modelsv2.App{}
+	var fields []string
+	if desiredApp.SyslogURL != "" && (liveApp.SyslogURL == nil || *liveApp.SyslogURL != desiredApp.SyslogURL) {
+		syslogURL := desiredApp.SyslogURL
+		update.SyslogURL = &syslogURL
+		fields = append(fields, "syslog_url")
+	}
+	if change := configChange(liveApp.Config, desiredApp.Config); change != nil {
+		update.Config = change
+		fields = append(fields, "config")
+	}
+	if change := annotationsChange(liveApp.Annotations, desiredApp.Annotations); change != nil {
+		update.Annotations = change
+		fields = append(fields, "annotations")
+	}
+	if len(fields) > 0 {
+		cs.add(Change{
+			Action:   ActionUpdate,
+			Resource: provider.ApplicationResourceType,
+			Path:     desiredApp.Name,
+			ID:       liveApp.ID,
+			Fields:   fields,
+			appName:  desiredApp.Name,
+			app:      update,
+		})
+	}
+
+	liveFns, err := pager.Fns(ctx, r.client, fns.NewListFnsParams().WithAppID(&liveApp.ID), pager.Options{}).All()
+	if err != nil {
+		return fmt.Errorf("failed to list functions of app %s: %w", desiredApp.Name, err)
+	}
+	liveFnsByName := map[string]*modelsv2.Fn{}
+	liveFnsByID := map[string]*modelsv2.Fn{}
+	for _, fn := range liveFns {
+		liveFnsByName[fn.Name] = fn
+		liveFnsByID[fn.ID] = fn
+	}
+
+	var liveTriggers []*modelsv2.Trigger
+	if !r.skip(provider.TriggerResourceType) {
+		liveTriggers, err = pager.Triggers(ctx, r.client, triggers.NewListTriggersParams().WithAppID(&liveApp.ID), pager.Options{}).All()
+		if err != nil {
+			return fmt.Errorf("failed to list triggers of app %s: %w", desiredApp.Name, err)
+		}
+	}
+	existing := &existingTriggers{
+		byName:  map[string]*modelsv2.Trigger{},
+		fnsByID: liveFnsByID,
+		desired: map[string]bool{},
+	}
+	for _, trigger := range liveTriggers {
+		existing.byName[trigger.Name] = trigger
+	}
+
+	desiredFnNames := map[string]bool{}
+	for i := range desiredApp.Fns {
+		desiredFn := &desiredApp.Fns[i]
+		desiredFnNames[desiredFn.Name] = true
+		fnPath := desiredApp.Name + "/" + desiredFn.Name
+
+		liveFn, exists := liveFnsByName[desiredFn.Name]
+		if !exists {
+			cs.add(Change{
+				Action:   ActionCreate,
+				Resource: provider.FunctionResourceType,
+				Path:     fnPath,
+				appName:  desiredApp.Name,
+				fnName:   desiredFn.Name,
+				fn:       fnModel(desiredFn),
+			})
+			// a trigger that already exists elsewhere in the app is moved to the new function
+			r.planTriggers(desiredApp.Name, desiredFn, "", existing, cs, plan)
+			continue
+		}
+
+		got, err := r.client.Fns.GetFn(fns.NewGetFnParams().WithContext(ctx).WithFnID(liveFn.ID))
+		if err != nil {
+			return fmt.Errorf("failed to get function %s: %w", fnPath, err)
+		}
+		planFnUpdate(desiredApp.Name, desiredFn, got.Payload, cs)
+		r.planTriggers(desiredApp.Name, desiredFn, liveFn.ID, existing, cs, plan)
+	}
+
+	if !r.opts.Prune {
+		return nil
+	}
+	for _, liveTrigger := range liveTriggers {
+		if !existing.desired[liveTrigger.Name] {
+			cs.add(triggerDelete(desiredApp.Name, liveFnsByID, liveTrigger))
+		}
+	}
+	for _, liveFn := range liveFns {
+		if !desiredFnNames[liveFn.Name] {
+			cs.add(Change{
+				Action:   ActionDelete,
+				Resource: provider.FunctionResourceType,
+				Path:     desiredApp.Name + "/" + liveFn.Name,
+				ID:       liveFn.ID,
+			})
+		}
+	}
+	return nil
+}
+
+// existingTriggers are the live triggers of an existing app, along with the names of the desired triggers planned so far
+type existingTriggers struct {
+	byName  map[string]*modelsv2.Trigger
+	fnsByID map[string]*modelsv2.Fn
+	desired map[string]bool
+}
+
+// planTriggers plans the desired triggers of a function in an existing app, liveFnID is empty if the function is being
+// created
+func (r *Reconciler) planTriggers(appName string, desiredFn *Fn, liveFnID string, existing *existingTriggers, cs *changeSet, plan *Plan) {
+	fnPath := appName + "/" + desiredFn.Name
+	for i := range desiredFn.Triggers {
+		desiredTrigger := &desiredFn.Triggers[i]
+		existing.desired[desiredTrigger.Name] = true
+		triggerPath := fnPath + "/" + desiredTrigger.Name
+		if r.skip(provider.TriggerResourceType) {
+			plan.Skipped = append(plan.Skipped, fmt.Sprintf("%s %s", provider.TriggerResourceType, triggerPath))
+			continue
+		}
+
+		liveTrigger, exists := existing.byName[desiredTrigger.Name]
+		if exists && (liveTrigger.FnID != liveFnID || liveTrigger.Type != desiredTrigger.Type) {
+			// a trigger can't be moved between functions or change type, so it is replaced. The old trigger is
+			// deleted first as trigger names are unique within an app.
+			cs.replace(triggerDelete(appName, existing.fnsByID, liveTrigger))
+			exists = false
+		}
+		if !exists {
+			cs.add(Change{
+				Action:   ActionCreate,
+				Resource: provider.TriggerResourceType,
+				Path:     triggerPath,
+				appName:  appName,
+				fnName:   desiredFn.Name,
+				trigger:  triggerModel(desiredTrigger),
+			})
+			continue
+		}
+		planTriggerUpdate(triggerPath, desiredTrigger, liveTrigger, cs)
+	}
+}
+
+func planFnUpdate(appName string, desiredFn *Fn, liveFn *modelsv2.Fn, cs *changeSet) {
+	update := &modelsv2.Fn{}
+	var fields []string
+	if desiredFn.Image != "" && desiredFn.Image != liveFn.Image {
+		update.Image = desiredFn.Image
+		fields = append(fields, "image")
+	}
+	if desiredFn.Memory != 0 && desiredFn.Memory != liveFn.Memory {
+		update.Memory = desiredFn.Memory
+		fields = append(fields, "memory")
+	}
+	if desiredFn.Timeout != nil && (liveFn.Timeout == nil || *liveFn.Timeout != *desiredFn.Timeout) {
+		update.Timeout = desiredFn.Timeout
+		fields = append(fields, "timeout")
+	}
+	if desiredFn.IdleTimeout != nil && (liveFn.IdleTimeout == nil || *liveFn.IdleTimeout != *desiredFn.IdleTimeout) {
+		update.IdleTimeout = desiredFn.IdleTimeout
+		fields = append(fields, "idle_timeout")
+	}
+	if change := configChange(liveFn.Config, desiredFn.Config); change != nil {
+		update.Config = change
+		fields = append(fields, "config")
+	}
+	if change := annotationsChange(liveFn.Annotations, desiredFn.Annotations); change != nil {
+		update.Annotations = change
+		fields = append(fields, "annotations")
+	}
+	if len(fields) == 0 {
+		return
+	}
+	cs.add(Change{
+		Action:   ActionUpdate,
+		Resource: provider.FunctionResourceType,
+		Path:     appName + "/" + desiredFn.Name,
+		ID:       liveFn.ID,
+		Fields:   fields,
+		appName:  appName,
+		fnName:   desiredFn.Name,
+		fn:       update,
+	})
+}
+
+func planTriggerUpdate(path string, desiredTrigger *Trigger, liveTrigger *modelsv2.Trigger, cs *changeSet) {
+	update := &modelsv2.Trigger{}
+	var fields []string
+	if desiredTrigger.Source != liveTrigger.Source {
+		update.Source = desiredTrigger.Source
+		fields = append(fields, "source")
+	}
+	if change := annotationsChange(liveTrigger.Annotations, desiredTrigger.Annotations); change != nil {
+		update.Annotations = change
+		fields = append(fields, "annotations")
+	}
+	if len(fields) == 0 {
+		return
+	}
+	cs.add(Change{
+		Action:   ActionUpdate,
+		Resource: provider.TriggerResourceType,
+		Path:     path,
+		ID:       liveTrigger.ID,
+		Fields:   fields,
+		trigger:  update,
+	})
+}
+
+func triggerDelete(appName string, liveFnsByID map[string]*modelsv2.Fn, liveTrigger *modelsv2.Trigger) Change {
+	fnName := liveTrigger.FnID
+	if fn, ok := liveFnsByID[liveTrigger.FnID]; ok {
+		fnName = fn.Name
+	}
+	return Change{
+		Action:   ActionDelete,
+		Resource: provider.TriggerResourceType,
+		Path:     appName + "/" + fnName + "/" + liveTrigger.Name,
+		ID:       liveTrigger.ID,
+	}
+}
+
+// planAppDelete deletes an app after its triggers and functions, as not every provider cascades deletes
+func (r *Reconciler) planAppDelete(ctx context.Context, liveApp *modelsv2.App, cs *changeSet) error {
+	liveFns, err := pager.Fns(ctx, r.client, fns.NewListFnsParams().WithAppID(&liveApp.ID), pager.Options{}).All()
+	if err != nil {
+		return fmt.Errorf("failed to list functions of app %s: %w", liveApp.Name, err)
+	}
+	liveFnsByID := map[string]*modelsv2.Fn{}
+	for _, fn := range liveFns {
+		liveFnsByID[fn.ID] = fn
+		cs.add(Change{
+			Action:   ActionDelete,
+			Resource: provider.FunctionResourceType,
+			Path:     liveApp.Name + "/" + fn.Name,
+			ID:       fn.ID,
+		})
+	}
+
+	if !r.skip(provider.TriggerResourceType) {
+		liveTriggers, err := pager.Triggers(ctx, r.client, triggers.NewListTriggersParams().WithAppID(&liveApp.ID), pager.Options{}).All()
+		if err != nil {
+			return fmt.Errorf("failed to list triggers of app %s: %w", liveApp.Name, err)
+		}
+		for _, trigger := range liveTriggers {
+			cs.add(triggerDelete(liveApp.Name, liveFnsByID, trigger))
+		}
+	}
+
+	cs.add(Change{
+		Action:   ActionDelete,
+		Resource: provider.ApplicationResourceType,
+		Path:     liveApp.Name,
+		ID:       liveApp.ID,
+	})
+	return nil
+}
+
+// configChange returns the config update that turns live into desired (using empty values to remove keys), or nil if
+// they are the same. A nil desired config leaves the live config as it is, an empty one removes every key.
+func configChange(live, desired map[string]string) map[string]string {
+	if desired == nil {
+		return nil
+	}
+	change := map[string]string{}
+	for k, v := range desired {
+		if live[k] != v {
+			change[k] = v
+		}
+	}
+	for k := range live {
+		if _, ok := desired[k]; !ok {
+			change[k] = ""
+		}
+	}
+	if len(change) == 0 {
+		return nil
+	}
+	return change
+}
+
+// annotationsChange returns the desired annotations that differ from live, or nil if there are none. Annotations that
+// are not in desired are left alone as servers and providers add their own.
+func annotationsChange(live, desired map[string]interface{}) map[string]interface{} {
+	change := map[string]interface{}{}
+	keys := make([]string, 0, len(desired))
+	for k := range desired {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		liveValue, ok := live[k]
+		if !ok || !jsonEqual(liveValue, desired[k]) {
+			change[k] = desired[k]
+		}
+	}
+	if len(change) == 0 {
+		return nil
+	}
+	return change
+}
+
+// jsonEqual compares values by their JSON representation so that e.g. YAML integers match JSON numbers
+func jsonEqual(a, b interface{}) bool {
+	var na, nb interface{}
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	json.Unmarshal(ja, &na)
+	json.Unmarshal(jb, &nb)
+	return reflect.DeepEqual(na, nb)
+}
+
+func appModel(app *App) *modelsv2.App {
+	model := &modelsv2.App{
+		Name:        app.Name,
+		Shape:       app.Shape,
+		Config:      app.Config,
+		Annotations: app.Annotations,
+	}
+	if app.SyslogURL != "" {
+		syslogURL := app.SyslogURL
+		model.SyslogURL = &syslogURL
+	}
+	return model
+}
+
+func fnModel(fn *Fn) *modelsv2.Fn {
+	return &modelsv2.Fn{
+		Name:        fn.Name,
+		Image:       fn.Image,
+		Memory:      fn.Memory,
+		Timeout:     fn.Timeout,
+		IdleTimeout: fn.IdleTimeout,
+		Config:      fn.Config,
+		Annotations: fn.Annotations,
+	}
+}
+
+func triggerModel(trigger *Trigger) *modelsv2.Trigger {
+	return &modelsv2.Trigger{
+		Name:        trigger.Name,
+		Type:        trigger.Type,
+		Source:      trigger.Source,
+		Annotations: trigger.Annotations,
+	}
+}
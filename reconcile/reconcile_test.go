@@ -0,0 +1,426 @@
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fnproject/fn_go/clientv2"
+	"github.com/fnproject/fn_go/clientv2/apps"
+	"github.com/fnproject/fn_go/clientv2/fns"
+	"github.com/fnproject/fn_go/clientv2/triggers"
+	"github.com/fnproject/fn_go/modelsv2"
+	"github.com/fnproject/fn_go/provider"
+	"github.com/go-openapi/runtime"
+	"github.com/stretchr/testify/assert"
+)
+
+// store is a minimal in-memory backend for the apps, fns and triggers client services
+type store struct {
+	nextID   int
+	apps     []*modelsv2.App
+	fns      []*modelsv2.Fn
+	triggers []*modelsv2.Trigger
+	calls    []string
+}
+
+func (s *store) id() string {
+	s.nextID++
+	return fmt.Sprintf("id%d", s.nextID)
+}
+
+func (s *store) client() *clientv2.Fn {
+	return &clientv2.Fn{Apps: &fakeApps{s}, Fns: &fakeFns{s}, Triggers: &fakeTriggers{s}}
+}
+
+func merge(live, change map[string]string) map[string]string {
+	if live == nil {
+		live = map[string]string{}
+	}
+	for k, v := range change {
+		if v == "" {
+			delete(live, k)
+		} else {
+			live[k] = v
+		}
+	}
+	return live
+}
+
+type fakeApps struct{ s *store }
+
+func (f *fakeApps) CreateApp(params *apps.CreateAppParams) (*apps.CreateAppOK, error) {
+	f.s.calls = append(f.s.calls, "create app "+params.Body.Name)
+	app := *params.Body
+	app.ID = f.s.id()
+	f.s.apps = append(f.s.apps, &app)
+	return &apps.CreateAppOK{Payload: &app}, nil
+}
+
+func (f *fakeApps) DeleteApp(params *apps.DeleteAppParams) (*apps.DeleteAppNoContent, error) {
+	f.s.calls = append(f.s.calls, "delete app "+params.AppID)
+	for i, app := range f.s.apps {
+		if app.ID == params.AppID {
+			f.s.apps = append(f.s.apps[:i], f.s.apps[i+1:]...)
+			break
+		}
+	}
+	return &apps.DeleteAppNoContent{}, nil
+}
+
+func (f *fakeApps) GetApp(params *apps.GetAppParams) (*apps.GetAppOK, error) {
+	for _, app := range f.s.apps {
+		if app.ID == params.AppID {
+			return &apps.GetAppOK{Payload: app}, nil
+		}
+	}
+	return nil, fmt.Errorf("app %s not found", params.AppID)
+}
+
+func (f *fakeApps) ListApps(params *apps.ListAppsParams) (*apps.ListAppsOK, error) {
+	list := &modelsv2.AppList{}
+	for _, app := range f.s.apps {
+		if params.Name == nil || *params.Name == app.Name {
+			list.Items = append(list.Items, app)
+		}
+	}
+	return &apps.ListAppsOK{Payload: list}, nil
+}
+
+func (f *fakeApps) UpdateApp(params *apps.UpdateAppParams) (*apps.UpdateAppOK, error) {
+	f.s.calls = append(f.s.calls, "update app "+params.AppID)
+	got, err := f.GetApp(&apps.GetAppParams{AppID: params.AppID})
+	if err != nil {
+		return nil, err
+	}
+	app := got.Payload
+	app.Config = merge(app.Config, params.Body.Config)
+	if params.Body.SyslogURL != nil {
+		app.SyslogURL = params.Body.SyslogURL
+	}
+	return &apps.UpdateAppOK{Payload: app}, nil
+}
+
+func (f *fakeApps) SetTransport(runtime.ClientTransport) {}
+
+type fakeFns struct{ s *store }
+
+func (f *fakeFns) CreateFn(params *fns.CreateFnParams) (*fns.CreateFnOK, error) {
+	f.s.calls = append(f.s.calls, "create fn "+params.Body.Name)
+	fn := *params.Body
+	fn.ID = f.s.id()
+	f.s.fns = append(f.s.fns, &fn)
+	return &fns.CreateFnOK{Payload: &fn}, nil
+}
+
+func (f *fakeFns) DeleteFn(params *fns.DeleteFnParams) (*fns.DeleteFnNoContent, error) {
+	f.s.calls = append(f.s.calls, "delete fn "+params.FnID)
+	for i, fn := range f.s.fns {
+		if fn.ID == params.FnID {
+			f.s.fns = append(f.s.fns[:i], f.s.fns[i+1:]...)
+			break
+		}
+	}
+	return &fns.DeleteFnNoContent{}, nil
+}
+
+func (f *fakeFns) GetFn(params *fns.GetFnParams) (*fns.GetFnOK, error) {
+	for _, fn := range f.s.fns {
+		if fn.ID == params.FnID {
+			return &fns.GetFnOK{Payload: fn}, nil
+		}
+	}
+	return nil, fmt.Errorf("fn %s not found", params.FnID)
+}
+
+func (f *fakeFns) ListFns(params *fns.ListFnsParams) (*fns.ListFnsOK, error) {
+	list := &modelsv2.FnList{}
+	for _, fn := range f.s.fns {
+		if (params.AppID == nil || *params.AppID == fn.AppID) && (params.Name == nil || *params.Name == fn.Name) {
+			// list results are summaries without config
+			summary := *fn
+			summary.Config = nil
+			list.Items = append(list.Items, &summary)
+		}
+	}
+	return &fns.ListFnsOK{Payload: list}, nil
+}
+
+func (f *fakeFns) UpdateFn(params *fns.UpdateFnParams) (*fns.UpdateFnOK, error) {
+	f.s.calls = append(f.s.calls, "update fn "+params.FnID)
+	got, err := f.GetFn(&fns.GetFnParams{FnID: params.FnID})
+	if err != nil {
+		return nil, err
+	}
+	fn := got.Payload
+	fn.Config = merge(fn.Config, params.Body.Config)
+	if params.Body.Image != "" {
+		fn.Image = params.Body.Image
+	}
+	return &fns.UpdateFnOK{Payload: fn}, nil
+}
+
+func (f *fakeFns) SetTransport(runtime.ClientTransport) {}
+
+type fakeTriggers struct{ s *store }
+
+func (f *fakeTriggers) CreateTrigger(params *triggers.CreateTriggerParams) (*triggers.CreateTriggerOK, error) {
+	f.s.calls = append(f.s.calls, "create trigger "+params.Body.Name)
+	for _, trigger := range f.s.triggers {
+		if trigger.AppID == params.Body.AppID && trigger.Name == params.Body.Name {
+			return nil, fmt.Errorf("trigger %s already exists", params.Body.Name)
+		}
+	}
+	trigger := *params.Body
+	trigger.ID = f.s.id()
+	f.s.triggers = append(f.s.triggers, &trigger)
+	return &triggers.CreateTriggerOK{Payload: &trigger}, nil
+}
+
+func (f *fakeTriggers) DeleteTrigger(params *triggers.DeleteTriggerParams) (*triggers.DeleteTriggerNoContent, error) {
+	f.s.calls = append(f.s.calls, "delete trigger "+params.TriggerID)
+	for i, trigger := range f.s.triggers {
+		if trigger.ID == params.TriggerID {
+			f.s.triggers = append(f.s.triggers[:i], f.s.triggers[i+1:]...)
+			break
+		}
+	}
+	return &triggers.DeleteTriggerNoContent{}, nil
+}
+
+func (f *fakeTriggers) GetTrigger(params *triggers.GetTriggerParams) (*triggers.GetTriggerOK, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (f *fakeTriggers) ListTriggers(params *triggers.ListTriggersParams) (*triggers.ListTriggersOK, error) {
+	list := &modelsv2.TriggerList{}
+	for _, trigger := range f.s.triggers {
+		if params.AppID == nil || *params.AppID == trigger.AppID {
+			list.Items = append(list.Items, trigger)
+		}
+	}
+	return &triggers.ListTriggersOK{Payload: list}, nil
+}
+
+func (f *fakeTriggers) UpdateTrigger(params *triggers.UpdateTriggerParams) (*triggers.UpdateTriggerOK, error) {
+	f.s.calls = append(f.s.calls, "update trigger "+params.TriggerID)
+	return &triggers.UpdateTriggerOK{Payload: params.Body}, nil
+}
+
+func (f *fakeTriggers) SetTransport(runtime.ClientTransport) {}
+
+const stateDoc = `
+apps:
+  - name: myapp
+    config:
+      LOG_LEVEL: debug
+    functions:
+      - name: hello
+        image: fnproject/hello:0.0.2
+        memory: 256
+        config:
+          GREETING: hi
+        triggers:
+          - name: hello-http
+            type: http
+            source: /hello
+`
+
+func TestPlanAndApplyCreatesThenConverges(t *testing.T) {
+	state, err := LoadState(strings.NewReader(stateDoc))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	s := &store{}
+	r := NewReconciler(s.client(), Options{})
+
+	plan, err := r.Plan(context.Background(), state)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "+ app myapp\n+ function myapp/hello\n+ trigger myapp/hello/hello-http\n", plan.String())
+
+	assert.NoError(t, r.Apply(context.Background(), plan))
+	if assert.Len(t, s.fns, 1) && assert.Len(t, s.triggers, 1) {
+		assert.Equal(t, s.apps[0].ID, s.fns[0].AppID)
+		assert.Equal(t, s.fns[0].ID, s.triggers[0].FnID)
+	}
+
+	plan, err = r.Plan(context.Background(), state)
+	assert.NoError(t, err)
+	assert.True(t, plan.Empty(), "expected no changes, got %s", plan)
+}
+
+func TestPlanUpdatesAndPrunes(t *testing.T) {
+	s := &store{
+		apps: []*modelsv2.App{{ID: "app1", Name: "myapp", Config: map[string]string{"LOG_LEVEL": "info", "OLD": "x"}}},
+		fns: []*modelsv2.Fn{
+			{ID: "fn1", AppID: "app1", Name: "hello", Image: "fnproject/hello:0.0.1", Memory: 256, Config: map[string]string{"GREETING": "hi"}},
+			{ID: "fn2", AppID: "app1", Name: "stale", Image: "fnproject/stale:0.0.1"},
+		},
+		triggers: []*modelsv2.Trigger{{ID: "t1", AppID: "app1", FnID: "fn2", Name: "stale-http", Type: "http", Source: "/stale"}},
+	}
+	state, err := LoadState(strings.NewReader(stateDoc))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := NewReconciler(s.client(), Options{Prune: true})
+	plan, err := r.Plan(context.Background(), state)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, strings.Join([]string{
+		"~ app myapp (config)",
+		"~ function myapp/hello (image)",
+		"+ trigger myapp/hello/hello-http",
+		"- trigger myapp/stale/stale-http",
+		"- function myapp/stale",
+	}, "\n")+"\n", plan.String())
+
+	assert.NoError(t, r.Apply(context.Background(), plan))
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "debug"}, s.apps[0].Config)
+	assert.Len(t, s.fns, 1)
+	if assert.Len(t, s.triggers, 1) {
+		assert.Equal(t, "fn1", s.triggers[0].FnID)
+	}
+}
+
+func TestPlanReplacesMovedTriggers(t *testing.T) {
+	s := &store{
+		apps: []*modelsv2.App{{ID: "app1", Name: "myapp", Config: map[string]string{"LOG_LEVEL": "debug"}}},
+		fns: []*modelsv2.Fn{
+			{ID: "fn1", AppID: "app1", Name: "old", Image: "fnproject/hello:0.0.2"},
+			{ID: "fn2", AppID: "app1", Name: "hello", Image: "fnproject/hello:0.0.2", Memory: 256, Config: map[string]string{"GREETING": "hi"}},
+		},
+		triggers: []*modelsv2.Trigger{{ID: "t1", AppID: "app1", FnID: "fn1", Name: "hello-http", Type: "http", Source: "/hello"}},
+	}
+	state, err := LoadState(strings.NewReader(stateDoc))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := NewReconciler(s.client(), Options{})
+	plan, err := r.Plan(context.Background(), state)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "- trigger myapp/old/hello-http\n+ trigger myapp/hello/hello-http\n", plan.String())
+
+	assert.NoError(t, r.Apply(context.Background(), plan))
+	if assert.Len(t, s.triggers, 1) {
+		assert.Equal(t, "fn2", s.triggers[0].FnID)
+	}
+}
+
+func TestPlanReplacesTriggersMovedToNewFunctions(t *testing.T) {
+	s := &store{
+		apps:     []*modelsv2.App{{ID: "app1", Name: "myapp", Config: map[string]string{"LOG_LEVEL": "debug"}}},
+		fns:      []*modelsv2.Fn{{ID: "fn1", AppID: "app1", Name: "old", Image: "fnproject/hello:0.0.2"}},
+		triggers: []*modelsv2.Trigger{{ID: "t1", AppID: "app1", FnID: "fn1", Name: "hello-http", Type: "http", Source: "/hello"}},
+	}
+	state, err := LoadState(strings.NewReader(stateDoc))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	r := NewReconciler(s.client(), Options{Prune: true})
+	plan, err := r.Plan(context.Background(), state)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, strings.Join([]string{
+		"+ function myapp/hello",
+		"- trigger myapp/old/hello-http",
+		"+ trigger myapp/hello/hello-http",
+		"- function myapp/old",
+	}, "\n")+"\n", plan.String())
+
+	assert.NoError(t, r.Apply(context.Background(), plan))
+	if assert.Len(t, s.fns, 1) && assert.Len(t, s.triggers, 1) {
+		assert.Equal(t, s.fns[0].ID, s.triggers[0].FnID)
+	}
+}
+
+func TestPlanRejectsShapeChanges(t *testing.T) {
+	s := &store{apps: []*modelsv2.App{{ID: "app1", Name: "myapp", Shape: "GENERIC_X86"}}}
+	r := NewReconciler(s.client(), Options{})
+
+	state := &State{Apps: []App{{Name: "myapp", Shape: "GENERIC_ARM"}}}
+	_, err := r.Plan(context.Background(), state)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "app myapp has shape GENERIC_X86, it can't be changed to GENERIC_ARM")
+	}
+
+	state.Apps[0].Shape = "GENERIC_X86"
+	plan, err := r.Plan(context.Background(), state)
+	if assert.NoError(t, err) {
+		assert.True(t, plan.Empty(), "expected no changes for the same shape, got %s", plan)
+	}
+}
+
+func TestPlanLeavesConfigAloneWhenOmitted(t *testing.T) {
+	s := &store{
+		apps: []*modelsv2.App{{ID: "app1", Name: "myapp", Config: map[string]string{"DB_URL": "postgres://prod"}}},
+		fns:  []*modelsv2.Fn{{ID: "fn1", AppID: "app1", Name: "hello", Image: "fnproject/hello:0.0.2", Config: map[string]string{"GREETING": "hi"}}},
+	}
+	r := NewReconciler(s.client(), Options{})
+
+	state, err := LoadState(strings.NewReader(`
+apps:
+  - name: myapp
+    functions:
+      - name: hello
+        image: fnproject/hello:0.0.2
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	plan, err := r.Plan(context.Background(), state)
+	if assert.NoError(t, err) {
+		assert.True(t, plan.Empty(), "expected omitted config to be left alone, got %s", plan)
+	}
+
+	state, err = LoadState(strings.NewReader(`
+apps:
+  - name: myapp
+    config: {}
+    functions:
+      - name: hello
+        image: fnproject/hello:0.0.2
+`))
+	if !assert.NoError(t, err) {
+		return
+	}
+	plan, err = r.Plan(context.Background(), state)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "~ app myapp (config)\n", plan.String())
+	assert.NoError(t, r.Apply(context.Background(), plan))
+	assert.Empty(t, s.apps[0].Config)
+	assert.Equal(t, map[string]string{"GREETING": "hi"}, s.fns[0].Config)
+}
+
+func TestPlanSkipsUnavailableResources(t *testing.T) {
+	state, err := LoadState(strings.NewReader(stateDoc))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	s := &store{}
+	r := NewReconciler(s.client(), Options{SkipResources: []provider.FnResourceType{provider.TriggerResourceType}})
+	plan, err := r.Plan(context.Background(), state)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, plan.Changes, 2)
+	assert.Equal(t, []string{"trigger myapp/hello/hello-http"}, plan.Skipped)
+}
+
+func TestValidateRejectsDuplicates(t *testing.T) {
+	state := &State{Apps: []App{{Name: "a", Fns: []Fn{{Name: "f", Image: "i"}, {Name: "f", Image: "i"}}}}}
+	assert.Error(t, state.Validate())
+}
@@ -0,0 +1,100 @@
+// Package reconcile synchronises Fn applications, functions and triggers with a declarative description of the
+// desired state.
+//
+// A Reconciler computes a Plan by comparing the desired state with what is currently deployed, the plan can be
+// printed (as a dry run) and then applied. Reconcilers work through a *clientv2.Fn and so behave the same for every
+// provider.
+package reconcile
+
+import (
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// State is the desired set of applications together with their functions and triggers
+type State struct {
+	Apps []App `yaml:"apps" json:"apps"`
+}
+
+// App is the desired state of an application. Settings that are omitted, config included, are left as they are on the
+// live app; an empty config removes every key. Shape is only applied when the app is created, a plan that would change
+// the shape of an existing app fails.
+type App struct {
+	Name        string                 `yaml:"name" json:"name"`
+	Shape       string                 `yaml:"shape,omitempty" json:"shape,omitempty"`
+	SyslogURL   string                 `yaml:"syslog_url,omitempty" json:"syslog_url,omitempty"`
+	Config      map[string]string      `yaml:"config,omitempty" json:"config,omitempty"`
+	Annotations map[string]interface{} `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+	Fns         []Fn                   `yaml:"functions,omitempty" json:"functions,omitempty"`
+}
+
+// Fn is the desired state of a function, omitted settings are left as they are like those of App
+type Fn struct {
+	Name        string                 `yaml:"name" json:"name"`
+	Image       string                 `yaml:"image" json:"image"`
+	Memory      uint64                 `yaml:"memory,omitempty" json:"memory,omitempty"`
+	Timeout     *int32                 `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	IdleTimeout *int32                 `yaml:"idle_timeout,omitempty" json:"idle_timeout,omitempty"`
+	Config      map[string]string      `yaml:"config,omitempty" json:"config,omitempty"`
+	Annotations map[string]interface{} `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+	Triggers    []Trigger              `yaml:"triggers,omitempty" json:"triggers,omitempty"`
+}
+
+// Trigger is the desired state of a trigger
+type Trigger struct {
+	Name        string                 `yaml:"name" json:"name"`
+	Type        string                 `yaml:"type" json:"type"`
+	Source      string                 `yaml:"source" json:"source"`
+	Annotations map[string]interface{} `yaml:"annotations,omitempty" json:"annotations,omitempty"`
+}
+
+// LoadState reads a desired state document in YAML (or JSON) format
+func LoadState(r io.Reader) (*State, error) {
+	state := &State{}
+	if err := yaml.NewDecoder(r).Decode(state); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("invalid state document: %s", err)
+	}
+	if err := state.Validate(); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// Validate checks that every resource is named and that names are unique within their app
+func (s *State) Validate() error {
+	appNames := map[string]bool{}
+	for _, app := range s.Apps {
+		if app.Name == "" {
+			return fmt.Errorf("app with no name in desired state")
+		}
+		if appNames[app.Name] {
+			return fmt.Errorf("app %s is declared more than once", app.Name)
+		}
+		appNames[app.Name] = true
+
+		fnNames := map[string]bool{}
+		triggerNames := map[string]bool{}
+		for _, fn := range app.Fns {
+			if fn.Name == "" {
+				return fmt.Errorf("function with no name in app %s", app.Name)
+			}
+			if fnNames[fn.Name] {
+				return fmt.Errorf("function %s/%s is declared more than once", app.Name, fn.Name)
+			}
+			fnNames[fn.Name] = true
+
+			for _, trigger := range fn.Triggers {
+				if trigger.Name == "" {
+					return fmt.Errorf("trigger with no name on function %s/%s", app.Name, fn.Name)
+				}
+				if triggerNames[trigger.Name] {
+					return fmt.Errorf("trigger %s is declared more than once in app %s", trigger.Name, app.Name)
+				}
+				triggerNames[trigger.Name] = true
+			}
+		}
+	}
+	return nil
+}